@@ -0,0 +1,146 @@
+package viper
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// WithBase64Fields makes Unmarshal, UnmarshalKey and UnmarshalExact decode base64 strings
+// (standard encoding, with or without padding) into []byte fields tagged with `viper:"base64"`,
+// eg. to read TLS keys stored base64 encoded in config files:
+//
+//	type TLS struct {
+//		Key []byte `viper:"base64"`
+//	}
+//
+// Untagged []byte fields are decoded as usual.
+func WithBase64Fields() Option {
+	return optionFunc(func(v *Viper) {
+		v.base64Fields = true
+	})
+}
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// base64FieldsHookFunc returns a decode hook decoding the base64 strings of the maps structs are decoded from
+// for the fields tagged with `viper:"base64"` (see WithBase64Fields).
+func (v *Viper) base64FieldsHookFunc(config *mapstructure.DecoderConfig) mapstructure.DecodeHookFuncType {
+	return func(_ reflect.Type, t reflect.Type, data any) (any, error) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		input, ok := data.(map[string]any)
+		if !ok || t.Kind() != reflect.Struct {
+			return data, nil
+		}
+
+		tagName := config.TagName
+		if tagName == "" {
+			tagName = "mapstructure"
+		}
+
+		var (
+			out  map[string]any
+			errs []error
+		)
+
+		v.walkStructFields(t, tagName, config.Squash, "", nil, func(key string, field reflect.StructField) {
+			if field.Type != bytesType || !slices.Contains(strings.Split(field.Tag.Get("viper"), ","), "base64") {
+				return
+			}
+
+			path := strings.Split(key, v.keyDelim)
+
+			s, ok := searchFold(input, path).(string)
+			if !ok {
+				return
+			}
+
+			b, err := decodeBase64(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("decode base64 value of %s: %w", key, err))
+
+				return
+			}
+
+			if out == nil {
+				out = deepCopyMap(input)
+			}
+
+			setValueFold(out, path, b)
+		})
+
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+
+		if out == nil {
+			return data, nil
+		}
+
+		return out, nil
+	}
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "=") {
+		return base64.StdEncoding.DecodeString(s)
+	}
+
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// searchFold returns the value of a path in nested maps, matching keys case-insensitively.
+func searchFold(m map[string]any, path []string) any {
+	for i, k := range path {
+		val, ok := lookupFold(m, k)
+		if !ok {
+			return nil
+		}
+
+		if i == len(path)-1 {
+			return val
+		}
+
+		if m, ok = val.(map[string]any); !ok {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// setValueFold replaces the value of an existing path in nested maps, matching keys case-insensitively.
+func setValueFold(m map[string]any, path []string, val any) {
+	for i, k := range path {
+		if _, ok := m[k]; !ok {
+			for key := range m {
+				if strings.EqualFold(key, k) {
+					k = key
+
+					break
+				}
+			}
+		}
+
+		if i == len(path)-1 {
+			m[k] = val
+
+			return
+		}
+
+		next, ok := m[k].(map[string]any)
+		if !ok {
+			return
+		}
+
+		m = next
+	}
+}
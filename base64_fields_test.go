@@ -0,0 +1,42 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBase64Fields(t *testing.T) {
+	v := NewWithOptions(WithBase64Fields())
+	v.Set("tls.key", "c2VjcmV0")
+	v.Set("tls.cert", "Y2VydA")
+	v.Set("peers", []map[string]any{{"token": "dG9rZW4="}})
+
+	type tls struct {
+		Key  []byte `viper:"base64"`
+		Cert []byte `mapstructure:"cert" viper:"base64"`
+	}
+
+	var config struct {
+		TLS   tls
+		Peers []struct {
+			Token []byte `viper:"base64"`
+		}
+	}
+
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, []byte("secret"), config.TLS.Key)
+	assert.Equal(t, []byte("cert"), config.TLS.Cert, "padding is optional")
+	require.Len(t, config.Peers, 1)
+	assert.Equal(t, []byte("token"), config.Peers[0].Token)
+	assert.Equal(t, "c2VjcmV0", v.Get("tls.key"), "the configuration is not modified")
+
+	var key tls
+	require.NoError(t, v.UnmarshalKey("tls", &key))
+	assert.Equal(t, []byte("secret"), key.Key)
+
+	v.Set("tls.key", "not base64!")
+	require.Error(t, v.Unmarshal(&config))
+}
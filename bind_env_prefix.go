@@ -0,0 +1,129 @@
+package viper
+
+import (
+	"slices"
+	"strings"
+)
+
+// BindEnvPrefix binds every key under keyPrefix to the environment variables named after envPrefix
+// and the rest of the key, without enumerating the keys (and without AutomaticEnv):
+// after BindEnvPrefix("database", "DB"), "database.host" is read from DB_HOST.
+//
+// The keys of the variables carrying envPrefix are returned by AllKeys, so that Unmarshal and AllSettings
+// read them even when no other source knows about them. The rest of their name is lower cased and used
+// as a single key (DB_POOL_SIZE is "database.pool_size"), unless an env nesting separator is set
+// (see SetEnvNestingSeparator): with "__", DB_POOL__SIZE is "database.pool.size".
+//
+// Bound variables take precedence over config files, like variables bound with BindEnv.
+// When several key prefixes match a key, the longest one is used.
+func BindEnvPrefix(keyPrefix, envPrefix string) { v.BindEnvPrefix(keyPrefix, envPrefix) }
+
+func (v *Viper) BindEnvPrefix(keyPrefix, envPrefix string) {
+	if v.envPrefixBindings == nil {
+		v.envPrefixBindings = make(map[string]string)
+	}
+
+	v.envPrefixBindings[strings.ToLower(keyPrefix)] = envPrefix
+}
+
+// prefixBoundEnvName returns the name of the environment variable bound to a key path with BindEnvPrefix.
+func (v *Viper) prefixBoundEnvName(path []string) (string, bool) {
+	matched := -1
+
+	var envPrefix string
+
+	for keyPrefix, prefix := range v.envPrefixBindings {
+		prefixPath := strings.Split(keyPrefix, v.keyDelim)
+		if len(prefixPath) < len(path) && len(prefixPath) > matched && slices.Equal(prefixPath, path[:len(prefixPath)]) {
+			matched, envPrefix = len(prefixPath), prefix
+		}
+	}
+
+	if matched < 0 {
+		return "", false
+	}
+
+	sep := "_"
+	if v.envNestingSeparator != "" {
+		sep = v.envNestingSeparator
+	}
+
+	return strings.ToUpper(envPrefix + "_" + strings.Join(path[matched:], sep)), true
+}
+
+// findPrefixBoundEnv returns the value of a key path from the environment variables bound with BindEnvPrefix.
+// When the variable of a parent key is set, it shadows the key: the value is searched in the parent value
+// if it is JSON (see WithJSONEnv), and the second result is true.
+func (v *Viper) findPrefixBoundEnv(path []string) (any, bool) {
+	if len(v.envPrefixBindings) == 0 {
+		return nil, false
+	}
+
+	if name, ok := v.prefixBoundEnvName(path); ok {
+		if val, ok := v.getEnv(name); ok {
+			return v.envValue(strings.Join(path, v.keyDelim), val), true
+		}
+	}
+
+	for i := 1; i < len(path); i++ {
+		name, ok := v.prefixBoundEnvName(path[:i])
+		if !ok {
+			continue
+		}
+
+		if _, ok := v.getEnv(name); ok {
+			return v.searchJSONEnv(strings.Join(path[:i], v.keyDelim), path, name), true
+		}
+	}
+
+	return nil, false
+}
+
+// prefixBoundEnvKeys returns the keys of the set environment variables bound with BindEnvPrefix.
+func (v *Viper) prefixBoundEnvKeys() map[string]any {
+	keys := make(map[string]any)
+
+	if len(v.envPrefixBindings) == 0 {
+		return keys
+	}
+
+	env := make(map[string]string)
+
+	if v.envLookup == nil {
+		for name, val := range v.processEnv() {
+			env[name] = val
+		}
+	}
+
+	for name, val := range v.dotenv {
+		if _, ok := env[name]; !ok {
+			env[name] = val
+		}
+	}
+
+	for keyPrefix, envPrefix := range v.envPrefixBindings {
+		prefix := strings.ToUpper(envPrefix + "_")
+
+		for name, val := range env {
+			if v.envFileSuffix != "" && strings.HasSuffix(name, v.envFileSuffix) {
+				name = strings.TrimSuffix(name, v.envFileSuffix)
+			} else if val == "" && !v.allowEmptyEnv {
+				continue
+			}
+
+			rest, ok := strings.CutPrefix(name, prefix)
+			if !ok || rest == "" {
+				continue
+			}
+
+			path := []string{strings.ToLower(rest)}
+			if v.envNestingSeparator != "" {
+				path = strings.Split(path[0], strings.ToLower(v.envNestingSeparator))
+			}
+
+			keys[keyPrefix+v.keyDelim+strings.Join(path, v.keyDelim)] = true
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,68 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindEnvPrefix(t *testing.T) {
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("DB_POOL_SIZE", "10")
+	t.Setenv("DB_EMPTY", "")
+	t.Setenv("REDIS_URL", "redis://cache")
+	t.Setenv("HOST", "ignored")
+
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+database:
+  host: localhost
+  user: root
+cache:
+  ttl: 5s
+`)))
+	v.BindEnvPrefix("database", "DB")
+	v.BindEnvPrefix("cache", "redis")
+
+	assert.Equal(t, "db.internal", v.Get("database.host"))
+	assert.Equal(t, "root", v.Get("database.user"))
+	assert.Equal(t, 10, v.GetInt("database.pool_size"))
+	assert.Equal(t, "redis://cache", v.Get("cache.url"))
+	assert.Nil(t, v.Get("host"))
+
+	assert.ElementsMatch(t, []string{
+		"database.host",
+		"database.user",
+		"database.pool_size",
+		"cache.ttl",
+		"cache.url",
+	}, v.AllKeys())
+
+	var config struct {
+		Database struct {
+			Host     string
+			PoolSize int `mapstructure:"pool_size"`
+		}
+	}
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, "db.internal", config.Database.Host)
+	assert.Equal(t, 10, config.Database.PoolSize)
+}
+
+func TestBindEnvPrefix_NestingSeparator(t *testing.T) {
+	t.Setenv("DB_POOL__SIZE", "10")
+	t.Setenv("DB_REPLICA", "replica.internal")
+
+	v := New()
+	v.SetEnvNestingSeparator("__")
+	v.SetDefault("database.replica.host", "localhost")
+	v.BindEnvPrefix("database", "DB")
+
+	assert.Equal(t, "10", v.Get("database.pool.size"))
+	assert.Nil(t, v.Get("database.replica.host"), "the variable of a parent key shadows nested keys")
+	assert.ElementsMatch(t, []string{"database.pool.size", "database.replica"}, v.AllKeys())
+}
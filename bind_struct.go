@@ -0,0 +1,144 @@
+package viper
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// BindStruct binds an environment variable to the key of every field of a struct (see BindEnv),
+// so that Unmarshal reads them from the environment even when no other source
+// (config file, defaults, flags) knows about the keys.
+//
+// Keys are derived from the struct type the same way Unmarshal matches them:
+// mapstructure tags are honored (including "-" and ",squash"), and nested structs
+// (or pointers to them) are walked, even when nil. Structs that unmarshal themselves
+// from text (eg. time.Time) are bound as a single key.
+//
+// Environment variable names honor the env prefix, key replacer and nesting separator
+// (see SetEnvPrefix, SetEnvKeyReplacer and SetEnvNestingSeparator).
+// Decoder options setting the tag name or squashing embedded structs are taken into account.
+func BindStruct(input any, opts ...DecoderConfigOption) error { return v.BindStruct(input, opts...) }
+
+func (v *Viper) BindStruct(input any, opts ...DecoderConfigOption) error {
+	typ := reflect.TypeOf(input)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return errors.New("BindStruct requires a struct or a pointer to a struct")
+	}
+
+	config := v.defaultDecoderConfig(nil, opts...)
+
+	tagName := config.TagName
+	if tagName == "" {
+		tagName = "mapstructure"
+	}
+
+	for _, key := range v.structFieldKeys(typ, tagName, config.Squash, "", nil) {
+		envKey := key
+		if v.envNestingSeparator != "" {
+			envKey = strings.Join(strings.Split(key, v.keyDelim), v.envNestingSeparator)
+		}
+
+		for _, name := range v.mergeWithEnvPrefixes(envKey) {
+			if !slices.Contains(v.env[key], name) {
+				v.env[key] = append(v.env[key], name)
+			}
+		}
+	}
+
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// structFieldKeys returns the keys of the fields of a struct type, prefixed with prefix.
+func (v *Viper) structFieldKeys(typ reflect.Type, tagName string, squashEmbedded bool, prefix string, parents []reflect.Type) []string {
+	var keys []string
+
+	v.walkStructFields(typ, tagName, squashEmbedded, prefix, parents, func(key string, _ reflect.StructField) {
+		keys = append(keys, key)
+	})
+
+	return keys
+}
+
+// walkStructFields calls fn with the key of every field of a struct type (prefixed with prefix),
+// walking nested structs the way Unmarshal matches keys.
+// Recursive types are only walked once, as they would produce infinitely many keys.
+func (v *Viper) walkStructFields(typ reflect.Type, tagName string, squashEmbedded bool, prefix string, parents []reflect.Type, fn func(key string, field reflect.StructField)) {
+	v.walkStructTree(typ, tagName, squashEmbedded, prefix, parents, func(key string, field reflect.StructField, recursive bool) {
+		if !recursive {
+			fn(key, field)
+		}
+	})
+}
+
+// walkStructTree walks the fields of a struct type like walkStructFields,
+// and also calls fn with the key of the fields of recursive types, which are not walked.
+func (v *Viper) walkStructTree(typ reflect.Type, tagName string, squashEmbedded bool, prefix string, parents []reflect.Type, fn func(key string, field reflect.StructField, recursive bool)) {
+	parents = append(parents, typ)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		// fields of embedded structs are exported even when the struct type is not
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if name == "-" || slices.Contains(strings.Split(options, ","), "remain") {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		// structs unmarshaled from text are values, not nested keys
+		nested := fieldType.Kind() == reflect.Struct && !reflect.PointerTo(fieldType).Implements(textUnmarshalerType)
+		recursive := nested && slices.Contains(parents, fieldType)
+
+		squash := slices.Contains(strings.Split(options, ","), "squash") || (squashEmbedded && field.Anonymous)
+		if recursive && squash {
+			continue
+		}
+
+		if nested && squash {
+			v.walkStructTree(fieldType, tagName, squashEmbedded, prefix, parents, fn)
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		key := prefix + strings.ToLower(name)
+
+		if recursive {
+			fn(key, field, true)
+
+			continue
+		}
+
+		if nested {
+			v.walkStructTree(fieldType, tagName, squashEmbedded, key+v.keyDelim, parents, fn)
+
+			continue
+		}
+
+		fn(key, field, false)
+	}
+}
@@ -0,0 +1,86 @@
+package viper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindStructDatabase struct {
+	Host     string
+	Port     int
+	Password string `mapstructure:"pass"`
+}
+
+type bindStructCommon struct {
+	Name string
+}
+
+type bindStructConfig struct {
+	bindStructCommon `mapstructure:",squash"`
+
+	Database  bindStructDatabase
+	Replica   *bindStructDatabase `mapstructure:"replica"`
+	Timeout   time.Duration
+	StartedAt time.Time
+	Ignored   string         `mapstructure:"-"`
+	Extra     map[string]any `mapstructure:",remain"`
+	Parent    *bindStructConfig
+
+	unexported string
+}
+
+func TestBindStruct(t *testing.T) {
+	v := New()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	require.NoError(t, v.BindStruct(&bindStructConfig{}))
+
+	assert.ElementsMatch(t, []string{
+		"name",
+		"database.host",
+		"database.port",
+		"database.pass",
+		"replica.host",
+		"replica.port",
+		"replica.pass",
+		"timeout",
+		"startedat",
+	}, v.AllKeys())
+
+	t.Setenv("APP_NAME", "app")
+	t.Setenv("APP_DATABASE_HOST", "db.internal")
+	t.Setenv("APP_DATABASE_PASS", "secret")
+	t.Setenv("APP_REPLICA_PORT", "5433")
+	t.Setenv("APP_TIMEOUT", "5s")
+
+	var config bindStructConfig
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, bindStructDatabase{Host: "db.internal", Password: "secret"}, config.Database)
+	assert.Equal(t, &bindStructDatabase{Port: 5433}, config.Replica)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+
+	// binding again does not duplicate environment variables
+	require.NoError(t, v.BindStruct(bindStructConfig{}))
+	assert.Equal(t, []string{"APP_DATABASE.HOST"}, v.env["database.host"])
+
+	require.Error(t, v.BindStruct("not a struct"))
+}
+
+func TestBindStruct_NestingSeparator(t *testing.T) {
+	v := New()
+	v.SetEnvNestingSeparator("__")
+	require.NoError(t, v.BindStruct(&bindStructConfig{}))
+
+	t.Setenv("DATABASE__HOST", "db.internal")
+
+	var config bindStructConfig
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, "db.internal", config.Database.Host)
+}
@@ -0,0 +1,116 @@
+package viper
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// WithCaseSensitiveConfig preserves the case of keys read into the config file layer
+// (by ReadInConfig, ReadConfig, MergeInConfig, MergeConfig and MergeConfigMap),
+// for formats where keys differing only by case are distinct (eg. JSON documents returned by APIs).
+// Every other layer (overrides, flags, environment variables, key/value stores and defaults)
+// remains case-insensitive.
+//
+// Keys of the config file layer are resolved as follows:
+//
+//   - a key matching the requested key exactly is used first
+//   - otherwise a key matching it case-insensitively is used; when several keys only differ by case,
+//     the first one in lexical order wins
+//   - merged config files only replace keys matching exactly
+//
+// The precedence between layers does not change: a flag or an environment variable bound to "apikey"
+// overrides both "apiKey" and "APIKey" of config files.
+// AllKeys, AllSettings and Unmarshal report lower cased keys, as for the other layers.
+func WithCaseSensitiveConfig() Option {
+	return optionFunc(func(v *Viper) {
+		v.caseSensitiveConfig = true
+	})
+}
+
+// normalizeConfigKeys prepares a map read into the config file layer:
+// keys are lower cased, unless the layer is case-sensitive.
+func (v *Viper) normalizeConfigKeys(m map[string]any) {
+	if v.caseSensitiveConfig {
+		stringifyMap(m)
+
+		return
+	}
+
+	insensitiviseMap(m)
+}
+
+// configKey returns the key of m matching k in the config file layer, or "" when there is none.
+func (v *Viper) configKey(k string, m map[string]any) string {
+	if !v.caseSensitiveConfig {
+		return keyExists(k, m)
+	}
+
+	if _, ok := m[k]; ok {
+		return k
+	}
+
+	return ""
+}
+
+// configPath returns the path used to search the config file layer for key,
+// given its lower cased (and alias resolved) path.
+func (v *Viper) configPath(key string, path []string) []string {
+	if !v.caseSensitiveConfig {
+		return path
+	}
+
+	// aliases resolve to the registered key
+	if lcaseKey := strings.ToLower(key); v.realKey(lcaseKey) != lcaseKey {
+		return path
+	}
+
+	return strings.Split(key, v.keyDelim)
+}
+
+// lookupConfigKey returns the value of key k in a map of the config file layer (see WithCaseSensitiveConfig).
+func (v *Viper) lookupConfigKey(m map[string]any, k string) (any, bool) {
+	val, ok := m[k]
+	if ok || !v.caseSensitiveConfig {
+		return val, ok
+	}
+
+	var keys []string
+	for mk := range m {
+		if strings.EqualFold(mk, k) {
+			keys = append(keys, mk)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	return m[slices.Min(keys)], true
+}
+
+// stringifyMap converts nested map[any]any values to map[string]any, preserving the case of keys.
+func stringifyMap(m map[string]any) {
+	for key, val := range m {
+		m[key] = stringifyVal(val)
+	}
+}
+
+func stringifyVal(val any) any {
+	switch v := val.(type) {
+	case map[any]any:
+		nm := cast.ToStringMap(v)
+		stringifyMap(nm)
+
+		return nm
+	case map[string]any:
+		stringifyMap(v)
+	case []any:
+		for i, item := range v {
+			v[i] = stringifyVal(item)
+		}
+	}
+
+	return val
+}
@@ -0,0 +1,53 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCaseSensitiveConfig(t *testing.T) {
+	v := NewWithOptions(WithCaseSensitiveConfig())
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`{
+		"apiKey": "lower",
+		"APIKey": "upper",
+		"Server": {"Host": "localhost", "Port": 8080}
+	}`)))
+
+	// exact matches first
+	assert.Equal(t, "lower", v.GetString("apiKey"))
+	assert.Equal(t, "upper", v.GetString("APIKey"))
+	assert.True(t, v.InConfig("APIKey"))
+
+	// then case-insensitive matches, the first one in lexical order winning
+	assert.Equal(t, "upper", v.GetString("apikey"))
+	assert.Equal(t, "localhost", v.GetString("server.host"))
+	assert.Equal(t, 8080, v.Sub("server").GetInt("port"))
+
+	// merged config files only replace exact matches
+	require.NoError(t, v.MergeConfigMap(map[string]any{"apiKey": "merged"}))
+	assert.Equal(t, "merged", v.GetString("apiKey"))
+	assert.Equal(t, "upper", v.GetString("APIKey"))
+
+	// other layers remain case-insensitive and keep their precedence
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("apikey", "", "")
+	require.NoError(t, flags.Parse([]string{"--apikey=flag"}))
+	require.NoError(t, v.BindPFlag("APIKEY", flags.Lookup("apikey")))
+
+	assert.Equal(t, "flag", v.GetString("apiKey"))
+	assert.Equal(t, "flag", v.GetString("APIKey"))
+}
+
+func TestCaseInsensitiveConfig(t *testing.T) {
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`{"apiKey": "value"}`)))
+
+	assert.Equal(t, map[string]any{"apikey": "value"}, v.config.load())
+	assert.Equal(t, "value", v.GetString("APIKEY"))
+}
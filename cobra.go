@@ -0,0 +1,89 @@
+package viper
+
+import "github.com/spf13/pflag"
+
+// CobraCommand is the part of *cobra.Command used by BindCobra,
+// so that Viper does not depend on cobra.
+type CobraCommand[C any] interface {
+	Name() string
+	Commands() []C
+	LocalFlags() *pflag.FlagSet
+}
+
+// CobraOption configures BindCobra.
+type CobraOption interface {
+	apply(c *cobraConfig)
+}
+
+type cobraOptionFunc func(c *cobraConfig)
+
+func (fn cobraOptionFunc) apply(c *cobraConfig) {
+	fn(c)
+}
+
+type cobraConfig struct {
+	viper      *Viper
+	namespaces bool
+}
+
+// WithCommandNamespaces binds the flags of subcommands under the names of the commands:
+// the "port" flag of the "serve" command is bound to "serve.port", and the flags
+// of "db migrate" under "db.migrate". Flags of the root command are bound without a namespace.
+func WithCommandNamespaces() CobraOption {
+	return cobraOptionFunc(func(c *cobraConfig) {
+		c.namespaces = true
+	})
+}
+
+// WithCobraViper binds the flags to v instead of the global instance.
+func WithCobraViper(v *Viper) CobraOption {
+	return cobraOptionFunc(func(c *cobraConfig) {
+		c.viper = v
+	})
+}
+
+// BindCobra binds the flags of every command of a cobra command tree (see BindPFlag),
+// including the persistent flags, to the command defining them.
+// Without WithCommandNamespaces, flags are bound to their names: among flags of the same name,
+// the one set on the command line is used, and otherwise the flag of the most specific subcommand.
+//
+// The command tree is retained and its flags are looked up whenever a value is read,
+// like flag sets bound with WithLazyFlagBinding: commands and flags added to the tree
+// after BindCobra is called (eg. by the init functions of subcommands) are bound too.
+// Flags explicitly bound to a key take precedence over flags of the tree.
+func BindCobra[C CobraCommand[C]](root C, opts ...CobraOption) {
+	c := cobraConfig{viper: v}
+
+	for _, opt := range opts {
+		opt.apply(&c)
+	}
+
+	c.viper.lazyFlagSets = append(c.viper.lazyFlagSets, lazyFlagSet{
+		tree: func() []lazyFlagSet {
+			return cobraFlagSets(c.viper, root, "", c.namespaces)
+		},
+	})
+}
+
+// cobraFlagSets lists the flag sets of cmd and its subcommands, under prefix,
+// in the order they take precedence: the last subcommand first, and cmd last.
+func cobraFlagSets[C CobraCommand[C]](v *Viper, cmd C, prefix string, namespaces bool) []lazyFlagSet {
+	var sets []lazyFlagSet
+
+	commands := cmd.Commands()
+	for i := len(commands) - 1; i >= 0; i-- {
+		sub := commands[i]
+
+		subPrefix := prefix
+		if namespaces {
+			subPrefix = sub.Name()
+			if prefix != "" {
+				subPrefix = prefix + v.keyDelim + sub.Name()
+			}
+		}
+
+		sets = append(sets, cobraFlagSets(v, sub, subPrefix, namespaces)...)
+	}
+
+	return append(sets, lazyFlagSet{keyPrefix: prefix, flags: cmd.LocalFlags()})
+}
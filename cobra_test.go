@@ -0,0 +1,98 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// command mimics the parts of *cobra.Command used by BindCobra.
+type command struct {
+	name     string
+	flags    *pflag.FlagSet
+	commands []*command
+}
+
+func newCommand(name string, commands ...*command) *command {
+	return &command{name: name, flags: pflag.NewFlagSet(name, pflag.ContinueOnError), commands: commands}
+}
+
+func (c *command) Name() string               { return c.name }
+func (c *command) Commands() []*command       { return c.commands }
+func (c *command) LocalFlags() *pflag.FlagSet { return c.flags }
+
+func TestBindCobra(t *testing.T) {
+	migrate := newCommand("migrate")
+	migrate.flags.Int("steps", 1, "")
+
+	db := newCommand("db", migrate)
+	db.flags.String("dsn", "postgres://", "")
+
+	serve := newCommand("serve")
+	serve.flags.Int("port", 8080, "")
+	serve.flags.String("config", "serve.yaml", "")
+
+	root := newCommand("app", serve, db)
+	root.flags.String("config", "app.yaml", "")
+
+	t.Run("Namespaces", func(t *testing.T) {
+		v := New()
+		BindCobra(root, WithCommandNamespaces(), WithCobraViper(v))
+
+		assert.ElementsMatch(t, []string{
+			"config",
+			"serve.port",
+			"serve.config",
+			"db.dsn",
+			"db.migrate.steps",
+		}, v.AllKeys())
+		assert.Equal(t, 8080, v.Get("serve.port"))
+		assert.Equal(t, "app.yaml", v.Get("config"))
+	})
+
+	t.Run("Flat", func(t *testing.T) {
+		v := New()
+		BindCobra(root, WithCobraViper(v))
+
+		assert.Equal(t, 8080, v.Get("port"))
+		assert.Equal(t, 1, v.Get("steps"))
+		assert.Equal(t, "serve.yaml", v.Get("config"), "flags of subcommands take precedence")
+
+		// flags set on the command line take precedence over unset flags of the same name
+		require.NoError(t, root.flags.Parse([]string{"--config=user.yaml"}))
+		assert.Equal(t, "user.yaml", v.Get("config"))
+	})
+}
+
+func TestBindCobra_Lazy(t *testing.T) {
+	serve := newCommand("serve")
+	root := newCommand("app", serve)
+
+	v := New()
+	BindCobra(root, WithCommandNamespaces(), WithCobraViper(v))
+
+	// flags and commands are registered after BindCobra, eg. by init functions of subcommands
+	serve.flags.Int("port", 8080, "")
+
+	version := newCommand("version")
+	version.flags.Bool("short", false, "")
+	root.commands = append(root.commands, version)
+
+	assert.Equal(t, 8080, v.Get("serve.port"))
+	assert.Equal(t, false, v.Get("version.short"))
+	assert.ElementsMatch(t, []string{"serve.port", "version.short"}, v.AllKeys())
+
+	// flags are read when the command is executed
+	require.NoError(t, serve.flags.Parse([]string{"--port=9090"}))
+	assert.Equal(t, 9090, v.GetInt("serve.port"))
+
+	t.Run("ExplicitBinding", func(t *testing.T) {
+		other := pflag.NewFlagSet("other", pflag.ContinueOnError)
+		other.Int("port", 1234, "")
+
+		require.NoError(t, v.BindPFlag("serve.port", other.Lookup("port")))
+		assert.Equal(t, 1234, v.GetInt("serve.port"))
+	})
+}
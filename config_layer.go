@@ -0,0 +1,51 @@
+package viper
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// configLayer holds the settings read from config files or remote providers.
+//
+// Settings are never modified in place: writers update a copy and swap it in atomically,
+// so that readers never observe a partially populated or mixed-generation configuration.
+type configLayer struct {
+	mu       sync.Mutex // serializes writers
+	settings atomic.Pointer[map[string]any]
+}
+
+// load returns the current settings. The returned map must not be modified.
+func (l *configLayer) load() map[string]any {
+	if settings := l.settings.Load(); settings != nil {
+		return *settings
+	}
+
+	return nil
+}
+
+// store replaces the settings and returns the previous ones.
+func (l *configLayer) store(settings map[string]any) map[string]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous := l.load()
+	l.settings.Store(&settings)
+
+	return previous
+}
+
+// update applies fn to a copy of the settings and swaps the copy in,
+// unless fn returns an error.
+func (l *configLayer) update(fn func(settings map[string]any) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	settings := deepCopyMap(l.load())
+	if err := fn(settings); err != nil {
+		return err
+	}
+
+	l.settings.Store(&settings)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package viper
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigLayer_ConcurrentReload(t *testing.T) {
+	v := New()
+	require.NoError(t, v.MergeConfigMap(map[string]any{"gen": map[string]any{"a": 0, "b": 0}}))
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for i := 1; i <= 200; i++ {
+			v.MergeConfigMap(map[string]any{"gen": map[string]any{"a": i, "b": i}})
+		}
+	}()
+
+	// Readers always observe a single generation of the configuration
+	for i := 0; i < 200; i++ {
+		gen := v.GetStringMap("gen")
+		require.Equal(t, gen["a"], gen["b"])
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 200, v.GetInt("gen.a"))
+}
+
+func TestConfigLayer_Update(t *testing.T) {
+	var l configLayer
+
+	assert.Nil(t, l.load())
+
+	l.store(map[string]any{"foo": map[string]any{"bar": "baz"}})
+	before := l.load()
+
+	require.NoError(t, l.update(func(settings map[string]any) error {
+		settings["foo"].(map[string]any)["bar"] = "qux"
+
+		return nil
+	}))
+
+	assert.Equal(t, map[string]any{"foo": map[string]any{"bar": "baz"}}, before, "published settings are never modified")
+	assert.Equal(t, map[string]any{"foo": map[string]any{"bar": "qux"}}, l.load())
+
+	require.Error(t, l.update(func(settings map[string]any) error {
+		settings["foo"] = "partial"
+
+		return assert.AnError
+	}))
+
+	assert.Equal(t, map[string]any{"foo": map[string]any{"bar": "qux"}}, l.load(), "failed updates are discarded")
+}
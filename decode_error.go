@@ -0,0 +1,183 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// DecodeError lists the fields that Unmarshal, UnmarshalKey or UnmarshalExact failed to decode,
+// eg. to render them as structured validation output. Use errors.As to get it from the error of the call.
+type DecodeError struct {
+	Fields []FieldDecodeError
+}
+
+// Error returns the formatted decode error.
+func (e *DecodeError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, fe := range e.Fields {
+		msgs[i] = fe.Error()
+	}
+
+	return fmt.Sprintf("decoding failed: %s", strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the fields.
+func (e *DecodeError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, fe := range e.Fields {
+		errs[i] = fe
+	}
+
+	return errs
+}
+
+// FieldDecodeError describes a value that could not be decoded into its field.
+type FieldDecodeError struct {
+	// Key is the path of the value, made of the keys of the configuration
+	// and of slice indexes (eg. "servers[0].port").
+	Key string
+
+	// Value is the value of the configuration, or RedactedValue for Secret fields.
+	Value any
+
+	// Type is the type of the field.
+	Type reflect.Type
+
+	// Err is the error of the decoding.
+	Err error
+}
+
+// Error returns the formatted field decode error.
+func (fe FieldDecodeError) Error() string {
+	return fmt.Sprintf("%s: cannot decode %#v into %s", fe.Key, fe.Value, fe.Type)
+}
+
+// Unwrap returns the error of the decoding.
+func (fe FieldDecodeError) Unwrap() error {
+	return fe.Err
+}
+
+// decodeError turns the error of decoding input, the value of the key prefix, into a DecodeError
+// naming the failing fields. The error is returned as is when no field can be blamed for it.
+func decodeError(input any, config *mapstructure.DecoderConfig, prefix string, err error) error {
+	typ := reflect.TypeOf(config.Result)
+	if typ == nil || typ.Kind() != reflect.Pointer {
+		return err
+	}
+
+	var fields []FieldDecodeError
+
+	collectDecodeErrors(input, typ.Elem(), config, prefix, &fields)
+
+	if len(fields) == 0 {
+		return err
+	}
+
+	return &DecodeError{Fields: fields}
+}
+
+// collectDecodeErrors decodes input into a value of typ on its own and, when that fails,
+// looks for the nested values responsible for it.
+// Failures that cannot be narrowed down further are reported under key.
+func collectDecodeErrors(input any, typ reflect.Type, config *mapstructure.DecoderConfig, key string, fields *[]FieldDecodeError) {
+	c := *config
+	c.Result = reflect.New(typ).Interface()
+	c.Metadata = nil
+	c.ErrorUnused = false
+	c.ErrorUnset = false
+
+	err := decode(input, &c)
+	if err == nil {
+		return
+	}
+
+	n := len(*fields)
+
+	elemType := typ
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	switch elemType.Kind() {
+	case reflect.Struct:
+		if m, ok := input.(map[string]any); ok {
+			collectFieldDecodeErrors(m, elemType, config, key, fields)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if s, ok := input.([]any); ok {
+			for i, elem := range s {
+				collectDecodeErrors(elem, elemType.Elem(), config, fmt.Sprintf("%s[%d]", key, i), fields)
+			}
+		}
+
+	case reflect.Map:
+		if m, ok := input.(map[string]any); ok && elemType.Key().Kind() == reflect.String {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+
+			for _, k := range keys {
+				collectDecodeErrors(m[k], elemType.Elem(), config, joinKey(key, k), fields)
+			}
+		}
+	}
+
+	if len(*fields) == n {
+		if isSecretType(typ) {
+			input = RedactedValue
+		}
+
+		*fields = append(*fields, FieldDecodeError{Key: key, Value: input, Type: typ, Err: err})
+	}
+}
+
+// collectFieldDecodeErrors collects the decode errors of the fields of a struct decoded from input.
+func collectFieldDecodeErrors(input map[string]any, typ reflect.Type, config *mapstructure.DecoderConfig, key string, fields *[]FieldDecodeError) {
+	tagName := config.TagName
+	if tagName == "" {
+		tagName = "mapstructure"
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if name == "-" || slices.Contains(strings.Split(options, ","), "remain") {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		squash := slices.Contains(strings.Split(options, ","), "squash") || (config.Squash && field.Anonymous)
+		if squash && fieldType.Kind() == reflect.Struct {
+			collectFieldDecodeErrors(input, fieldType, config, key, fields)
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		for k, val := range input {
+			if strings.EqualFold(k, name) {
+				collectDecodeErrors(val, field.Type, config, joinKey(key, k), fields)
+
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package viper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeError(t *testing.T) {
+	type server struct {
+		Port int
+	}
+
+	type config struct {
+		Name    string
+		Timeout int
+		DB      struct {
+			Port int
+		}
+		Servers []server
+	}
+
+	v := New()
+	v.Set("name", "app")
+	v.Set("timeout", "soon")
+	v.Set("db.port", "postgres")
+	v.Set("servers", []any{map[string]any{"port": 80}, map[string]any{"port": "http"}})
+
+	err := v.Unmarshal(&config{})
+
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	require.Len(t, decodeErr.Fields, 3)
+
+	assert.Equal(t, "timeout", decodeErr.Fields[0].Key)
+	assert.Equal(t, "soon", decodeErr.Fields[0].Value)
+	assert.Equal(t, reflect.TypeOf(0), decodeErr.Fields[0].Type)
+	assert.Equal(t, "db.port", decodeErr.Fields[1].Key)
+	assert.Equal(t, "servers[1].port", decodeErr.Fields[2].Key)
+
+	assert.EqualError(t, err, `decoding failed: timeout: cannot decode "soon" into int; `+
+		`db.port: cannot decode "postgres" into int; servers[1].port: cannot decode "http" into int`)
+
+	assert.ErrorContains(t, decodeErr.Fields[0].Err, "cannot parse")
+	assert.ErrorIs(t, err, decodeErr.Fields[1], "fields are unwrapped")
+
+	err = v.UnmarshalKey("db", &struct{ Port int }{})
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "db.port", decodeErr.Fields[0].Key)
+
+	err = v.UnmarshalExact(&config{})
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Len(t, decodeErr.Fields, 3)
+}
@@ -0,0 +1,77 @@
+package viper
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// WithStandardDecodeHooks registers StandardDecodeHooks for every Unmarshal call of the instance
+// (see RegisterDecodeHook).
+func WithStandardDecodeHooks() Option {
+	return WithDecodeHooks(StandardDecodeHooks())
+}
+
+// StandardDecodeHooks returns a decode hook parsing strings into the following standard library types:
+// net.IP, net.HardwareAddr, netip.Addr, netip.AddrPort, netip.Prefix, *url.URL and *regexp.Regexp.
+func StandardDecodeHooks() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToIPHookFunc(),
+		mapstructure.StringToNetIPAddrHookFunc(),
+		mapstructure.StringToNetIPAddrPortHookFunc(),
+		mapstructure.StringToURLHookFunc(),
+		stringToHookFunc(netip.ParsePrefix),
+		stringToHookFunc(net.ParseMAC),
+		stringToHookFunc(regexp.Compile),
+	)
+}
+
+// stringToHookFunc returns a decode hook parsing strings into values of type T.
+func stringToHookFunc[T any](parse func(string) (T, error)) mapstructure.DecodeHookFunc {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String || t != typ {
+			return data, nil
+		}
+
+		return parse(reflect.ValueOf(data).String())
+	}
+}
+
+// WithTextUnmarshalerDecoding decodes strings into the values of every type implementing encoding.TextUnmarshaler
+// (eg. uuid.UUID, log levels or custom enums) in Unmarshal, UnmarshalKey and UnmarshalExact calls.
+//
+// When time layouts or a time location are set (see WithTimeLayouts and WithTimeLocation),
+// time.Time values are still decoded with them.
+func WithTextUnmarshalerDecoding() Option {
+	return optionFunc(func(v *Viper) {
+		v.textUnmarshalerDecoding = true
+	})
+}
+
+// textUnmarshalerHookFunc returns a decode hook decoding strings with the UnmarshalText method of the target type
+// (see WithTextUnmarshalerDecoding).
+func (v *Viper) textUnmarshalerHookFunc() mapstructure.DecodeHookFunc {
+	hook := mapstructure.TextUnmarshallerHookFunc()
+
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if t == reflect.TypeOf(time.Time{}) && (len(v.timeLayouts) > 0 || v.timeLocation != nil) {
+			return data, nil
+		}
+
+		return hook(f, t, data)
+	}
+}
@@ -0,0 +1,96 @@
+package viper
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStandardDecodeHooks(t *testing.T) {
+	v := NewWithOptions(WithStandardDecodeHooks())
+	v.Set("ip", "10.0.0.1")
+	v.Set("mac", "00:00:5e:00:53:01")
+	v.Set("addr", "::1")
+	v.Set("listen", "127.0.0.1:8080")
+	v.Set("subnet", "10.0.0.0/8")
+	v.Set("endpoint", "https://example.com/api")
+	v.Set("pattern", "^v[0-9]+$")
+	v.Set("allowed", []string{"10.0.0.0/8", "192.168.0.0/16"})
+
+	var config struct {
+		IP       net.IP
+		MAC      net.HardwareAddr
+		Addr     netip.Addr
+		Listen   netip.AddrPort
+		Subnet   netip.Prefix
+		Endpoint *url.URL
+		Pattern  *regexp.Regexp
+		Allowed  []netip.Prefix
+	}
+
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, net.ParseIP("10.0.0.1"), config.IP)
+	assert.Equal(t, "00:00:5e:00:53:01", config.MAC.String())
+	assert.Equal(t, netip.MustParseAddr("::1"), config.Addr)
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), config.Listen)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), config.Subnet)
+	assert.Equal(t, "example.com", config.Endpoint.Host)
+	assert.True(t, config.Pattern.MatchString("v2"))
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")}, config.Allowed)
+
+	v.Set("pattern", "(")
+	require.Error(t, v.Unmarshal(&config))
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+
+	return nil
+}
+
+func TestWithTextUnmarshalerDecoding(t *testing.T) {
+	v := NewWithOptions(WithTextUnmarshalerDecoding(), WithTimeLayouts("2006-01-02"))
+	v.Set("level", "info")
+	v.Set("levels", []string{"debug", "info"})
+	v.Set("since", "2024-01-02")
+	v.Set("name", "app")
+
+	var config struct {
+		Level  testLevel
+		Levels []testLevel
+		Since  time.Time
+		Name   string
+	}
+
+	require.NoError(t, v.Unmarshal(&config))
+	assert.Equal(t, testLevel(1), config.Level)
+	assert.Equal(t, []testLevel{0, 1}, config.Levels)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), config.Since)
+	assert.Equal(t, "app", config.Name)
+
+	v.Set("level", "verbose")
+	require.Error(t, v.Unmarshal(&config))
+
+	v = New()
+	v.Set("level", "info")
+
+	var level struct{ Level testLevel }
+	require.Error(t, v.Unmarshal(&level), "text decoding is opt-in")
+}
@@ -0,0 +1,130 @@
+package viper
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var deprecatedFlagReplacementRegexp = regexp.MustCompile(`--([\w.-]+)`)
+
+// WithDeprecatedFlagAliases makes BindPFlags and BindPFlagsWithPrefix handle flags marked deprecated
+// with a replacement (see pflag.FlagSet.MarkDeprecated): the replacement is the first flag of the set
+// named in the deprecation message, prefixed with "--".
+//
+// The key of the deprecated flag is registered as an alias of the key of the replacement (see RegisterAlias),
+// and the replacement key gets the value of the deprecated flag when only the deprecated flag is changed.
+// Getting a value through the deprecated key logs a warning.
+//
+//	flags.String("log-level", "info", "")
+//	flags.String("loglevel", "info", "")
+//	flags.MarkDeprecated("loglevel", "use --log-level instead")
+func WithDeprecatedFlagAliases() Option {
+	return optionFunc(func(v *Viper) {
+		v.deprecatedFlagAliases = true
+	})
+}
+
+// bindDeprecatedPFlags registers aliases for the deprecated flags of a bound flag set (see WithDeprecatedFlagAliases).
+func (v *Viper) bindDeprecatedPFlags(keyPrefix string, flags *pflag.FlagSet) {
+	if !v.deprecatedFlagAliases {
+		return
+	}
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Deprecated == "" {
+			return
+		}
+
+		replacement := deprecatedFlagReplacement(flags, flag)
+		if replacement == nil {
+			return
+		}
+
+		oldKey := v.flagKey(flag.Name)
+		newKey := v.flagKey(replacement.Name)
+		if keyPrefix != "" {
+			oldKey = keyPrefix + v.keyDelim + oldKey
+			newKey = keyPrefix + v.keyDelim + newKey
+		}
+
+		oldKey, newKey = strings.ToLower(oldKey), strings.ToLower(newKey)
+
+		delete(v.pflags, oldKey)
+		v.pflags[newKey] = deprecatedFlagValue{pflagValue{replacement}, pflagValue{flag}}
+		v.registerAlias(oldKey, newKey)
+
+		if v.deprecatedKeys == nil {
+			v.deprecatedKeys = make(map[string]string)
+		}
+		v.deprecatedKeys[oldKey] = flag.Deprecated
+	})
+}
+
+// unbindDeprecatedFlags removes the aliases of the deprecated flags bound to key.
+// When key is the alias of a deprecated flag, the alias is removed
+// and the deprecated flag no longer sets the key of its replacement.
+func (v *Viper) unbindDeprecatedFlags(key string) {
+	if _, ok := v.deprecatedKeys[key]; ok {
+		newKey := v.aliases[key]
+		if flag, ok := v.pflags[newKey].(deprecatedFlagValue); ok {
+			v.pflags[newKey] = flag.FlagValue
+		}
+
+		delete(v.aliases, key)
+		delete(v.deprecatedKeys, key)
+
+		return
+	}
+
+	for oldKey := range v.deprecatedKeys {
+		if v.aliases[oldKey] == key {
+			delete(v.aliases, oldKey)
+			delete(v.deprecatedKeys, oldKey)
+		}
+	}
+}
+
+// deprecatedFlagReplacement returns the flag named in the deprecation message of flag, if any.
+func deprecatedFlagReplacement(flags *pflag.FlagSet, flag *pflag.Flag) *pflag.Flag {
+	for _, match := range deprecatedFlagReplacementRegexp.FindAllStringSubmatch(flag.Deprecated, -1) {
+		if match[1] == flag.Name {
+			continue
+		}
+
+		if replacement := flags.Lookup(match[1]); replacement != nil {
+			return replacement
+		}
+	}
+
+	return nil
+}
+
+// warnDeprecatedKey logs a warning when key is the alias of a deprecated flag.
+func (v *Viper) warnDeprecatedKey(key string) {
+	if msg, ok := v.deprecatedKeys[key]; ok {
+		v.logger.Warn("key is deprecated", "key", key, "replacement", v.realKey(key), "message", msg)
+	}
+}
+
+// deprecatedFlagValue is the FlagValue of a flag replacing a deprecated flag,
+// which gets the value of the deprecated flag when only the deprecated flag is changed.
+type deprecatedFlagValue struct {
+	FlagValue
+	deprecated FlagValue
+}
+
+// HasChanged returns whether the flag or the deprecated flag has changed.
+func (d deprecatedFlagValue) HasChanged() bool {
+	return d.FlagValue.HasChanged() || d.deprecated.HasChanged()
+}
+
+// ValueString returns the value of the flag, or the value of the deprecated flag when only it has changed.
+func (d deprecatedFlagValue) ValueString() string {
+	if !d.FlagValue.HasChanged() && d.deprecated.HasChanged() {
+		return d.deprecated.ValueString()
+	}
+
+	return d.FlagValue.ValueString()
+}
@@ -0,0 +1,73 @@
+package viper
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeprecatedFlagSet(t *testing.T) *pflag.FlagSet {
+	t.Helper()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.String("log-level", "info", "test")
+	flags.String("loglevel", "info", "test")
+	flags.Int("port", 8080, "test")
+	require.NoError(t, flags.MarkDeprecated("loglevel", "use --log-level instead"))
+
+	return flags
+}
+
+func TestWithDeprecatedFlagAliases(t *testing.T) {
+	var logs bytes.Buffer
+
+	v := NewWithOptions(
+		WithDeprecatedFlagAliases(),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("loglevel: warn\n")))
+
+	flags := newDeprecatedFlagSet(t)
+	require.NoError(t, v.BindPFlags(flags))
+
+	assert.Equal(t, "warn", v.Get("log-level"), "config values of the deprecated key are moved to the replacement")
+	assert.Empty(t, logs.String())
+
+	require.NoError(t, flags.Parse([]string{"--loglevel", "debug"}))
+
+	assert.Equal(t, "debug", v.Get("log-level"))
+	assert.Equal(t, "debug", v.Get("loglevel"))
+	assert.Contains(t, logs.String(), "key is deprecated")
+
+	require.NoError(t, flags.Parse([]string{"--log-level", "error"}))
+	assert.Equal(t, "error", v.Get("loglevel"), "the replacement takes precedence")
+}
+
+func TestWithDeprecatedFlagAliases_Prefix(t *testing.T) {
+	v := NewWithOptions(WithDeprecatedFlagAliases())
+
+	flags := newDeprecatedFlagSet(t)
+	require.NoError(t, v.BindPFlagsWithPrefix("app", flags))
+	require.NoError(t, flags.Parse([]string{"--loglevel", "debug"}))
+
+	assert.Equal(t, "debug", v.Get("app.log-level"))
+	assert.Equal(t, "debug", v.Get("app.loglevel"))
+}
+
+func TestWithoutDeprecatedFlagAliases(t *testing.T) {
+	v := New()
+
+	flags := newDeprecatedFlagSet(t)
+	require.NoError(t, v.BindPFlags(flags))
+	require.NoError(t, flags.Parse([]string{"--loglevel", "debug"}))
+
+	assert.Equal(t, "info", v.Get("log-level"))
+	assert.Equal(t, "debug", v.Get("loglevel"))
+}
@@ -0,0 +1,225 @@
+package viper
+
+import (
+	"slices"
+	"strings"
+)
+
+// EnvBinding describes the environment variables a key is read from (see EnvBindings).
+type EnvBinding struct {
+	// Key is the configuration key.
+	Key string
+
+	// EnvVars lists the names of the environment variables the key is read from, in order of precedence.
+	EnvVars []string
+
+	// Automatic reports whether some of EnvVars are checked because of AutomaticEnv
+	// rather than an explicit binding.
+	Automatic bool
+
+	// Default is the default value of the key, or nil if it has none.
+	Default any
+}
+
+// EnvBindings returns the environment variables of every known key (see AllKeys), sorted by key,
+// eg. to render a table of the supported environment variables in the help of a CLI.
+// Names are reported as they are looked up, after the env key replacer is applied.
+// Keys that are not read from any environment variable are omitted.
+func EnvBindings() []EnvBinding { return v.EnvBindings() }
+
+func (v *Viper) EnvBindings() []EnvBinding {
+	var bindings []EnvBinding
+
+	keys := v.AllKeys()
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		path := strings.Split(key, v.keyDelim)
+
+		binding := EnvBinding{
+			Key:     key,
+			Default: v.searchMap(v.defaults, path),
+		}
+		binding.EnvVars, binding.Automatic = v.envVarsOf(key)
+
+		if len(binding.EnvVars) > 0 {
+			bindings = append(bindings, binding)
+		}
+	}
+
+	return bindings
+}
+
+// envVarsOf returns the names of the environment variables a key is read from, in order of precedence,
+// and whether some of them are checked because of AutomaticEnv.
+func (v *Viper) envVarsOf(key string) (names []string, automatic bool) {
+	add := func(name string) {
+		if v.envKeyReplacer != nil {
+			name = v.envKeyReplacer.Replace(name)
+		}
+
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	// same order as the lookups of Get
+	if v.automaticEnvApplied {
+		envKey := strings.Join(append(v.parents, key), ".")
+		if v.envNestingSeparator != "" {
+			envKey = v.nestedEnvKey(strings.Split(key, v.keyDelim))
+		}
+
+		for _, name := range v.autoEnvNames(envKey) {
+			add(name)
+			automatic = true
+		}
+	}
+
+	for _, name := range v.env[key] {
+		add(name)
+	}
+
+	return names, automatic
+}
+
+// UnrecognizedEnv returns the environment variables (including the ones loaded from .env files)
+// carrying one of the env prefixes that are not read for any known key (see AllKeys), sorted by name,
+// and logs a warning for each of them. It helps catching typos such as MYAPP_PROT instead of MYAPP_PORT.
+//
+// Keys must be known for their variables to be recognized: set defaults, read config files
+// or bind keys (eg. with BindStruct) before calling UnrecognizedEnv.
+// It returns nil when no env prefix is set.
+func UnrecognizedEnv() []string { return v.UnrecognizedEnv() }
+
+func (v *Viper) UnrecognizedEnv() []string {
+	var prefixes []string
+	for _, prefix := range append([]string{v.envPrefix}, v.envFallbackPrefixes...) {
+		if prefix != "" {
+			prefixes = append(prefixes, strings.ToUpper(prefix+"_"))
+		}
+	}
+
+	for _, prefix := range v.envPrefixes {
+		if prefix != "" {
+			prefixes = append(prefixes, strings.ToUpper(prefix+"_"))
+		}
+	}
+
+	if len(prefixes) == 0 {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for _, name := range v.knownEnvNames() {
+		known[name] = true
+	}
+
+	recognized := func(name string) bool {
+		if known[name] {
+			return true
+		}
+
+		if v.envFileSuffix != "" && known[strings.TrimSuffix(name, v.envFileSuffix)] {
+			return true
+		}
+
+		// every variable under a bound prefix maps to a key (see BindEnvPrefix)
+		for _, prefix := range v.envPrefixBindings {
+			if strings.HasPrefix(name, strings.ToUpper(prefix+"_")) {
+				return true
+			}
+		}
+
+		// indexed variables (see WithIndexedEnv)
+		if v.indexedEnv {
+			sep := "_"
+			if v.envNestingSeparator != "" {
+				sep = v.envNestingSeparator
+			}
+
+			for k := range known {
+				if rest, ok := strings.CutPrefix(name, k+sep); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	names := make(map[string]bool)
+
+	if v.envLookup == nil {
+		for name := range v.processEnv() {
+			names[name] = true
+		}
+	}
+
+	for name := range v.dotenv {
+		names[name] = true
+	}
+
+	var unrecognized []string
+
+	for name := range names {
+		if !slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(name, prefix) }) || recognized(name) {
+			continue
+		}
+
+		unrecognized = append(unrecognized, name)
+	}
+
+	slices.Sort(unrecognized)
+
+	for _, name := range unrecognized {
+		v.logger.Warn("unrecognized environment variable", "env", name)
+	}
+
+	return unrecognized
+}
+
+// knownEnvNames returns the names of the environment variables read for known keys and their parent keys,
+// after the env key replacer is applied.
+func (v *Viper) knownEnvNames() []string {
+	var names []string
+
+	add := func(name string) {
+		if v.envKeyReplacer != nil {
+			name = v.envKeyReplacer.Replace(name)
+		}
+
+		names = append(names, name)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, key := range v.AllKeys() {
+		path := strings.Split(key, v.keyDelim)
+
+		// parent keys may be set as a whole (see WithJSONEnv)
+		for i := 1; i <= len(path); i++ {
+			key := strings.Join(path[:i], v.keyDelim)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			envKey := strings.Join(append(v.parents, key), ".")
+			if v.envNestingSeparator != "" {
+				envKey = v.nestedEnvKey(path[:i])
+			}
+
+			for _, name := range v.mergeWithEnvPrefixes(envKey) {
+				add(name)
+			}
+
+			for _, name := range v.env[key] {
+				add(name)
+			}
+		}
+	}
+
+	return names
+}
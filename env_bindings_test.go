@@ -0,0 +1,65 @@
+package viper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvBindings(t *testing.T) {
+	v := New()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("name", "app")
+	require.NoError(t, v.BindEnv("token", "APP_TOKEN", "TOKEN"))
+
+	assert.Equal(t, []EnvBinding{
+		{Key: "token", EnvVars: []string{"APP_TOKEN", "TOKEN"}},
+	}, v.EnvBindings())
+
+	v.AutomaticEnv()
+	require.NoError(t, v.BindEnv("server.port", "PORT"))
+
+	assert.Equal(t, []EnvBinding{
+		{Key: "name", EnvVars: []string{"APP_NAME"}, Automatic: true, Default: "app"},
+		{Key: "server.port", EnvVars: []string{"APP_SERVER_PORT", "PORT"}, Automatic: true, Default: 8080},
+		{Key: "token", EnvVars: []string{"APP_TOKEN", "TOKEN"}, Automatic: true},
+	}, v.EnvBindings())
+
+	// restricted automatic env
+	v.AutomaticEnvStrict("APP_SERVER")
+
+	assert.Equal(t, []EnvBinding{
+		{Key: "server.port", EnvVars: []string{"APP_SERVER_PORT", "PORT"}, Automatic: true, Default: 8080},
+		{Key: "token", EnvVars: []string{"APP_TOKEN", "TOKEN"}},
+	}, v.EnvBindings())
+}
+
+func TestUnrecognizedEnv(t *testing.T) {
+	t.Setenv("MYAPP_PORT", "8080")
+	t.Setenv("MYAPP_PROT", "8080")
+	t.Setenv("MYAPP_DB_HOST", "db.internal")
+	t.Setenv("MYAPP_DB_PASSWORD_FILE", "/run/secrets/db")
+	t.Setenv("MYAPP_DB", `{"host": "db.internal"}`)
+	t.Setenv("MYAPP_SERVERS_0_HOST", "a.example.com")
+	t.Setenv("MYAPP_TOKEN", "token")
+	t.Setenv("OTHER_PROT", "8080")
+
+	v := NewWithOptions(WithEnvFileSuffix("_FILE"), WithIndexedEnv())
+	v.SetEnvPrefix("myapp")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.SetDefault("port", 80)
+	v.SetDefault("db.host", "localhost")
+	v.SetDefault("db.password", "")
+	require.NoError(t, v.BindEnv("servers"))
+
+	assert.Equal(t, []string{"MYAPP_PROT", "MYAPP_TOKEN"}, v.UnrecognizedEnv())
+
+	require.NoError(t, v.BindEnv("token"))
+	assert.Equal(t, []string{"MYAPP_PROT"}, v.UnrecognizedEnv())
+
+	assert.Nil(t, New().UnrecognizedEnv(), "variables cannot be recognized without a prefix")
+}
@@ -0,0 +1,60 @@
+package viper
+
+import (
+	"os"
+	"strings"
+)
+
+// WithEnvSnapshot reads environment variables from a snapshot of the process environment
+// instead of looking them up on every Get, which makes Get faster and keeps values from changing
+// between two calls. The snapshot is taken when the instance is created, and taken again by
+// ReadInConfig (including reloads of watched config files) and RefreshEnv.
+//
+// Custom lookups (see WithEnvLookup) are not snapshotted.
+func WithEnvSnapshot() Option {
+	return optionFunc(func(v *Viper) {
+		v.envSnapshotEnabled = true
+		v.refreshEnvSnapshot()
+	})
+}
+
+// RefreshEnv takes a new snapshot of the environment (see WithEnvSnapshot).
+// It does nothing when environment variables are not snapshotted.
+func RefreshEnv() { v.RefreshEnv() }
+
+func (v *Viper) RefreshEnv() {
+	v.refreshEnvSnapshot()
+}
+
+func (v *Viper) refreshEnvSnapshot() {
+	if !v.envSnapshotEnabled {
+		return
+	}
+
+	environ := os.Environ()
+	snapshot := make(map[string]string, len(environ))
+
+	for _, env := range environ {
+		key, val, _ := strings.Cut(env, "=")
+		snapshot[key] = val
+	}
+
+	v.envSnapshot.Store(&snapshot)
+}
+
+// processEnv returns the variables of the process environment, from the snapshot if there is one.
+func (v *Viper) processEnv() map[string]string {
+	if snapshot := v.envSnapshot.Load(); snapshot != nil {
+		return *snapshot
+	}
+
+	environ := os.Environ()
+	env := make(map[string]string, len(environ))
+
+	for _, kv := range environ {
+		key, val, _ := strings.Cut(kv, "=")
+		env[key] = val
+	}
+
+	return env
+}
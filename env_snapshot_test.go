@@ -0,0 +1,55 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEnvSnapshot(t *testing.T) {
+	t.Setenv("APP_HOST", "a.example.com")
+
+	v := NewWithOptions(WithEnvSnapshot())
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+
+	assert.Equal(t, "a.example.com", v.Get("host"))
+
+	t.Setenv("APP_HOST", "b.example.com")
+	t.Setenv("APP_PORT", "8080")
+
+	assert.Equal(t, "a.example.com", v.Get("host"))
+	assert.Nil(t, v.Get("port"))
+
+	v.RefreshEnv()
+
+	assert.Equal(t, "b.example.com", v.Get("host"))
+	assert.Equal(t, "8080", v.Get("port"))
+
+	t.Setenv("APP_HOST", "c.example.com")
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: app"), 0o644))
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	assert.Equal(t, "c.example.com", v.Get("host"), "ReadInConfig refreshes the snapshot")
+}
+
+func TestWithEnvSnapshot_Disabled(t *testing.T) {
+	t.Setenv("APP_HOST", "a.example.com")
+
+	v := New()
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("name: app")))
+
+	t.Setenv("APP_HOST", "b.example.com")
+
+	assert.Equal(t, "b.example.com", v.Get("host"))
+}
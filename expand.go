@@ -0,0 +1,150 @@
+package viper
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// ExpansionSource is a source of values for references expanded in configuration values
+// (see WithValueExpansion).
+type ExpansionSource int
+
+const (
+	// ExpandEnv resolves references from environment variables (and .env files, see WithDotenv).
+	ExpandEnv ExpansionSource = iota
+
+	// ExpandKeys resolves references from other keys: keys of the same config file first,
+	// then keys of the effective configuration (eg. defaults, flags or previously read files).
+	ExpandKeys
+)
+
+// maxExpansionDepth bounds the expansion of references to keys whose values contain references,
+// so that cyclic references cannot expand forever.
+const maxExpansionDepth = 8
+
+// WithValueExpansion expands references in the string values of config files and remote providers
+// right after they are decoded, including values nested in maps and slices:
+//
+//   - ${NAME} is replaced with the value of NAME, or an empty string if it has none
+//   - ${NAME:-default} is replaced with the value of NAME, or default if it is unset or empty
+//   - $${ is replaced with a literal ${
+//
+// References are resolved from the given sources in order (environment variables by default).
+// Values set with Set, SetDefault or read from flags and environment variables are never expanded.
+func WithValueExpansion(sources ...ExpansionSource) Option {
+	return optionFunc(func(v *Viper) {
+		if len(sources) == 0 {
+			sources = []ExpansionSource{ExpandEnv}
+		}
+
+		v.expansionSources = sources
+	})
+}
+
+// expandConfig expands references in the values of a decoded configuration, in place.
+func (v *Viper) expandConfig(c map[string]any) {
+	if len(v.expansionSources) == 0 {
+		return
+	}
+
+	var lookup func(name string, depth int) (string, bool)
+
+	lookup = func(name string, depth int) (string, bool) {
+		for _, source := range v.expansionSources {
+			switch source {
+			case ExpandEnv:
+				if val, ok := v.lookupEnv(name); ok {
+					return val, true
+				}
+
+			case ExpandKeys:
+				val := v.searchIndexableWithPathPrefixes(c, v.configPath(name, strings.Split(strings.ToLower(name), v.keyDelim)))
+				if val == nil {
+					val = v.Get(name)
+				}
+				if val == nil {
+					continue
+				}
+
+				s := cast.ToString(val)
+				if depth < maxExpansionDepth {
+					s = expandString(s, func(name string) (string, bool) { return lookup(name, depth+1) })
+				}
+
+				return s, true
+			}
+		}
+
+		return "", false
+	}
+
+	expandMap(c, func(name string) (string, bool) { return lookup(name, 0) })
+}
+
+func expandMap(m map[string]any, lookup func(name string) (string, bool)) {
+	for key, val := range m {
+		m[key] = expandValue(val, lookup)
+	}
+}
+
+func expandValue(val any, lookup func(name string) (string, bool)) any {
+	switch val := val.(type) {
+	case string:
+		return expandString(val, lookup)
+	case map[string]any:
+		expandMap(val, lookup)
+	case map[any]any:
+		for key, v := range val {
+			val[key] = expandValue(v, lookup)
+		}
+	case []any:
+		for i, v := range val {
+			val[i] = expandValue(v, lookup)
+		}
+	}
+
+	return val
+}
+
+// expandString replaces the references in s (see WithValueExpansion).
+func expandString(s string, lookup func(name string) (string, bool)) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			b.WriteString("${")
+			i += 3
+
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				// unterminated references are kept as is
+				b.WriteString(s[i:])
+
+				return b.String()
+			}
+
+			name, def, hasDefault := strings.Cut(s[i+2:i+2+end], ":-")
+
+			val, ok := lookup(name)
+			if hasDefault && (!ok || val == "") {
+				val = def
+			}
+
+			b.WriteString(val)
+			i += end + 3
+
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+
+	return b.String()
+}
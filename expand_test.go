@@ -0,0 +1,70 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithValueExpansion(t *testing.T) {
+	t.Setenv("API_HOST", "api.example.com")
+	t.Setenv("EMPTY", "")
+
+	config := []byte(`
+url: "https://${API_HOST}:${API_PORT:-8080}/v1"
+empty: "${EMPTY:-fallback}"
+missing: "[${MISSING}]"
+escaped: "$${API_HOST}"
+unterminated: "${API_HOST"
+password: "pa$$word"
+servers:
+  - "${API_HOST}"
+  - name: "${API_HOST}"
+nested:
+  endpoint: "${nested.scheme}://${API_HOST}"
+  scheme: https
+`)
+
+	t.Run("Env", func(t *testing.T) {
+		v := NewWithOptions(WithValueExpansion())
+		v.SetConfigType("yaml")
+		require.NoError(t, v.ReadConfig(bytes.NewReader(config)))
+
+		assert.Equal(t, "https://api.example.com:8080/v1", v.GetString("url"))
+		assert.Equal(t, "fallback", v.GetString("empty"))
+		assert.Equal(t, "[]", v.GetString("missing"))
+		assert.Equal(t, "${API_HOST}", v.GetString("escaped"))
+		assert.Equal(t, "${API_HOST", v.GetString("unterminated"))
+		assert.Equal(t, "pa$$word", v.GetString("password"))
+		assert.Equal(t, []any{"api.example.com", map[string]any{"name": "api.example.com"}}, v.Get("servers"))
+		assert.Equal(t, "://api.example.com", v.GetString("nested.endpoint"))
+	})
+
+	t.Run("EnvAndKeys", func(t *testing.T) {
+		v := NewWithOptions(WithValueExpansion(ExpandEnv, ExpandKeys))
+		v.SetDefault("api_port", 9090)
+		v.SetConfigType("yaml")
+		require.NoError(t, v.ReadConfig(bytes.NewReader(config)))
+
+		assert.Equal(t, "https://api.example.com:9090/v1", v.GetString("url"))
+		assert.Equal(t, "https://api.example.com", v.GetString("nested.endpoint"))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		v := New()
+		v.SetConfigType("yaml")
+		require.NoError(t, v.ReadConfig(bytes.NewReader(config)))
+
+		assert.Equal(t, "https://${API_HOST}:${API_PORT:-8080}/v1", v.GetString("url"))
+	})
+}
+
+func TestWithValueExpansion_Cycle(t *testing.T) {
+	v := NewWithOptions(WithValueExpansion(ExpandKeys))
+	v.SetConfigType("json")
+
+	// cyclic references stop expanding instead of looping forever
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`{"a": "${b}", "b": "${a}"}`)))
+}
@@ -0,0 +1,67 @@
+package viper
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// kubernetesNamespaceFile is where the service account token mount exposes
+// the namespace of the running pod.
+const kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// WithFacts enables the read-only "facts" layer.
+//
+// Facts are computed from the runtime environment when the option is applied
+// and are exposed under the "facts" key:
+//
+//	facts.hostname
+//	facts.num_cpu
+//	facts.pid
+//
+// When running in Kubernetes, the pod name and namespace are exposed as
+// "facts.pod.name" and "facts.pod.namespace". They are read from the POD_NAME
+// and POD_NAMESPACE environment variables (as populated by the downward API),
+// falling back to the hostname and the service account namespace file.
+//
+// Facts take precedence over every other configuration source,
+// so they cannot be overridden by Set, flags, env or config files.
+func WithFacts() Option {
+	return optionFunc(func(v *Viper) {
+		v.facts = map[string]any{
+			"facts": computeFacts(),
+		}
+	})
+}
+
+func computeFacts() map[string]any {
+	facts := map[string]any{
+		"num_cpu": runtime.NumCPU(),
+		"pid":     os.Getpid(),
+	}
+
+	hostname, err := os.Hostname()
+	if err == nil {
+		facts["hostname"] = hostname
+	}
+
+	if _, ok := os.LookupEnv("KUBERNETES_SERVICE_HOST"); ok {
+		pod := map[string]any{}
+
+		if name := os.Getenv("POD_NAME"); name != "" {
+			pod["name"] = name
+		} else if hostname != "" {
+			pod["name"] = hostname
+		}
+
+		if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+			pod["namespace"] = namespace
+		} else if b, err := os.ReadFile(kubernetesNamespaceFile); err == nil {
+			pod["namespace"] = strings.TrimSpace(string(b))
+		}
+
+		facts["pod"] = pod
+	}
+
+	return facts
+}
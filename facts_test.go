@@ -0,0 +1,45 @@
+package viper
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFacts(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAME", "myapp-5d9c7b")
+	t.Setenv("POD_NAMESPACE", "prod")
+
+	v := NewWithOptions(WithFacts())
+
+	hostname, _ := os.Hostname()
+
+	assert.Equal(t, hostname, v.GetString("facts.hostname"))
+	assert.Equal(t, runtime.NumCPU(), v.GetInt("facts.num_cpu"))
+	assert.Equal(t, os.Getpid(), v.GetInt("facts.pid"))
+	assert.Equal(t, "myapp-5d9c7b", v.GetString("facts.pod.name"))
+	assert.Equal(t, "prod", v.GetString("facts.pod.namespace"))
+
+	assert.Contains(t, v.AllKeys(), "facts.hostname")
+	assert.Contains(t, v.AllKeys(), "facts.pod.namespace")
+}
+
+func TestFacts_ReadOnly(t *testing.T) {
+	v := NewWithOptions(WithFacts())
+
+	v.Set("facts.pid", 1)
+	v.SetDefault("facts.hostname", "overridden")
+
+	assert.Equal(t, os.Getpid(), v.GetInt("facts.pid"))
+	assert.NotEqual(t, "overridden", v.GetString("facts.hostname"))
+}
+
+func TestFacts_Disabled(t *testing.T) {
+	v := New()
+
+	assert.Nil(t, v.Get("facts.pid"))
+	assert.False(t, v.IsSet("facts.hostname"))
+}
@@ -1,6 +1,15 @@
 package viper
 
-import "github.com/spf13/pflag"
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+)
 
 // FlagValueSet is an interface that users can implement
 // to bind a set of flags to viper.
@@ -17,6 +26,11 @@ type FlagValue interface {
 	ValueType() string
 }
 
+// PFlagValueSet adapts a pflag flag set to FlagValueSet, eg. to bind it with BindFlagValuesFiltered.
+func PFlagValueSet(flags *pflag.FlagSet) FlagValueSet {
+	return pflagValueSet{flags}
+}
+
 // pflagValueSet is a wrapper around *pflag.ValueSet
 // that implements FlagValueSet.
 type pflagValueSet struct {
@@ -55,3 +69,181 @@ func (p pflagValue) ValueString() string {
 func (p pflagValue) ValueType() string {
 	return p.flag.Value.Type()
 }
+
+// Usage returns the usage message of the flag.
+func (p pflagValue) Usage() string {
+	return p.flag.Usage
+}
+
+// DefaultValueString returns the default value of the flag as a string.
+func (p pflagValue) DefaultValueString() string {
+	return p.flag.DefValue
+}
+
+// GoFlagValueSet adapts a flag set of the standard library flag package to FlagValueSet,
+// so that it can be bound with BindFlagValues (see BindGoFlags).
+// Flags are considered changed when they were set on the command line (see flag.FlagSet.Visit).
+func GoFlagValueSet(flags *flag.FlagSet) FlagValueSet {
+	return goFlagValueSet{flags}
+}
+
+// goFlagValueSet is a wrapper around *flag.FlagSet
+// that implements FlagValueSet.
+type goFlagValueSet struct {
+	flags *flag.FlagSet
+}
+
+// VisitAll iterates over all *flag.Flag inside the *flag.FlagSet.
+func (p goFlagValueSet) VisitAll(fn func(flag FlagValue)) {
+	p.flags.VisitAll(func(flag *flag.Flag) {
+		fn(goFlagValue{p.flags, flag})
+	})
+}
+
+// goFlagValue is a wrapper around *flag.Flag
+// that implements FlagValue.
+type goFlagValue struct {
+	flags *flag.FlagSet
+	flag  *flag.Flag
+}
+
+// HasChanged returns whether the flag was set on the command line.
+func (p goFlagValue) HasChanged() bool {
+	var changed bool
+
+	p.flags.Visit(func(flag *flag.Flag) {
+		if flag == p.flag {
+			changed = true
+		}
+	})
+
+	return changed
+}
+
+// Name returns the name of the flag.
+func (p goFlagValue) Name() string {
+	return p.flag.Name
+}
+
+// ValueString returns the value of the flag as a string.
+func (p goFlagValue) ValueString() string {
+	return p.flag.Value.String()
+}
+
+// ValueType returns the type of the flag as a string, named like the types of pflag.
+func (p goFlagValue) ValueType() string {
+	getter, ok := p.flag.Value.(flag.Getter)
+	if !ok {
+		return "string"
+	}
+
+	switch getter.Get().(type) {
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case int64:
+		return "int64"
+	case uint:
+		return "uint"
+	case uint64:
+		return "uint64"
+	case float64:
+		return "float64"
+	case time.Duration:
+		return "duration"
+	}
+
+	return "string"
+}
+
+// Usage returns the usage message of the flag.
+func (p goFlagValue) Usage() string {
+	return p.flag.Usage
+}
+
+// DefaultValueString returns the default value of the flag as a string.
+func (p goFlagValue) DefaultValueString() string {
+	return p.flag.DefValue
+}
+
+// flagValueOf returns the value of a flag, converted according to its pflag type.
+// Values of unknown types are returned as strings.
+func flagValueOf(flag FlagValue) any {
+	switch flag.ValueType() {
+	case "int", "int8", "int16", "int32", "int64", "count":
+		return cast.ToInt(flag.ValueString())
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return cast.ToUint(flag.ValueString())
+	case "float32", "float64":
+		return cast.ToFloat64(flag.ValueString())
+	case "bool":
+		return cast.ToBool(flag.ValueString())
+	case "duration":
+		return cast.ToDuration(flag.ValueString())
+	case "bytesHex":
+		b, err := hex.DecodeString(flag.ValueString())
+		if err != nil {
+			return nil
+		}
+		return b
+	case "bytesBase64":
+		b, err := base64.StdEncoding.DecodeString(flag.ValueString())
+		if err != nil {
+			return nil
+		}
+		return b
+	case "stringSlice", "stringArray", "ipSlice":
+		return flagSliceOf(flag)
+	case "intSlice":
+		return cast.ToIntSlice(flagSliceOf(flag))
+	case "int32Slice":
+		return convertSlice(flagSliceOf(flag), cast.ToInt32)
+	case "int64Slice":
+		return convertSlice(flagSliceOf(flag), cast.ToInt64)
+	case "uintSlice":
+		return convertSlice(flagSliceOf(flag), cast.ToUint)
+	case "float32Slice":
+		return convertSlice(flagSliceOf(flag), cast.ToFloat32)
+	case "float64Slice":
+		return convertSlice(flagSliceOf(flag), cast.ToFloat64)
+	case "boolSlice":
+		return convertSlice(flagSliceOf(flag), cast.ToBool)
+	case "durationSlice":
+		s := strings.TrimPrefix(flag.ValueString(), "[")
+		s = strings.TrimSuffix(s, "]")
+		slice := strings.Split(s, ",")
+		return cast.ToDurationSlice(slice)
+	case "stringToString":
+		return stringToStringConv(flag.ValueString())
+	case "stringToInt":
+		return stringToIntConv(flag.ValueString())
+	case "stringToInt64":
+		m, ok := stringToIntConv(flag.ValueString()).(map[string]any)
+		if !ok {
+			return nil
+		}
+		for k, v := range m {
+			m[k] = cast.ToInt64(v)
+		}
+		return m
+	default:
+		return flag.ValueString()
+	}
+}
+
+// flagSliceOf returns the elements of a slice flag, formatted like "[a,b]".
+func flagSliceOf(flag FlagValue) []string {
+	s := strings.TrimPrefix(flag.ValueString(), "[")
+	s = strings.TrimSuffix(s, "]")
+	res, _ := readAsCSV(s)
+	return res
+}
+
+func convertSlice[T any](s []string, conv func(any) T) []T {
+	out := make([]T, len(s))
+	for i, e := range s {
+		out[i] = conv(e)
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FlagsFromStruct creates a flag for the key of every field of a struct, and binds it to the key (see BindPFlag).
+//
+// Keys are derived from the struct type the same way as BindStruct. Flags are named after them,
+// with the key delimiter replaced by dashes (eg. "database-host" for "database.host"),
+// and typed after the fields. The following struct tags are read:
+//
+//   - default: the default value of the flag (lists are comma separated)
+//   - usage: the usage message of the flag
+//
+// Supported field types are strings, booleans, integers, floats, time.Duration,
+// and slices of strings, integers and durations. Fields of other types get no flag.
+//
+//	type Config struct {
+//		Port     int           `mapstructure:"port" default:"8080" usage:"port to listen on"`
+//		Database struct {
+//			Host string `default:"localhost"`
+//		}
+//	}
+//
+//	flags, err := viper.FlagsFromStruct(&Config{}) // --port and --database-host
+func FlagsFromStruct(cfg any) (*pflag.FlagSet, error) { return v.FlagsFromStruct(cfg) }
+
+func (v *Viper) FlagsFromStruct(cfg any) (*pflag.FlagSet, error) {
+	typ := reflect.TypeOf(cfg)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, errors.New("FlagsFromStruct requires a struct or a pointer to a struct")
+	}
+
+	config := v.defaultDecoderConfig(nil)
+
+	tagName := config.TagName
+	if tagName == "" {
+		tagName = "mapstructure"
+	}
+
+	flags := pflag.NewFlagSet(strings.ToLower(typ.Name()), pflag.ContinueOnError)
+
+	var (
+		keys []string
+		errs []error
+	)
+
+	v.walkStructFields(typ, tagName, config.Squash, "", nil, func(key string, field reflect.StructField) {
+		name := strings.ReplaceAll(key, v.keyDelim, "-")
+
+		ok, err := addStructFlag(flags, name, field)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("flag %s: %w", name, err))
+
+			return
+		}
+
+		if ok {
+			keys = append(keys, key)
+		}
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if err := v.BindPFlag(key, flags.Lookup(strings.ReplaceAll(key, v.keyDelim, "-"))); err != nil {
+			return nil, err
+		}
+	}
+
+	return flags, nil
+}
+
+// addStructFlag defines a flag typed after a struct field, and reports whether the type of the field is supported.
+func addStructFlag(flags *pflag.FlagSet, name string, field reflect.StructField) (bool, error) {
+	def, hasDefault := field.Tag.Lookup("default")
+	usage := field.Tag.Get("usage")
+
+	typ := field.Type
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	var list []string
+	if hasDefault {
+		var err error
+		if list, err = readAsCSV(def); err != nil {
+			return false, fmt.Errorf("invalid default %q: %w", def, err)
+		}
+	}
+
+	var err error
+
+	switch {
+	case typ == durationType:
+		var d time.Duration
+		if hasDefault {
+			d, err = cast.ToDurationE(def)
+		}
+		flags.Duration(name, d, usage)
+
+	case typ.Kind() == reflect.Slice && typ.Elem() == durationType:
+		var d []time.Duration
+		if hasDefault {
+			d, err = cast.ToDurationSliceE(list)
+		}
+		flags.DurationSlice(name, d, usage)
+
+	case typ.Kind() == reflect.String:
+		flags.String(name, def, usage)
+
+	case typ.Kind() == reflect.Bool:
+		var b bool
+		if hasDefault {
+			b, err = cast.ToBoolE(def)
+		}
+		flags.Bool(name, b, usage)
+
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		var i int64
+		if hasDefault {
+			i, err = cast.ToInt64E(def)
+		}
+		flags.Int64(name, i, usage)
+
+	case typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uint64:
+		var u uint64
+		if hasDefault {
+			u, err = cast.ToUint64E(def)
+		}
+		flags.Uint64(name, u, usage)
+
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		var f float64
+		if hasDefault {
+			f, err = cast.ToFloat64E(def)
+		}
+		flags.Float64(name, f, usage)
+
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String:
+		flags.StringSlice(name, list, usage)
+
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Int:
+		var ints []int
+		if hasDefault {
+			ints, err = cast.ToIntSliceE(list)
+		}
+		flags.IntSlice(name, ints, usage)
+
+	default:
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("invalid default %q: %w", def, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,62 @@
+package viper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flagsStructConfig struct {
+	Port     int           `mapstructure:"port" default:"8080" usage:"port to listen on"`
+	Debug    bool          `default:"true"`
+	Timeout  time.Duration `default:"5s"`
+	Ratio    float64
+	Hosts    []string `default:"a,b"`
+	Ports    []int    `mapstructure:"extra_ports"`
+	Database struct {
+		Host string `default:"localhost"`
+		Pool *struct {
+			Size uint `default:"10"`
+		}
+	}
+	Labels map[string]string
+}
+
+func TestFlagsFromStruct(t *testing.T) {
+	v := New()
+
+	flags, err := v.FlagsFromStruct(&flagsStructConfig{})
+	require.NoError(t, err)
+
+	port := flags.Lookup("port")
+	require.NotNil(t, port)
+	assert.Equal(t, "8080", port.DefValue)
+	assert.Equal(t, "port to listen on", port.Usage)
+
+	assert.NotNil(t, flags.Lookup("extra_ports"))
+	assert.NotNil(t, flags.Lookup("database-pool-size"))
+	assert.Nil(t, flags.Lookup("labels"), "unsupported types get no flag")
+
+	require.NoError(t, flags.Parse([]string{"--database-host", "db.internal", "--hosts", "c", "--ratio", "0.5"}))
+
+	var config flagsStructConfig
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, 8080, config.Port)
+	assert.True(t, config.Debug)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, 0.5, config.Ratio)
+	assert.Equal(t, []string{"c"}, config.Hosts)
+	assert.Equal(t, "db.internal", config.Database.Host)
+	assert.Equal(t, uint(10), config.Database.Pool.Size)
+
+	_, err = v.FlagsFromStruct(struct {
+		Port int `default:"http"`
+	}{})
+	require.Error(t, err)
+
+	_, err = v.FlagsFromStruct("not a struct")
+	require.Error(t, err)
+}
@@ -1,7 +1,9 @@
 package viper
 
 import (
+	"flag"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
@@ -63,3 +65,30 @@ func TestBindFlagValue(t *testing.T) {
 
 	assert.Equal(t, "testing_mutate", Get("testvalue"))
 }
+
+func TestBindGoFlags(t *testing.T) {
+	v := New()
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.Int("port", 8080, "test")
+	flagSet.Bool("debug", false, "test")
+	flagSet.Duration("timeout", time.Second, "test")
+	flagSet.String("host", "localhost", "test")
+	flagSet.String("name", "app", "test")
+
+	v.SetDefault("host", "example.com")
+	v.Set("debug", false)
+
+	require.NoError(t, v.BindGoFlags(flagSet))
+	require.NoError(t, flagSet.Parse([]string{"-port", "9090", "-timeout", "5s"}))
+
+	assert.Equal(t, 9090, v.Get("port"))
+	assert.Equal(t, 5*time.Second, v.GetDuration("timeout"))
+	assert.Equal(t, false, v.Get("debug"), "overrides take precedence")
+	assert.Equal(t, "example.com", v.Get("host"), "defaults take precedence over flag defaults")
+	assert.Equal(t, "app", v.Get("name"))
+
+	port := flagSet.Lookup("port")
+	assert.Equal(t, "int", goFlagValue{flagSet, port}.ValueType())
+	assert.True(t, goFlagValue{flagSet, port}.HasChanged())
+	assert.False(t, goFlagValue{flagSet, flagSet.Lookup("host")}.HasChanged())
+}
@@ -0,0 +1,74 @@
+package viper
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// AddConfigFragmentDir adds a conf.d-style directory of configuration fragments.
+//
+// ReadInConfig reads every file of the directory with a supported extension
+// (see SupportedExts) in lexical order and deep merges them into the config file,
+// so later fragments take precedence over earlier ones and over the config file itself.
+// Hidden files and subdirectories are ignored, as is a missing directory.
+// When fragment directories are added, finding no config file is not an error.
+//
+// WatchConfig watches the directory and reads the configuration again
+// whenever a fragment is added, removed or edited.
+func AddConfigFragmentDir(dir string) { v.AddConfigFragmentDir(dir) }
+
+func (v *Viper) AddConfigFragmentDir(dir string) {
+	if dir == "" {
+		return
+	}
+
+	dir = filepath.Clean(absPathify(v.logger, dir))
+	if !slices.Contains(v.configFragmentDirs, dir) {
+		v.logger.Info("adding config fragment directory", "dir", dir)
+
+		v.configFragmentDirs = append(v.configFragmentDirs, dir)
+	}
+}
+
+// readConfigFragments lists the fragments of a fragment directory, in lexical order.
+func (v *Viper) readConfigFragments(dir string) ([]configSource, error) {
+	entries, err := afero.ReadDir(v.fs, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fragments []configSource
+
+	// afero.ReadDir returns entries sorted by name
+	for _, entry := range entries {
+		if !isConfigFragment(entry.Name()) || entry.IsDir() {
+			continue
+		}
+
+		fragments = append(fragments, configSource{
+			file:   filepath.Join(dir, entry.Name()),
+			format: strings.TrimPrefix(filepath.Ext(entry.Name()), "."),
+		})
+	}
+
+	return fragments, nil
+}
+
+// isConfigFragment reports whether a file name can be read as a configuration fragment.
+func isConfigFragment(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+
+	ext := filepath.Ext(name)
+
+	return len(ext) > 1 && slices.Contains(SupportedExts, strings.ToLower(ext[1:]))
+}
@@ -0,0 +1,104 @@
+package viper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddConfigFragmentDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("db:\n  host: localhost\n  port: 5432\nname: app\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/10-db.yaml", []byte("db:\n  host: db.internal\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/20-db.json", []byte(`{"db": {"host": "db.override"}}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/30-log.toml", []byte("[log]\nlevel = \"debug\"\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/README", []byte("not a fragment"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/.99-hidden.yaml", []byte("name: hidden\n"), 0o644))
+
+	t.Run("WithConfigFile", func(t *testing.T) {
+		v := New()
+		v.SetFs(fs)
+		v.SetConfigFile("/etc/app/config.yaml")
+		v.AddConfigFragmentDir("/etc/app/conf.d")
+		require.NoError(t, v.ReadInConfig())
+
+		assert.Equal(t, "db.override", v.GetString("db.host"))
+		assert.Equal(t, 5432, v.GetInt("db.port"))
+		assert.Equal(t, "debug", v.GetString("log.level"))
+		assert.Equal(t, "app", v.GetString("name"))
+	})
+
+	t.Run("FragmentsOnly", func(t *testing.T) {
+		v := New()
+		v.SetFs(fs)
+		v.AddConfigPath("/etc/missing")
+		v.AddConfigFragmentDir("/etc/app/conf.d")
+		require.NoError(t, v.ReadInConfig())
+
+		assert.Equal(t, "db.override", v.GetString("db.host"))
+	})
+
+	t.Run("MissingDir", func(t *testing.T) {
+		v := New()
+		v.SetFs(fs)
+		v.SetConfigFile("/etc/app/config.yaml")
+		v.AddConfigFragmentDir("/etc/app/missing.d")
+		require.NoError(t, v.ReadInConfig())
+
+		assert.Equal(t, "localhost", v.GetString("db.host"))
+	})
+}
+
+func TestAddConfigFragmentDir_Watch(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "conf.d")
+	require.NoError(t, os.Mkdir(dir, 0o777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "10-base.yaml"), []byte("foo: bar\n"), 0o640))
+
+	v := New()
+	v.AddConfigFragmentDir(dir)
+	require.NoError(t, v.ReadInConfig())
+	require.Equal(t, "bar", v.GetString("foo"))
+
+	// settings are read from the watcher goroutine to avoid racing with reloads
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+
+	waitFor := func(want string) {
+		t.Helper()
+
+		timeout := time.After(5 * time.Second)
+
+		for {
+			select {
+			case got := <-changes:
+				if got == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", want)
+			}
+		}
+	}
+
+	// added fragment
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "20-override.yaml"), []byte("foo: baz\n"), 0o640))
+	waitFor("baz")
+
+	// edited fragment
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "20-override.yaml"), []byte("foo: qux\n"), 0o640))
+	waitFor("qux")
+
+	// removed fragment
+	require.NoError(t, os.Remove(filepath.Join(dir, "20-override.yaml")))
+	waitFor("bar")
+}
@@ -0,0 +1,112 @@
+package viper
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper/internal/maputil"
+)
+
+// WithIndexedEnv makes environment variables with an index after the name of a key
+// populate a list for that key, when the key itself has no environment variable
+// (with AutomaticEnv or BindEnv), eg. for the "servers" key:
+//
+//	SERVERS_0_HOST=a.example.com
+//	SERVERS_0_PORT=8080
+//	SERVERS_1_HOST=b.example.com
+//
+// is read as a list of two maps (that Unmarshal decodes into a slice of structs),
+// and SERVERS_0=a, SERVERS_1=b as a list of strings. Items are listed in index order.
+//
+// Field names are lower cased; they may contain underscores (eg. SERVERS_0_MAX_CONNS is read as "max_conns").
+// With an env nesting separator (see SetEnvNestingSeparator), the separator is used around indexes
+// and between the parts of nested fields instead (eg. SERVERS__0__TLS__CERT).
+func WithIndexedEnv() Option {
+	return optionFunc(func(v *Viper) {
+		v.indexedEnv = true
+	})
+}
+
+// getIndexedEnv assembles the list read from the environment variables indexed under name.
+func (v *Viper) getIndexedEnv(name string) ([]any, bool) {
+	if v.envKeyReplacer != nil {
+		name = v.envKeyReplacer.Replace(name)
+	}
+
+	sep := "_"
+	if v.envNestingSeparator != "" {
+		sep = v.envNestingSeparator
+	}
+
+	prefix := name + sep
+	items := make(map[int]any)
+
+	collect := func(key, val string) {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || (val == "" && !v.allowEmptyEnv) {
+			return
+		}
+
+		index, field, hasField := strings.Cut(rest, sep)
+
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			return
+		}
+
+		// fields take precedence over a value for the whole item
+		if !hasField {
+			if _, ok := items[i]; !ok {
+				items[i] = val
+			}
+
+			return
+		}
+
+		item, ok := items[i].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			items[i] = item
+		}
+
+		path := []string{strings.ToLower(field)}
+		if v.envNestingSeparator != "" {
+			path = strings.Split(path[0], sep)
+		}
+
+		maputil.DeepSearch(item, path[:len(path)-1])[path[len(path)-1]] = val
+	}
+
+	// custom env lookups cannot be enumerated
+	if v.envLookup == nil {
+		for key, val := range v.processEnv() {
+			collect(key, val)
+		}
+	}
+
+	// the real environment takes precedence over .env files
+	for key, val := range v.dotenv {
+		if _, ok := v.lookupRealEnv(key); !ok {
+			collect(key, val)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	indexes := make([]int, 0, len(items))
+	for i := range items {
+		indexes = append(indexes, i)
+	}
+
+	slices.Sort(indexes)
+
+	list := make([]any, 0, len(indexes))
+	for _, i := range indexes {
+		list = append(list, items[i])
+	}
+
+	return list, true
+}
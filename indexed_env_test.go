@@ -0,0 +1,77 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type indexedEnvServer struct {
+	Host     string
+	Port     int
+	MaxConns int `mapstructure:"max_conns"`
+}
+
+func TestWithIndexedEnv(t *testing.T) {
+	t.Setenv("APP_SERVERS_1_HOST", "b.example.com")
+	t.Setenv("APP_SERVERS_0_HOST", "a.example.com")
+	t.Setenv("APP_SERVERS_0_PORT", "8080")
+	t.Setenv("APP_SERVERS_0_MAX_CONNS", "10")
+	t.Setenv("APP_SERVERS_X_HOST", "ignored")
+	t.Setenv("APP_TAGS_0", "a")
+	t.Setenv("APP_TAGS_1", "b")
+
+	t.Run("AutomaticEnv", func(t *testing.T) {
+		v := NewWithOptions(WithIndexedEnv())
+		v.SetEnvPrefix("app")
+		v.AutomaticEnv()
+
+		assert.Equal(t, []any{"a", "b"}, v.Get("tags"))
+		assert.Equal(t, []any{
+			map[string]any{"host": "a.example.com", "port": "8080", "max_conns": "10"},
+			map[string]any{"host": "b.example.com"},
+		}, v.Get("servers"))
+	})
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		v := NewWithOptions(WithIndexedEnv())
+		v.SetEnvPrefix("app")
+		require.NoError(t, v.BindEnv("servers"))
+		v.SetDefault("tags", []string{"default"})
+		v.AutomaticEnv()
+
+		var config struct {
+			Servers []indexedEnvServer
+			Tags    []string
+		}
+		require.NoError(t, v.Unmarshal(&config))
+
+		assert.Equal(t, []indexedEnvServer{
+			{Host: "a.example.com", Port: 8080, MaxConns: 10},
+			{Host: "b.example.com"},
+		}, config.Servers)
+		assert.Equal(t, []string{"a", "b"}, config.Tags)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		v := New()
+		v.SetEnvPrefix("app")
+		v.AutomaticEnv()
+
+		assert.Nil(t, v.Get("servers"))
+	})
+}
+
+func TestWithIndexedEnv_NestingSeparator(t *testing.T) {
+	t.Setenv("SERVERS__0__TLS__CERT", "a.pem")
+	t.Setenv("SERVERS__0__MAX_CONNS", "10")
+
+	v := NewWithOptions(WithIndexedEnv())
+	v.SetEnvNestingSeparator("__")
+	v.AutomaticEnv()
+
+	assert.Equal(t, []any{
+		map[string]any{"tls": map[string]any{"cert": "a.pem"}, "max_conns": "10"},
+	}, v.Get("servers"))
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/subosito/gotenv"
+
+	"github.com/spf13/viper/internal/maputil"
 )
 
 const keyDelimiter = "_"
@@ -16,9 +18,7 @@ const keyDelimiter = "_"
 type Codec struct{}
 
 func (Codec) Encode(v map[string]any) ([]byte, error) {
-	flattened := map[string]any{}
-
-	flattened = flattenAndMergeMap(flattened, v, "", keyDelimiter)
+	flattened := maputil.Flatten(v, keyDelimiter)
 
 	keys := make([]string, 0, len(flattened))
 
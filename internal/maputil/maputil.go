@@ -0,0 +1,110 @@
+// Package maputil implements the nested map manipulations shared by Viper and its codecs.
+package maputil
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Flatten flattens the nested maps of m into a single level map
+// whose keys are the lower cased paths to each value, joined by delimiter.
+func Flatten(m map[string]any, delimiter string) map[string]any {
+	return flattenAndMergeMap(nil, m, "", delimiter)
+}
+
+// flattenAndMergeMap recursively flattens the given map into a new map.
+func flattenAndMergeMap(shadow, m map[string]any, prefix, delimiter string) map[string]any {
+	if shadow != nil && prefix != "" && shadow[prefix] != nil {
+		// prefix is shadowed => nothing more to flatten
+		return shadow
+	}
+	if shadow == nil {
+		shadow = make(map[string]any)
+	}
+
+	var m2 map[string]any
+	if prefix != "" {
+		prefix += delimiter
+	}
+	for k, val := range m {
+		fullKey := prefix + k
+		switch val := val.(type) {
+		case map[string]any:
+			m2 = val
+		case map[any]any:
+			m2 = cast.ToStringMap(val)
+		default:
+			// immediate value
+			shadow[strings.ToLower(fullKey)] = val
+			continue
+		}
+		// recursively merge to shadow map
+		shadow = flattenAndMergeMap(shadow, m2, fullKey, delimiter)
+	}
+	return shadow
+}
+
+// Unflatten builds nested maps from a single level map whose keys are paths joined by delimiter.
+// Keys are lower cased. Nested keys take precedence over values of their parent keys
+// (eg. "db.host" replaces a "db" value).
+func Unflatten(m map[string]any, delimiter string) map[string]any {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	// parent keys are sorted before their nested keys
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	nested := make(map[string]any)
+
+	for _, key := range keys {
+		path := strings.Split(strings.ToLower(key), delimiter)
+		lastKey := path[len(path)-1]
+		deepestMap := DeepSearch(nested, path[0:len(path)-1])
+
+		// set innermost value
+		deepestMap[lastKey] = m[key]
+	}
+
+	return nested
+}
+
+// DeepSearch scans deep maps, following the key indexes listed in the
+// sequence "path".
+// The last value is expected to be another map, and is returned.
+//
+// In case intermediate keys do not exist, or map to a non-map value,
+// a new map is created and inserted, and the search continues from there:
+// the initial map "m" may be modified!
+func DeepSearch(m map[string]any, path []string) map[string]any {
+	for _, k := range path {
+		m2, ok := m[k]
+		if !ok {
+			// intermediate key does not exist
+			// => create it and continue from there
+			m3 := make(map[string]any)
+			m[k] = m3
+			m = m3
+			continue
+		}
+		m3, ok := m2.(map[string]any)
+		if !ok {
+			// intermediate key is a value
+			// => replace with a new map
+			m3 = make(map[string]any)
+			m[k] = m3
+		}
+		// continue search from here
+		m = m3
+	}
+	return m
+}
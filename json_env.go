@@ -0,0 +1,86 @@
+package viper
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// WithJSONEnv decodes environment variables whose value is a JSON object or array
+// (starting with "{" or "[") into nested maps and slices, so that complex structures
+// can be set with a single variable:
+//
+//	MYAPP_FEATURES='{"a": true, "b": false}'
+//
+// makes Get("features") return a map and Get("features.a") return true.
+// When keys are given, only the environment variables of these keys are decoded.
+// Values that are not valid JSON are kept as strings.
+func WithJSONEnv(keys ...string) Option {
+	return optionFunc(func(v *Viper) {
+		v.jsonEnv = true
+		v.jsonEnvKeys = nil
+
+		for _, key := range keys {
+			v.jsonEnvKeys = append(v.jsonEnvKeys, strings.ToLower(key))
+		}
+	})
+}
+
+// envValue returns the value of an environment variable read for key, decoded if it is JSON (see WithJSONEnv).
+func (v *Viper) envValue(key string, val string) any {
+	if !v.jsonEnv || (len(v.jsonEnvKeys) > 0 && !slices.Contains(v.jsonEnvKeys, key)) {
+		return val
+	}
+
+	trimmed := strings.TrimSpace(val)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return val
+	}
+
+	var decoded any
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		v.logger.Warn(fmt.Errorf("decode JSON env value: %w", err).Error(), "key", key)
+
+		return val
+	}
+
+	return insensitiviseVal(decoded)
+}
+
+// searchJSONEnv returns the value at path in the JSON value of the first set environment variable
+// of parentKey (see WithJSONEnv), or nil when it is not JSON.
+func (v *Viper) searchJSONEnv(parentKey string, path []string, names ...string) any {
+	if !v.jsonEnv {
+		return nil
+	}
+
+	for _, name := range names {
+		val, ok := v.getEnv(name)
+		if !ok {
+			continue
+		}
+
+		decoded := v.envValue(parentKey, val)
+		if _, ok := decoded.(string); ok {
+			return nil
+		}
+
+		return v.searchIndexableWithPathPrefixes(decoded, path[strings.Count(parentKey, v.keyDelim)+1:])
+	}
+
+	return nil
+}
+
+// searchBoundJSONEnv returns the value at path in the JSON value of an environment variable
+// bound to one of its parent keys.
+func (v *Viper) searchBoundJSONEnv(path []string) any {
+	for i := 1; i < len(path); i++ {
+		parentKey := strings.Join(path[0:i], v.keyDelim)
+		if val := v.searchJSONEnv(parentKey, path, v.env[parentKey]...); val != nil {
+			return val
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithJSONEnv(t *testing.T) {
+	t.Setenv("MYAPP_FEATURES", `{"A": true, "b": {"c": 1}}`)
+	t.Setenv("MYAPP_HOSTS", ` ["a", "b"]`)
+	t.Setenv("MYAPP_INVALID", "{not json")
+	t.Setenv("MYAPP_NAME", "app")
+
+	t.Run("AutomaticEnv", func(t *testing.T) {
+		v := NewWithOptions(WithJSONEnv())
+		v.SetEnvPrefix("myapp")
+		v.AutomaticEnv()
+		v.SetDefault("features.d", "default")
+
+		assert.Equal(t, map[string]any{"a": true, "b": map[string]any{"c": float64(1)}}, v.Get("features"))
+		assert.Equal(t, true, v.Get("features.a"))
+		assert.Equal(t, 1, v.GetInt("features.b.c"))
+		assert.Nil(t, v.Get("features.d"))
+		assert.Equal(t, []string{"a", "b"}, v.GetStringSlice("hosts"))
+		assert.Equal(t, "{not json", v.Get("invalid"))
+		assert.Equal(t, "app", v.Get("name"))
+	})
+
+	t.Run("BindEnv", func(t *testing.T) {
+		v := NewWithOptions(WithJSONEnv("features"))
+		require.NoError(t, v.BindEnv("features", "MYAPP_FEATURES"))
+		require.NoError(t, v.BindEnv("hosts", "MYAPP_HOSTS"))
+
+		var config struct {
+			Features struct {
+				A bool
+				B struct{ C int }
+			}
+		}
+		require.NoError(t, v.Unmarshal(&config))
+
+		assert.True(t, config.Features.A)
+		assert.Equal(t, 1, config.Features.B.C)
+		assert.Equal(t, true, v.Get("features.a"))
+		assert.Equal(t, ` ["a", "b"]`, v.Get("hosts"))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		v := New()
+		v.SetEnvPrefix("myapp")
+		v.AutomaticEnv()
+
+		assert.Equal(t, `{"A": true, "b": {"c": 1}}`, v.Get("features"))
+		assert.Nil(t, v.Get("features.a"))
+	})
+}
@@ -0,0 +1,75 @@
+package viper
+
+import "strings"
+
+// KeyInfo describes where the value of a key can come from (see GetKeyInfo).
+type KeyInfo struct {
+	// Key is the configuration key, after aliases are resolved.
+	Key string
+
+	// Usage is the usage message of the flag bound to the key, if any.
+	Usage string
+
+	// Default is the default value of the key (see SetDefault),
+	// or else the default value of the flag bound to the key, or nil.
+	Default any
+
+	// EnvVars lists the names of the environment variables the key is read from, in order of precedence
+	// (see EnvBindings).
+	EnvVars []string
+
+	// FlagName is the name of the flag bound to the key, if any.
+	FlagName string
+}
+
+// flagMetadata is implemented by the FlagValues of the flag packages supported by Viper.
+type flagMetadata interface {
+	Usage() string
+	DefaultValueString() string
+}
+
+// GetKeyInfo returns the metadata of a key gathered from its bindings, eg. to generate
+// the documentation of the configuration or a "--help-config" output from one place.
+func GetKeyInfo(key string) KeyInfo { return v.KeyInfo(key) }
+
+func (v *Viper) KeyInfo(key string) KeyInfo {
+	key = v.realKey(strings.ToLower(key))
+
+	info := KeyInfo{
+		Key:     key,
+		Default: v.searchMap(v.defaults, strings.Split(key, v.keyDelim)),
+	}
+	info.EnvVars, _ = v.envVarsOf(key)
+
+	flag, ok := v.boundFlag(key)
+	if !ok {
+		return info
+	}
+
+	if d, ok := flag.(deprecatedFlagValue); ok {
+		flag = d.FlagValue
+	}
+
+	info.FlagName = flag.Name()
+
+	if meta, ok := flag.(flagMetadata); ok {
+		info.Usage = meta.Usage()
+
+		if info.Default == nil {
+			info.Default = flagValueOf(flagDefaultValue{flag, meta.DefaultValueString()})
+		}
+	}
+
+	return info
+}
+
+// flagDefaultValue is a FlagValue holding the default value of a flag.
+type flagDefaultValue struct {
+	FlagValue
+	def string
+}
+
+// ValueString returns the default value of the flag.
+func (f flagDefaultValue) ValueString() string {
+	return f.def
+}
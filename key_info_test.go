@@ -0,0 +1,42 @@
+package viper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyInfo(t *testing.T) {
+	v := New()
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+	v.SetDefault("port", 80)
+	require.NoError(t, v.BindEnv("port", "PORT"))
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.Int("port", 8080, "port to listen on")
+	flagSet.Duration("timeout", 5*time.Second, "request timeout")
+	require.NoError(t, v.BindPFlags(flagSet))
+	v.RegisterAlias("deadline", "timeout")
+
+	assert.Equal(t, KeyInfo{
+		Key:      "port",
+		Usage:    "port to listen on",
+		Default:  80,
+		EnvVars:  []string{"APP_PORT", "PORT"},
+		FlagName: "port",
+	}, v.KeyInfo("Port"))
+
+	assert.Equal(t, KeyInfo{
+		Key:      "timeout",
+		Usage:    "request timeout",
+		Default:  5 * time.Second,
+		EnvVars:  []string{"APP_TIMEOUT"},
+		FlagName: "timeout",
+	}, v.KeyInfo("deadline"))
+
+	assert.Equal(t, KeyInfo{Key: "missing", EnvVars: []string{"APP_MISSING"}}, v.KeyInfo("missing"))
+}
@@ -0,0 +1,96 @@
+package viper
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// lazyFlagSet is a flag set bound with lazy flag binding (see WithLazyFlagBinding).
+type lazyFlagSet struct {
+	keyPrefix string
+	flags     *pflag.FlagSet
+
+	// tree lists the flag sets of a command tree bound with BindCobra instead,
+	// when the flags are looked up.
+	tree func() []lazyFlagSet
+}
+
+// WithLazyFlagBinding makes BindPFlags and BindPFlagsWithPrefix retain the flag sets they bind,
+// and look up their flags again whenever a value is read: flags defined after the flag set is bound
+// (eg. flags of cobra subcommands registered after an init function called BindPFlags) are bound too.
+//
+// Flags explicitly bound to a key (eg. with BindPFlag) take precedence over flags of retained sets.
+func WithLazyFlagBinding() Option {
+	return optionFunc(func(v *Viper) {
+		v.lazyFlagBinding = true
+	})
+}
+
+// retainFlagSet retains a bound flag set for lazy flag binding (see WithLazyFlagBinding).
+func (v *Viper) retainFlagSet(keyPrefix string, flags *pflag.FlagSet) {
+	if !v.lazyFlagBinding {
+		return
+	}
+
+	for _, set := range v.lazyFlagSets {
+		if set.keyPrefix == keyPrefix && set.flags == flags {
+			return
+		}
+	}
+
+	v.lazyFlagSets = append(v.lazyFlagSets, lazyFlagSet{keyPrefix: keyPrefix, flags: flags})
+}
+
+// visitLazyFlags calls fn with the key of every flag of the retained flag sets
+// that is neither explicitly bound nor unbound, in the order the sets were bound.
+func (v *Viper) visitLazyFlags(fn func(key string, flag FlagValue)) {
+	for _, set := range v.lazyFlagSets {
+		if set.tree == nil {
+			v.visitLazyFlagSet(set, fn)
+
+			continue
+		}
+
+		for _, set := range set.tree() {
+			v.visitLazyFlagSet(set, fn)
+		}
+	}
+}
+
+func (v *Viper) visitLazyFlagSet(set lazyFlagSet, fn func(key string, flag FlagValue)) {
+	set.flags.VisitAll(func(flag *pflag.Flag) {
+		key := v.flagKey(flag.Name)
+		if set.keyPrefix != "" {
+			key = set.keyPrefix + v.keyDelim + key
+		}
+
+		key = strings.ToLower(key)
+		if _, ok := v.pflags[key]; !ok && !v.unboundFlags[key] {
+			fn(key, pflagValue{flag})
+		}
+	})
+}
+
+// boundFlag returns the flag bound to a key, explicitly or through a retained flag set.
+// When several retained flags share the key, the first one set on the command line is returned,
+// or the first one in the order of precedence when none is set.
+func (v *Viper) boundFlag(key string) (FlagValue, bool) {
+	if flag, ok := v.pflags[key]; ok {
+		return flag, true
+	}
+
+	var found FlagValue
+
+	v.visitLazyFlags(func(k string, flag FlagValue) {
+		if k != key {
+			return
+		}
+
+		if found == nil || (!found.HasChanged() && flag.HasChanged()) {
+			found = flag
+		}
+	})
+
+	return found, found != nil
+}
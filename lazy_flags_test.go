@@ -0,0 +1,48 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLazyFlagBinding(t *testing.T) {
+	v := NewWithOptions(WithLazyFlagBinding())
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.Int("port", 8080, "test")
+	require.NoError(t, v.BindPFlags(flagSet))
+	require.NoError(t, v.BindPFlags(flagSet))
+	require.NoError(t, v.BindPFlagsWithPrefix("server", flagSet))
+	assert.Len(t, v.lazyFlagSets, 2)
+
+	// defined after the flag set is bound
+	flagSet.String("host", "localhost", "test")
+	flagSet.String("mode", "dev", "test")
+	require.NoError(t, flagSet.Parse([]string{"--host", "example.com", "--port", "9090"}))
+
+	override := pflag.NewFlagSet("override", pflag.ContinueOnError)
+	override.String("mode", "prod", "test")
+	require.NoError(t, v.BindPFlag("mode", override.Lookup("mode")))
+
+	assert.Equal(t, 9090, v.Get("port"))
+	assert.Equal(t, "example.com", v.Get("host"))
+	assert.Equal(t, "example.com", v.Get("server.host"))
+	assert.Equal(t, "prod", v.Get("mode"), "explicit bindings take precedence")
+	assert.True(t, v.IsSet("host"))
+	assert.ElementsMatch(t, []string{"port", "host", "mode", "server.port", "server.host", "server.mode"}, v.AllKeys())
+}
+
+func TestWithoutLazyFlagBinding(t *testing.T) {
+	v := New()
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	require.NoError(t, v.BindPFlags(flagSet))
+
+	flagSet.String("host", "localhost", "test")
+
+	assert.Nil(t, v.Get("host"))
+	assert.Empty(t, v.lazyFlagSets)
+}
@@ -0,0 +1,122 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// LiveStructOption configures a struct bound with BindStructLive.
+type LiveStructOption interface {
+	apply(l *liveStruct)
+}
+
+type liveStructOptionFunc func(l *liveStruct)
+
+func (fn liveStructOptionFunc) apply(l *liveStruct) {
+	fn(l)
+}
+
+// WithLiveLocker makes BindStructLive hold locker while it updates the bound struct.
+// Readers of the struct must hold it as well (eg. the read lock of a sync.RWMutex).
+func WithLiveLocker(locker sync.Locker) LiveStructOption {
+	return liveStructOptionFunc(func(l *liveStruct) {
+		l.locker = locker
+	})
+}
+
+// WithLiveSwap makes BindStructLive unmarshal into a new value every time the configuration changes
+// and hand it to swap (eg. to store it in an atomic.Pointer), instead of updating the bound struct.
+// The value passed to swap is a pointer of the same type as the bound struct.
+func WithLiveSwap(swap func(val any)) LiveStructOption {
+	return liveStructOptionFunc(func(l *liveStruct) {
+		l.swap = swap
+	})
+}
+
+// WithLiveDecoderOptions sets the decoder options used to unmarshal the bound struct.
+func WithLiveDecoderOptions(opts ...DecoderConfigOption) LiveStructOption {
+	return liveStructOptionFunc(func(l *liveStruct) {
+		l.decoderOpts = opts
+	})
+}
+
+// liveStruct is a struct bound with BindStructLive.
+type liveStruct struct {
+	rawVal      any
+	locker      sync.Locker
+	swap        func(val any)
+	decoderOpts []DecoderConfigOption
+}
+
+// BindStructLive unmarshals the config into a struct, and unmarshals it again
+// every time the effective configuration changes (eg. when a watched config file
+// or remote provider changes), so that services get hot-reloadable typed configuration.
+//
+// The struct is replaced as a whole, so that settings removed from the configuration
+// are reset to their zero value. Use WithLiveLocker or WithLiveSwap to synchronize
+// with the goroutines reading it. Changes that cannot be unmarshaled are logged
+// and leave the struct unchanged.
+func BindStructLive(rawVal any, opts ...LiveStructOption) error {
+	return v.BindStructLive(rawVal, opts...)
+}
+
+func (v *Viper) BindStructLive(rawVal any, opts ...LiveStructOption) error {
+	if typ := reflect.TypeOf(rawVal); typ == nil || typ.Kind() != reflect.Pointer || reflect.ValueOf(rawVal).IsNil() {
+		return errors.New("BindStructLive requires a non-nil pointer")
+	}
+
+	l := &liveStruct{rawVal: rawVal}
+
+	for _, opt := range opts {
+		opt.apply(l)
+	}
+
+	if err := l.refresh(v); err != nil {
+		return err
+	}
+
+	v.liveStructsMu.Lock()
+	defer v.liveStructsMu.Unlock()
+
+	v.liveStructs = append(v.liveStructs, l)
+
+	return nil
+}
+
+// refresh unmarshals the config into a new value and publishes it.
+func (l *liveStruct) refresh(v *Viper) error {
+	val := reflect.New(reflect.TypeOf(l.rawVal).Elem())
+
+	if err := v.Unmarshal(val.Interface(), l.decoderOpts...); err != nil {
+		return err
+	}
+
+	if l.swap != nil {
+		l.swap(val.Interface())
+
+		return nil
+	}
+
+	if l.locker != nil {
+		l.locker.Lock()
+		defer l.locker.Unlock()
+	}
+
+	reflect.ValueOf(l.rawVal).Elem().Set(val.Elem())
+
+	return nil
+}
+
+// refreshLiveStructs unmarshals the config again into every struct bound with BindStructLive.
+func (v *Viper) refreshLiveStructs() {
+	v.liveStructsMu.Lock()
+	defer v.liveStructsMu.Unlock()
+
+	for _, l := range v.liveStructs {
+		if err := l.refresh(v); err != nil {
+			v.logger.Error(fmt.Errorf("unmarshal live struct: %w", err).Error(), "type", reflect.TypeOf(l.rawVal).String())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package viper
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type liveTestConfig struct {
+	Host string
+	Port int
+}
+
+func TestBindStructLive(t *testing.T) {
+	t.Run("Locker", func(t *testing.T) {
+		v := New()
+		v.SetConfigType("yaml")
+		require.NoError(t, v.ReadConfig(bytes.NewBufferString("host: localhost\nport: 8080\n")))
+
+		var (
+			mu  sync.RWMutex
+			cfg liveTestConfig
+		)
+
+		require.NoError(t, v.BindStructLive(&cfg, WithLiveLocker(&mu)))
+		assert.Equal(t, liveTestConfig{Host: "localhost", Port: 8080}, cfg)
+
+		require.NoError(t, v.MergeConfigMap(map[string]any{"port": 9090}))
+		mu.RLock()
+		assert.Equal(t, liveTestConfig{Host: "localhost", Port: 9090}, cfg)
+		mu.RUnlock()
+
+		// Removed settings are reset
+		require.NoError(t, v.ReadConfig(bytes.NewBufferString("host: example.com\n")))
+		mu.RLock()
+		assert.Equal(t, liveTestConfig{Host: "example.com"}, cfg)
+		mu.RUnlock()
+
+		// Changes that cannot be unmarshaled leave the struct unchanged
+		require.NoError(t, v.MergeConfigMap(map[string]any{"port": "not a number"}))
+		mu.RLock()
+		assert.Equal(t, liveTestConfig{Host: "example.com"}, cfg)
+		mu.RUnlock()
+	})
+
+	t.Run("Swap", func(t *testing.T) {
+		v := New()
+		v.SetDefault("host", "localhost")
+
+		var (
+			cfg     liveTestConfig
+			current atomic.Pointer[liveTestConfig]
+		)
+
+		require.NoError(t, v.BindStructLive(&cfg, WithLiveSwap(func(val any) {
+			current.Store(val.(*liveTestConfig))
+		})))
+		assert.Equal(t, &liveTestConfig{Host: "localhost"}, current.Load())
+
+		require.NoError(t, v.MergeConfigMap(map[string]any{"port": 9090}))
+		assert.Equal(t, &liveTestConfig{Host: "localhost", Port: 9090}, current.Load())
+		assert.Equal(t, liveTestConfig{}, cfg, "the bound struct is not updated when swapping")
+	})
+
+	t.Run("NotAPointer", func(t *testing.T) {
+		v := New()
+
+		require.Error(t, v.BindStructLive(liveTestConfig{}))
+		require.Error(t, v.BindStructLive((*liveTestConfig)(nil)))
+	})
+}
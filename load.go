@@ -0,0 +1,33 @@
+package viper
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Load replaces the configuration read from config files with the fields of a struct,
+// eg. to define the configuration in Go and write it out with WriteConfigAs for users to edit.
+//
+// Structs are converted to nested maps like values passed to Set:
+// fields are named after their mapstructure tags (honoring "-", ",squash" and ",omitempty"),
+// and structs nested in fields, slices and maps are converted as well.
+// Secret fields are stored as plain strings and their keys are marked as secret.
+func Load(s any) error { return v.Load(s) }
+
+func (v *Viper) Load(s any) error {
+	rv := reflect.ValueOf(s)
+	if !rv.IsValid() || !isConvertibleStruct(rv.Type()) || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		return errors.New("Load requires a struct or a pointer to a struct")
+	}
+
+	value, secrets := toMapValueSecrets(s)
+	cfg, _ := value.(map[string]any)
+
+	v.normalizeConfigKeys(cfg)
+	v.config.store(cfg)
+	v.setSecretKeys("", secrets)
+
+	v.updateVersion()
+
+	return nil
+}
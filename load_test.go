@@ -0,0 +1,77 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loadServer struct {
+	Name string `mapstructure:"name"`
+	Port int    `mapstructure:"port,omitempty"`
+}
+
+type LoadCommon struct {
+	Debug bool
+}
+
+type loadConfig struct {
+	LoadCommon `mapstructure:",squash"`
+	Title      string
+	Timeout    time.Duration
+	Secret     string `mapstructure:"-"`
+	Database   *struct {
+		Host string
+	}
+	Servers []loadServer
+}
+
+func TestLoad(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("stale: true\n")))
+
+	config := loadConfig{
+		LoadCommon: LoadCommon{Debug: true},
+		Title:      "app",
+		Timeout:    5 * time.Second,
+		Secret:     "hidden",
+		Database: &struct {
+			Host string
+		}{Host: "localhost"},
+		Servers: []loadServer{{Name: "a", Port: 80}, {Name: "b"}},
+	}
+	require.NoError(t, v.Load(&config))
+
+	assert.False(t, v.IsSet("stale"))
+	assert.True(t, v.GetBool("debug"))
+	assert.Equal(t, "app", v.Get("title"))
+	assert.Equal(t, "localhost", v.Get("database.host"))
+	assert.False(t, v.IsSet("secret"))
+	assert.Equal(t, []any{
+		map[string]any{"name": "a", "port": 80},
+		map[string]any{"name": "b"},
+	}, v.Get("servers"))
+
+	var decoded loadConfig
+	require.NoError(t, v.Unmarshal(&decoded))
+	config.Secret = ""
+	assert.Equal(t, config, decoded)
+
+	fs := afero.NewMemMapFs()
+	v.SetFs(fs)
+	require.NoError(t, v.WriteConfigAs("/config.yaml"))
+
+	written := New()
+	written.SetFs(fs)
+	written.SetConfigFile("/config.yaml")
+	require.NoError(t, written.ReadInConfig())
+	assert.Equal(t, "localhost", written.Get("database.host"))
+
+	require.Error(t, v.Load("not a struct"))
+	require.Error(t, v.Load((*loadConfig)(nil)))
+}
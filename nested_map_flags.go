@@ -0,0 +1,95 @@
+package viper
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// WithNestedMapFlags makes the entries of map flags (stringToString, stringToInt and stringToInt64)
+// nested keys of the key the flag is bound to: with `--labels team=core`,
+// "labels.team" is set and other entries of "labels" set by other sources (eg. config files)
+// are kept in AllSettings and Unmarshal.
+func WithNestedMapFlags() Option {
+	return optionFunc(func(v *Viper) {
+		v.nestedMapFlags = true
+	})
+}
+
+// isMapFlag reports whether the entries of a flag are nested keys (see WithNestedMapFlags).
+func (v *Viper) isMapFlag(flag FlagValue) bool {
+	if !v.nestedMapFlags {
+		return false
+	}
+
+	switch flag.ValueType() {
+	case "stringToString", "stringToInt", "stringToInt64":
+		return true
+	}
+
+	return false
+}
+
+// mapFlagValue returns the entries of a map flag, with lower-cased keys.
+func mapFlagValue(flag FlagValue) map[string]any {
+	m := make(map[string]any)
+	for k, val := range cast.ToStringMap(flagValueOf(flag)) {
+		m[strings.ToLower(k)] = val
+	}
+
+	return m
+}
+
+// searchMapFlags looks up a nested key in the entries of the map flag bound to one of its parents,
+// considering only changed flags unless flagDefault is true.
+func (v *Viper) searchMapFlags(path []string, flagDefault bool) any {
+	for i := 1; i < len(path); i++ {
+		flag, ok := v.boundFlag(strings.Join(path[:i], v.keyDelim))
+		if !ok || !v.isMapFlag(flag) || !(flagDefault || flag.HasChanged()) {
+			continue
+		}
+
+		if val, ok := mapFlagValue(flag)[strings.Join(path[i:], v.keyDelim)]; ok {
+			return val
+		}
+	}
+
+	return nil
+}
+
+// flagKeys returns the bound flags by key, the entries of map flags being nested keys (see WithNestedMapFlags).
+func (v *Viper) flagKeys() map[string]any {
+	keys := make(map[string]any)
+
+	for key, flag := range v.boundFlags() {
+		if !v.isMapFlag(flag) {
+			keys[key] = flag
+
+			continue
+		}
+
+		for k := range mapFlagValue(flag) {
+			keys[key+v.keyDelim+k] = flag
+		}
+	}
+
+	return keys
+}
+
+// shadowingFlags returns the bound flags shadowing the nested keys of their key,
+// which excludes map flags with nested entries (see WithNestedMapFlags).
+func (v *Viper) shadowingFlags() map[string]FlagValue {
+	flags := v.boundFlags()
+	if !v.nestedMapFlags {
+		return flags
+	}
+
+	shadowing := make(map[string]FlagValue, len(flags))
+	for key, flag := range flags {
+		if !v.isMapFlag(flag) {
+			shadowing[key] = flag
+		}
+	}
+
+	return shadowing
+}
@@ -0,0 +1,59 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithNestedMapFlags(t *testing.T) {
+	v := NewWithOptions(WithNestedMapFlags())
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+labels:
+  team: platform
+  tier: backend
+limits:
+  cpu: 1
+`)))
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.StringToString("labels", nil, "test")
+	flagSet.StringToInt("limits", map[string]int{"memory": 512}, "test")
+	require.NoError(t, v.BindPFlags(flagSet))
+	require.NoError(t, flagSet.Parse([]string{"--labels", "team=core,Env=prod"}))
+
+	assert.Equal(t, "core", v.Get("labels.team"))
+	assert.Equal(t, "prod", v.Get("labels.env"))
+	assert.Equal(t, "backend", v.Get("labels.tier"))
+	assert.Equal(t, 1, v.Get("limits.cpu"))
+	assert.Equal(t, 512, v.Get("limits.memory"), "entries of unchanged flags are defaults")
+
+	assert.Equal(t, map[string]any{
+		"labels": map[string]any{"team": "core", "env": "prod", "tier": "backend"},
+		"limits": map[string]any{"cpu": 1, "memory": 512},
+	}, v.AllSettings())
+
+	var config struct {
+		Labels map[string]string
+	}
+	require.NoError(t, v.Unmarshal(&config))
+	assert.Equal(t, map[string]string{"team": "core", "env": "prod", "tier": "backend"}, config.Labels)
+}
+
+func TestWithoutNestedMapFlags(t *testing.T) {
+	v := New()
+	v.SetDefault("labels.tier", "backend")
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.StringToString("labels", nil, "test")
+	require.NoError(t, v.BindPFlags(flagSet))
+	require.NoError(t, flagSet.Parse([]string{"--labels", "team=core"}))
+
+	assert.Nil(t, v.Get("labels.team"))
+	assert.Nil(t, v.Get("labels.tier"))
+	assert.Equal(t, map[string]any{"team": "core"}, v.Get("labels"))
+}
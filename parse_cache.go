@@ -0,0 +1,205 @@
+package viper
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// parseCache holds config files parsed by instances created with WithParseCache.
+// It is shared by every instance of the process.
+var parseCache = struct {
+	mu      sync.Mutex
+	entries map[parseCacheKey]parseCacheEntry
+}{
+	entries: make(map[parseCacheKey]parseCacheEntry),
+}
+
+// parseCacheKey identifies a config file.
+type parseCacheKey struct {
+	fs     afero.Fs
+	path   string
+	format string
+}
+
+// parseCacheEntry holds the last parsed version of a config file.
+// A file is parsed again, and its entry replaced, as soon as its modification time or size changes.
+type parseCacheEntry struct {
+	modTime time.Time
+	size    int64
+	config  map[string]any
+}
+
+// WithParseCache enables the process-level cache of parsed config files.
+//
+// Config files read by ReadInConfig, MergeInConfig and WatchConfig are parsed once
+// and shared by every instance created with this option, as long as the modification time
+// and size of the file do not change. This cuts the cost of constructing many instances
+// for the same file (eg. one per command in CLI tools).
+//
+// Instances sharing the cache must use the same decoders for a given config type.
+// Use InvalidateParseCache or ResetParseCache when files are changed in a way
+// that preserves both their modification time and size.
+func WithParseCache() Option {
+	return optionFunc(func(v *Viper) {
+		v.parseCache = true
+	})
+}
+
+// InvalidateParseCache removes the config file at path from the parse cache.
+func InvalidateParseCache(path string) {
+	path = filepath.Clean(path)
+
+	parseCache.mu.Lock()
+	defer parseCache.mu.Unlock()
+
+	for key := range parseCache.entries {
+		if key.path == path {
+			delete(parseCache.entries, key)
+		}
+	}
+}
+
+// ResetParseCache removes every config file from the parse cache.
+func ResetParseCache() {
+	parseCache.mu.Lock()
+	defer parseCache.mu.Unlock()
+
+	parseCache.entries = make(map[parseCacheKey]parseCacheEntry)
+}
+
+// readConfigFile reads and parses a config file in the given format,
+// using the parse cache when enabled.
+func (v *Viper) readConfigFile(filename, format string) (map[string]any, error) {
+	var (
+		key    parseCacheKey
+		entry  parseCacheEntry
+		cached bool
+	)
+
+	// Filesystems that cannot be used as a map key are never cached
+	if v.parseCache && reflect.TypeOf(v.fs).Comparable() {
+		if stat, err := v.fs.Stat(filename); err == nil {
+			key = parseCacheKey{fs: v.fs, path: filepath.Clean(filename), format: format}
+			entry = parseCacheEntry{modTime: stat.ModTime(), size: stat.Size()}
+			cached = true
+
+			parseCache.mu.Lock()
+			cachedEntry, ok := parseCache.entries[key]
+			parseCache.mu.Unlock()
+
+			if ok && cachedEntry.modTime.Equal(entry.modTime) && cachedEntry.size == entry.size {
+				v.logger.Debug("using cached config file", "file", filename)
+
+				config := deepCopyMap(cachedEntry.config)
+				v.normalizeConfigKeys(config)
+				v.expandConfig(config)
+
+				return config, nil
+			}
+		}
+	}
+
+	file, err := afero.ReadFile(v.fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]any)
+	if err := v.decodeConfig(file, format, config); err != nil {
+		return nil, err
+	}
+
+	// Keys are cached as decoded, so that instances can apply their own case policy
+	if cached {
+		entry.config = deepCopyMap(config)
+
+		parseCache.mu.Lock()
+		parseCache.entries[key] = entry
+		parseCache.mu.Unlock()
+	}
+
+	v.normalizeConfigKeys(config)
+	v.expandConfig(config)
+
+	return config, nil
+}
+
+// deepCopyMap copies m and every map and slice nested in it,
+// so that cached configurations are never modified by their users.
+func deepCopyMap(m map[string]any) map[string]any {
+	nm := make(map[string]any, len(m))
+
+	for key, val := range m {
+		nm[key] = deepCopyValue(val)
+	}
+
+	return nm
+}
+
+func deepCopyValue(val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		return deepCopyMap(v)
+	case map[any]any:
+		nm := make(map[any]any, len(v))
+		for key, val := range v {
+			nm[key] = deepCopyValue(val)
+		}
+
+		return nm
+	case []any:
+		ns := make([]any, len(v))
+		for i, val := range v {
+			ns[i] = deepCopyValue(val)
+		}
+
+		return ns
+	default:
+		return deepCopyReflect(val)
+	}
+}
+
+// deepCopyReflect copies typed maps and slices (eg. []string) that deepCopyValue does not know about.
+func deepCopyReflect(val any) any {
+	rv := reflect.ValueOf(val)
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return val
+		}
+
+		nm := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			nm.SetMapIndex(iter.Key(), copyReflectValue(iter.Value(), rv.Type().Elem()))
+		}
+
+		return nm.Interface()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return val
+		}
+
+		ns := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			ns.Index(i).Set(copyReflectValue(rv.Index(i), rv.Type().Elem()))
+		}
+
+		return ns.Interface()
+	default:
+		return val
+	}
+}
+
+func copyReflectValue(val reflect.Value, typ reflect.Type) reflect.Value {
+	if !val.IsValid() || (val.Kind() == reflect.Interface && val.IsNil()) {
+		return reflect.Zero(typ)
+	}
+
+	return reflect.ValueOf(deepCopyValue(val.Interface())).Convert(typ)
+}
@@ -0,0 +1,80 @@
+package viper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithParseCache(t *testing.T) {
+	t.Cleanup(ResetParseCache)
+
+	fs := afero.NewMemMapFs()
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	writeConfig := func(content string) {
+		t.Helper()
+
+		require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(content), 0o644))
+		require.NoError(t, fs.Chtimes("/etc/app/config.yaml", modTime, modTime))
+	}
+
+	newViper := func(opts ...Option) *Viper {
+		v := NewWithOptions(opts...)
+		v.SetFs(fs)
+		v.SetConfigFile("/etc/app/config.yaml")
+		require.NoError(t, v.ReadInConfig())
+
+		return v
+	}
+
+	writeConfig("db:\n  host: one\n")
+
+	v1 := newViper(WithParseCache())
+	assert.Equal(t, "one", v1.GetString("db.host"))
+
+	// Instances do not share the parsed configuration itself
+	require.NoError(t, v1.MergeConfigMap(map[string]any{"db": map[string]any{"host": "merged"}}))
+
+	// Same modification time and size: served from the cache
+	writeConfig("db:\n  host: two\n")
+
+	assert.Equal(t, "one", newViper(WithParseCache()).GetString("db.host"))
+	assert.Equal(t, "two", newViper().GetString("db.host"), "instances without the option bypass the cache")
+
+	InvalidateParseCache("/etc/app/config.yaml")
+	assert.Equal(t, "two", newViper(WithParseCache()).GetString("db.host"))
+
+	// A different size invalidates the cached version
+	writeConfig("db:\n  host: three\n")
+	assert.Equal(t, "three", newViper(WithParseCache()).GetString("db.host"))
+}
+
+func TestWithParseCache_Rewrites(t *testing.T) {
+	t.Cleanup(ResetParseCache)
+	ResetParseCache()
+
+	fs := afero.NewMemMapFs()
+
+	v := NewWithOptions(WithParseCache())
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+
+	// Every rewrite replaces the cached version instead of adding one
+	for i := 1; i <= 5; i++ {
+		modTime := time.Date(2024, 1, i, 0, 0, 0, 0, time.UTC)
+
+		require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(fmt.Sprintf("version: %d\n", i)), 0o644))
+		require.NoError(t, fs.Chtimes("/etc/app/config.yaml", modTime, modTime))
+		require.NoError(t, v.ReadInConfig())
+		assert.Equal(t, i, v.GetInt("version"))
+
+		parseCache.mu.Lock()
+		assert.Len(t, parseCache.entries, 1)
+		parseCache.mu.Unlock()
+	}
+}
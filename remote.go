@@ -2,17 +2,25 @@ package viper
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper/internal/maputil"
 )
 
 // SupportedRemoteProviders are universally supported remote providers.
-var SupportedRemoteProviders = []string{"etcd", "etcd3", "consul", "firestore", "nats"}
+var SupportedRemoteProviders = []string{"etcd", "etcd3", "consul", "firestore", "nats", "dynamodb"}
 
 func resetRemote() {
-	SupportedRemoteProviders = []string{"etcd", "etcd3", "consul", "firestore", "nats"}
+	SupportedRemoteProviders = []string{"etcd", "etcd3", "consul", "firestore", "nats", "dynamodb"}
 }
 
 type remoteConfigFactory interface {
@@ -21,11 +29,34 @@ type remoteConfigFactory interface {
 	WatchChannel(rp RemoteProvider) (<-chan *RemoteResponse, chan bool)
 }
 
+// remoteConfigLister is implemented by remote config factories
+// that can list every key stored under a prefix.
+type remoteConfigLister interface {
+	List(rp RemoteProvider) (map[string][]byte, error)
+}
+
 type RemoteResponse struct {
 	Value []byte
 	Error error
 }
 
+// RemoteEvent describes an update received while watching a remote provider.
+type RemoteEvent struct {
+	// Provider is the remote provider the update was received from.
+	Provider RemoteProvider
+
+	// Value is the raw configuration payload received from the provider.
+	Value []byte
+
+	// Error is set when the provider reported an error
+	// or the payload could not be decoded.
+	Error error
+
+	// Version and Hash identify the effective configuration after the update (see Viper.Version).
+	Version uint64
+	Hash    string
+}
+
 // RemoteConfig is optional, see the remote package.
 var RemoteConfig remoteConfigFactory
 
@@ -52,6 +83,10 @@ type defaultRemoteProvider struct {
 	endpoint      string
 	path          string
 	secretKeyring string
+	prefix        bool
+	decrypter     RemoteDecrypter
+	mount         string
+	layered       bool
 }
 
 func (rp defaultRemoteProvider) Provider() string {
@@ -70,6 +105,29 @@ func (rp defaultRemoteProvider) SecretKeyring() string {
 	return rp.secretKeyring
 }
 
+// describe identifies a remote mount or layer in error messages.
+func (rp defaultRemoteProvider) describe() string {
+	if rp.layered {
+		return fmt.Sprintf("remote layer %q", rp.path)
+	}
+
+	return fmt.Sprintf("remote mount %q", rp.mount)
+}
+
+// RemoteDecrypter decrypts configuration payloads read from a remote provider
+// (eg. using a KMS, age or a custom scheme).
+type RemoteDecrypter interface {
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// RemoteDecrypterFunc is an adapter to allow the use of ordinary functions as RemoteDecrypter.
+type RemoteDecrypterFunc func(ciphertext []byte) ([]byte, error)
+
+// Decrypt calls fn(ciphertext).
+func (fn RemoteDecrypterFunc) Decrypt(ciphertext []byte) ([]byte, error) {
+	return fn(ciphertext)
+}
+
 // RemoteProvider stores the configuration necessary
 // to connect to a remote key/value store.
 // Optional secretKeyring to unencrypt encrypted values
@@ -83,8 +141,8 @@ type RemoteProvider interface {
 
 // AddRemoteProvider adds a remote configuration source.
 // Remote Providers are searched in the order they are added.
-// provider is a string value: "etcd", "etcd3", "consul", "firestore" or "nats" are currently supported.
-// endpoint is the url.  etcd requires http://ip:port, consul requires ip:port, nats requires nats://ip:port
+// provider is a string value: "etcd", "etcd3", "consul", "firestore", "nats" or "dynamodb" are currently supported.
+// endpoint is the url.  etcd requires http://ip:port, consul requires ip:port, nats requires nats://ip:port, dynamodb requires the table name
 // path is the path in the k/v store to retrieve configuration
 // To retrieve a config file called myapp.json from /configs/myapp.json
 // you should set path to /configs and set config name (SetConfigName()) to
@@ -114,7 +172,7 @@ func (v *Viper) AddRemoteProvider(provider, endpoint, path string) error {
 
 // AddSecureRemoteProvider adds a remote configuration source.
 // Secure Remote Providers are searched in the order they are added.
-// provider is a string value: "etcd", "etcd3", "consul", "firestore" or "nats" are currently supported.
+// provider is a string value: "etcd", "etcd3", "consul", "firestore", "nats" or "dynamodb" are currently supported.
 // endpoint is the url.  etcd requires http://ip:port  consul requires ip:port
 // secretkeyring is the filepath to your openpgp secret keyring.  e.g. /etc/secrets/myring.gpg
 // path is the path in the k/v store to retrieve configuration
@@ -146,15 +204,242 @@ func (v *Viper) AddSecureRemoteProvider(provider, endpoint, path, secretkeyring
 	return nil
 }
 
+// AddDecryptedRemoteProvider adds a remote configuration source
+// whose payloads are decrypted with decrypter before being parsed.
+// Unlike AddSecureRemoteProvider, it does not require an OpenPGP secret keyring.
+// See AddRemoteProvider for the supported providers and endpoint formats.
+func AddDecryptedRemoteProvider(provider, endpoint, path string, decrypter RemoteDecrypter) error {
+	return v.AddDecryptedRemoteProvider(provider, endpoint, path, decrypter)
+}
+
+func (v *Viper) AddDecryptedRemoteProvider(provider, endpoint, path string, decrypter RemoteDecrypter) error {
+	if !slices.Contains(SupportedRemoteProviders, provider) {
+		return UnsupportedRemoteProviderError(provider)
+	}
+	if provider != "" && endpoint != "" {
+		v.logger.Info("adding remote provider", "provider", provider, "endpoint", endpoint)
+
+		rp := &defaultRemoteProvider{
+			endpoint:  endpoint,
+			provider:  provider,
+			path:      path,
+			decrypter: decrypter,
+		}
+		if !v.providerPathExists(rp) {
+			v.remoteProviders = append(v.remoteProviders, rp)
+		}
+	}
+	return nil
+}
+
+// AddRemoteProviderPrefix adds a remote configuration source that stores one
+// key per setting under a common prefix (e.g. "myapp/db/host", "myapp/db/port")
+// instead of a single serialized configuration blob.
+// Every key under prefix is read and assembled into a nested map by splitting
+// the remainder of the key on "/": "myapp/db/host" becomes "db.host".
+// Values are stored as strings.
+// See AddRemoteProvider for the supported providers and endpoint formats.
+func AddRemoteProviderPrefix(provider, endpoint, prefix string) error {
+	return v.AddRemoteProviderPrefix(provider, endpoint, prefix)
+}
+
+func (v *Viper) AddRemoteProviderPrefix(provider, endpoint, prefix string) error {
+	if !slices.Contains(SupportedRemoteProviders, provider) {
+		return UnsupportedRemoteProviderError(provider)
+	}
+	if provider != "" && endpoint != "" {
+		v.logger.Info("adding remote provider", "provider", provider, "endpoint", endpoint, "prefix", prefix)
+
+		rp := &defaultRemoteProvider{
+			endpoint: endpoint,
+			provider: provider,
+			path:     prefix,
+			prefix:   true,
+		}
+		if !v.providerPathExists(rp) {
+			v.remoteProviders = append(v.remoteProviders, rp)
+		}
+	}
+	return nil
+}
+
+// AddRemoteMount adds a remote configuration source that is read into the key
+// of the configuration instead of its root, so that the layout of the remote
+// key/value store does not have to match the shape of the configuration:
+//
+//	viper.AddRemoteMount("etcd3", "http://127.0.0.1:2379", "/app/db", "database")
+//
+// reads the configuration stored at /app/db into the "database" key.
+//
+// Unlike remote providers, of which only the first one found is read,
+// every mount is read by ReadRemoteConfig and watched independently
+// by WatchRemoteConfig and WatchRemoteConfigOnChannel.
+// Each update replaces the whole subtree of the key.
+// See AddRemoteProvider for the supported providers and endpoint formats.
+func AddRemoteMount(provider, endpoint, path, key string) error {
+	return v.AddRemoteMount(provider, endpoint, path, key)
+}
+
+func (v *Viper) AddRemoteMount(provider, endpoint, path, key string) error {
+	if !slices.Contains(SupportedRemoteProviders, provider) {
+		return UnsupportedRemoteProviderError(provider)
+	}
+	if key == "" {
+		return RemoteConfigError("remote mount key is empty")
+	}
+	if provider != "" && endpoint != "" {
+		v.logger.Info("adding remote mount", "provider", provider, "endpoint", endpoint, "path", path, "key", key)
+
+		rp := &defaultRemoteProvider{
+			endpoint: endpoint,
+			provider: provider,
+			path:     path,
+			mount:    strings.ToLower(key),
+		}
+		if !v.providerPathExists(rp) {
+			v.remoteMounts = append(v.remoteMounts, rp)
+		}
+	}
+	return nil
+}
+
+// AddRemoteLayeredProvider adds remote configuration sources read from several paths
+// of the same provider and merged in order, so that later paths take precedence:
+//
+//	viper.AddRemoteLayeredProvider("consul", "localhost:8500", []string{"config/global", "config/myapp"})
+//
+// merges the application specific configuration stored at config/myapp
+// into the configuration shared by every application stored at config/global.
+//
+// Like remote mounts, every layer is read by ReadRemoteConfig and watched independently
+// by WatchRemoteConfig and WatchRemoteConfigOnChannel; an update of any layer merges all of them again.
+// A layer that cannot be read keeps its last known configuration and is reported in the returned error.
+// Layers take precedence over the configuration read from remote providers.
+// See AddRemoteProvider for the supported providers and endpoint formats.
+func AddRemoteLayeredProvider(provider, endpoint string, paths []string) error {
+	return v.AddRemoteLayeredProvider(provider, endpoint, paths)
+}
+
+func (v *Viper) AddRemoteLayeredProvider(provider, endpoint string, paths []string) error {
+	if !slices.Contains(SupportedRemoteProviders, provider) {
+		return UnsupportedRemoteProviderError(provider)
+	}
+	if provider != "" && endpoint != "" {
+		v.logger.Info("adding remote layered provider", "provider", provider, "endpoint", endpoint, "paths", paths)
+
+		for _, path := range paths {
+			rp := &defaultRemoteProvider{
+				endpoint: endpoint,
+				provider: provider,
+				path:     path,
+				layered:  true,
+			}
+			if !v.providerPathExists(rp) {
+				v.remoteLayers = append(v.remoteLayers, rp)
+			}
+		}
+	}
+	return nil
+}
+
+// Environment variables read by AutoRemoteFromEnv.
+const (
+	remoteProviderEnv      = "VIPER_REMOTE_PROVIDER"
+	remoteEndpointEnv      = "VIPER_REMOTE_ENDPOINT"
+	remotePathEnv          = "VIPER_REMOTE_PATH"
+	remoteSecretKeyringEnv = "VIPER_REMOTE_SECRET_KEYRING"
+	remotePrefixEnv        = "VIPER_REMOTE_PREFIX"
+	remoteConfigTypeEnv    = "VIPER_REMOTE_CONFIG_TYPE"
+)
+
+// AutoRemoteFromEnv adds a remote provider configured from environment variables,
+// so applications can switch between file-only and remote-backed configuration
+// without code changes:
+//
+//	VIPER_REMOTE_PROVIDER       provider name (eg. "etcd3"); nothing is added when empty
+//	VIPER_REMOTE_ENDPOINT       provider endpoint (see AddRemoteProvider)
+//	VIPER_REMOTE_PATH           path of the configuration in the k/v store
+//	VIPER_REMOTE_SECRET_KEYRING optional secret keyring (see AddSecureRemoteProvider)
+//	VIPER_REMOTE_PREFIX         when true, path is read as a prefix (see AddRemoteProviderPrefix)
+//	VIPER_REMOTE_CONFIG_TYPE    optional config type of the remote payload (see SetConfigType)
+//
+// The variables are looked up like the other environment variables (see WithEnvLookup).
+func AutoRemoteFromEnv() error { return v.AutoRemoteFromEnv() }
+
+func (v *Viper) AutoRemoteFromEnv() error {
+	provider := v.remoteEnv(remoteProviderEnv)
+	if provider == "" {
+		return nil
+	}
+
+	endpoint := v.remoteEnv(remoteEndpointEnv)
+	if endpoint == "" {
+		return RemoteConfigError(fmt.Sprintf("%s is set, but %s is empty", remoteProviderEnv, remoteEndpointEnv))
+	}
+
+	path := v.remoteEnv(remotePathEnv)
+
+	prefix := false
+	if p := v.remoteEnv(remotePrefixEnv); p != "" {
+		var err error
+
+		prefix, err = strconv.ParseBool(p)
+		if err != nil {
+			return RemoteConfigError(fmt.Sprintf("invalid value for %s: %s", remotePrefixEnv, err))
+		}
+	}
+
+	if configType := v.remoteEnv(remoteConfigTypeEnv); configType != "" {
+		v.SetConfigType(configType)
+	}
+
+	switch {
+	case prefix:
+		return v.AddRemoteProviderPrefix(provider, endpoint, path)
+	case v.remoteEnv(remoteSecretKeyringEnv) != "":
+		return v.AddSecureRemoteProvider(provider, endpoint, path, v.remoteEnv(remoteSecretKeyringEnv))
+	default:
+		return v.AddRemoteProvider(provider, endpoint, path)
+	}
+}
+
+// remoteEnv returns the value of an environment variable read by AutoRemoteFromEnv,
+// looked up like the other variables (see WithEnvLookup).
+func (v *Viper) remoteEnv(key string) string {
+	val, _ := v.lookupEnv(key)
+
+	return val
+}
+
 func (v *Viper) providerPathExists(p *defaultRemoteProvider) bool {
 	for _, y := range v.remoteProviders {
 		if reflect.DeepEqual(y, p) {
 			return true
 		}
 	}
+	for _, y := range v.remoteMounts {
+		if reflect.DeepEqual(y, p) {
+			return true
+		}
+	}
+	for _, y := range v.remoteLayers {
+		if reflect.DeepEqual(y, p) {
+			return true
+		}
+	}
 	return false
 }
 
+// independentRemoteProviders returns the remote mounts and layers,
+// which are all read and watched independently.
+func (v *Viper) independentRemoteProviders() []*defaultRemoteProvider {
+	providers := make([]*defaultRemoteProvider, 0, len(v.remoteMounts)+len(v.remoteLayers))
+	providers = append(providers, v.remoteMounts...)
+	providers = append(providers, v.remoteLayers...)
+
+	return providers
+}
+
 // ReadRemoteConfig attempts to get configuration from a remote source
 // and read it in the remote configuration registry.
 func ReadRemoteConfig() error { return v.ReadRemoteConfig() }
@@ -168,8 +453,34 @@ func (v *Viper) WatchRemoteConfig() error {
 	return v.watchKeyValueConfig()
 }
 
+// WatchRemoteConfigOnChannel starts watching the first remote provider for changes
+// in the background. The watcher runs until the process exits;
+// use WatchRemoteConfigOnChannelContext to be able to stop it.
 func (v *Viper) WatchRemoteConfigOnChannel() error {
-	return v.watchKeyValueConfigOnChannel()
+	return v.watchKeyValueConfigOnChannel(context.Background())
+}
+
+// WatchRemoteConfigOnChannelContext starts watching the first remote provider for changes
+// in the background until ctx is canceled.
+func (v *Viper) WatchRemoteConfigOnChannelContext(ctx context.Context) error {
+	return v.watchKeyValueConfigOnChannel(ctx)
+}
+
+// WithRemoteWatchDebounce coalesces bursts of updates received while watching
+// a remote provider on a channel: an update is only applied once no other update
+// arrived for the given duration. Errors are always reported immediately.
+func WithRemoteWatchDebounce(d time.Duration) Option {
+	return optionFunc(func(v *Viper) {
+		v.remoteWatchDebounce = d
+	})
+}
+
+// OnRemoteConfigChange sets the event handler that is called when
+// a remote provider watched on a channel reports an update.
+func OnRemoteConfigChange(run func(in RemoteEvent)) { v.OnRemoteConfigChange(run) }
+
+func (v *Viper) OnRemoteConfigChange(run func(in RemoteEvent)) {
+	v.onRemoteConfigChange = run
 }
 
 // Retrieve the first found remote configuration.
@@ -178,79 +489,564 @@ func (v *Viper) getKeyValueConfig() error {
 		return RemoteConfigError("Enable the remote features by doing a blank import of the viper/remote package: '_ github.com/spf13/viper/remote'")
 	}
 
-	if len(v.remoteProviders) == 0 {
+	if len(v.remoteProviders) == 0 && len(v.independentRemoteProviders()) == 0 {
 		return RemoteConfigError("No Remote Providers")
 	}
 
+	if len(v.remoteProviders) > 0 {
+		if err := v.getFirstRemoteConfig(); err != nil {
+			return err
+		}
+	} else {
+		v.remoteStale = false
+	}
+
+	err := v.getIndependentRemoteConfigs()
+	v.updateVersion()
+
+	return err
+}
+
+// getFirstRemoteConfig reads the first remote provider that can be reached,
+// or the first cached one (see WithRemoteCache).
+func (v *Viper) getFirstRemoteConfig() error {
 	for _, rp := range v.remoteProviders {
 		val, err := v.getRemoteConfig(rp)
+		v.recordRemoteResult(rp, err)
 		if err != nil {
 			v.logger.Error(fmt.Errorf("get remote config: %w", err).Error())
 
 			continue
 		}
 
-		v.kvstore = val
+		v.kvstore.store(val)
+		v.remoteStale = false
 
 		return nil
 	}
+
+	if v.remoteCacheDir != "" {
+		for _, rp := range v.remoteProviders {
+			val, err := v.readRemoteCache(rp)
+			if err != nil {
+				v.logger.Error(fmt.Errorf("read remote config cache: %w", err).Error())
+
+				continue
+			}
+
+			v.logger.Warn("serving stale remote config from cache", "provider", rp.Provider(), "path", rp.Path())
+
+			v.kvstore.store(val)
+			v.remoteStale = true
+			v.recordRemoteStale(rp)
+
+			return nil
+		}
+	}
+
 	return RemoteConfigError("No Files Found")
 }
 
+// getIndependentRemoteConfigs reads every remote mount and layer, falling back to the cache (see WithRemoteCache).
+func (v *Viper) getIndependentRemoteConfigs() error {
+	var errs []error
+
+	for _, rp := range v.independentRemoteProviders() {
+		_, err := v.getRemoteConfig(rp)
+		v.recordRemoteResult(rp, err)
+		if err == nil {
+			continue
+		}
+
+		v.logger.Error(fmt.Errorf("get remote config: %w", err).Error())
+
+		if v.remoteCacheDir != "" {
+			if _, cacheErr := v.readRemoteCache(rp); cacheErr == nil {
+				v.logger.Warn("serving stale remote config from cache", "provider", rp.Provider(), "path", rp.Path())
+
+				v.remoteStale = true
+				v.recordRemoteStale(rp)
+
+				continue
+			}
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", rp.describe(), err))
+	}
+
+	return errors.Join(errs...)
+}
+
 func (v *Viper) getRemoteConfig(provider RemoteProvider) (map[string]any, error) {
+	if rp, ok := provider.(*defaultRemoteProvider); ok && rp.prefix {
+		return v.getRemotePrefixConfig(rp)
+	}
+
 	reader, err := RemoteConfig.Get(provider)
 	if err != nil {
 		return nil, err
 	}
-	err = v.unmarshalReader(reader, v.kvstore)
-	return v.kvstore, err
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := v.verifyRemote(provider, b)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := v.decryptRemote(provider, b)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache holds the payload as received, so encrypted payloads are never stored in plain text
+	err = v.applyRemotePayload(provider, plaintext)
+	if err == nil {
+		v.writeRemoteCache(provider, b, signature)
+	}
+
+	return v.kvstore.load(), err
+}
+
+// getRemotePrefixConfig lists every key under the provider's prefix
+// and assembles them into the remote configuration registry.
+func (v *Viper) getRemotePrefixConfig(provider RemoteProvider) (map[string]any, error) {
+	if _, err := v.verifyRemote(provider, nil); err != nil {
+		return nil, err
+	}
+
+	lister, ok := RemoteConfig.(remoteConfigLister)
+	if !ok {
+		return nil, RemoteConfigError("remote provider does not support listing keys")
+	}
+
+	kvs, err := lister.List(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, err := json.Marshal(kvs); err == nil {
+		v.writeRemoteCache(provider, b, nil)
+	}
+
+	return v.setRemoteKeyValues(provider, kvs), nil
+}
+
+// openRemotePayload verifies and decrypts a payload read from a provider.
+func (v *Viper) openRemotePayload(provider RemoteProvider, b []byte) ([]byte, error) {
+	if _, err := v.verifyRemote(provider, b); err != nil {
+		return nil, err
+	}
+
+	return v.decryptRemote(provider, b)
+}
+
+// applyRemotePayload reads a payload into the remote configuration registry,
+// into the key of a remote mount, or merges it with the other remote layers.
+func (v *Viper) applyRemotePayload(provider RemoteProvider, b []byte) error {
+	rp, ok := provider.(*defaultRemoteProvider)
+	if !ok || (rp.mount == "" && !rp.layered) {
+		return v.kvstore.update(func(kvstore map[string]any) error {
+			return v.unmarshalReader(bytes.NewReader(b), kvstore)
+		})
+	}
+
+	cfg := make(map[string]any)
+	if err := v.unmarshalReader(bytes.NewReader(b), cfg); err != nil {
+		return err
+	}
+
+	if rp.layered {
+		// Layers are watched concurrently, so their state is only touched while the registry is updated
+		return v.kvstore.update(func(kvstore map[string]any) error {
+			if v.remoteLayerConfigs == nil {
+				v.remoteLayerConfigs = make(map[*defaultRemoteProvider]map[string]any)
+			}
+
+			v.remoteLayerConfigs[rp] = cfg
+			v.mergeRemoteLayers(kvstore)
+
+			return nil
+		})
+	}
+
+	path := strings.Split(rp.mount, v.keyDelim)
+	lastKey := path[len(path)-1]
+
+	return v.kvstore.update(func(kvstore map[string]any) error {
+		deepestMap := maputil.DeepSearch(kvstore, path[0:len(path)-1])
+		deepestMap[lastKey] = cfg
+
+		return nil
+	})
+}
+
+// mergeRemoteLayers merges the last known configuration of every remote layer, in order,
+// into the remote configuration registry. It must be called from an update of the registry.
+func (v *Viper) mergeRemoteLayers(kvstore map[string]any) {
+	merged := make(map[string]any)
+
+	for _, rp := range v.remoteLayers {
+		if cfg, ok := v.remoteLayerConfigs[rp]; ok {
+			v.mergeMaps(deepCopyMap(cfg), merged, nil, "", nil)
+		}
+	}
+
+	// Keys removed from every layer must not linger from a previous merge
+	for _, key := range v.remoteLayerKeys {
+		delete(kvstore, key)
+	}
+
+	v.remoteLayerKeys = v.remoteLayerKeys[:0]
+
+	for key, val := range merged {
+		kvstore[key] = val
+		v.remoteLayerKeys = append(v.remoteLayerKeys, key)
+	}
+}
+
+// decryptRemote decrypts a payload read from a provider added with AddDecryptedRemoteProvider.
+// Payloads of other providers are returned as is.
+func (v *Viper) decryptRemote(provider RemoteProvider, b []byte) ([]byte, error) {
+	rp, ok := provider.(*defaultRemoteProvider)
+	if !ok || rp.decrypter == nil {
+		return b, nil
+	}
+
+	plaintext, err := rp.decrypter.Decrypt(b)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt remote config: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// setRemoteKeyValues assembles the keys listed under the provider's prefix
+// into the remote configuration registry.
+// Keys read from the provider before and no longer listed are removed.
+func (v *Viper) setRemoteKeyValues(provider RemoteProvider, kvs map[string][]byte) map[string]any {
+	prefix := strings.Trim(provider.Path(), "/")
+
+	v.kvstore.update(func(kvstore map[string]any) error {
+		for _, path := range v.remotePrefixKeys[provider] {
+			deleteKeyPath(kvstore, path)
+		}
+
+		if v.remotePrefixKeys == nil {
+			v.remotePrefixKeys = make(map[RemoteProvider][][]string)
+		}
+
+		paths := make([][]string, 0, len(kvs))
+
+		for key, value := range kvs {
+			// Keys ending with a slash are "folders" in consul
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+
+			key = strings.Trim(key, "/")
+			if prefix != "" {
+				// Skip siblings sharing the prefix (e.g. "myapp2" for "myapp")
+				if !strings.HasPrefix(key, prefix+"/") {
+					continue
+				}
+
+				key = strings.TrimPrefix(key, prefix+"/")
+			}
+
+			path := strings.Split(strings.ToLower(key), "/")
+			lastKey := path[len(path)-1]
+			deepestMap := maputil.DeepSearch(kvstore, path[0:len(path)-1])
+
+			deepestMap[lastKey] = string(value)
+			paths = append(paths, path)
+		}
+
+		v.remotePrefixKeys[provider] = paths
+
+		return nil
+	})
+
+	return v.kvstore.load()
+}
+
+// deleteKeyPath deletes a key path from nested maps, along with the maps it leaves empty.
+func deleteKeyPath(m map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(m, path[0])
+
+		return
+	}
+
+	nested, ok := m[path[0]].(map[string]any)
+	if !ok {
+		return
+	}
+
+	deleteKeyPath(nested, path[1:])
+
+	if len(nested) == 0 {
+		delete(m, path[0])
+	}
 }
 
 // Retrieve the first found remote configuration.
-func (v *Viper) watchKeyValueConfigOnChannel() error {
-	if len(v.remoteProviders) == 0 {
+func (v *Viper) watchKeyValueConfigOnChannel(ctx context.Context) error {
+	if RemoteConfig == nil {
+		return RemoteConfigError("Enable the remote features by doing a blank import of the viper/remote package: '_ github.com/spf13/viper/remote'")
+	}
+
+	if len(v.remoteProviders) == 0 && len(v.independentRemoteProviders()) == 0 {
 		return RemoteConfigError("No Remote Providers")
 	}
 
-	for _, rp := range v.remoteProviders {
-		respc, _ := RemoteConfig.WatchChannel(rp)
-		// Todo: Add quit channel
-		go func(rc <-chan *RemoteResponse) {
-			for {
-				b := <-rc
-				reader := bytes.NewReader(b.Value)
-				v.unmarshalReader(reader, v.kvstore)
+	if len(v.remoteProviders) > 0 {
+		watching := false
+
+		for _, rp := range v.remoteProviders {
+			if v.watchRemoteChannel(ctx, rp) {
+				watching = true
+
+				break
+			}
+		}
+
+		if !watching {
+			return RemoteConfigError("No Files Found")
+		}
+	}
+
+	for _, rp := range v.independentRemoteProviders() {
+		v.watchRemoteChannel(ctx, rp)
+	}
+
+	return nil
+}
+
+// watchRemoteChannel watches a provider on a channel in the background until ctx is canceled.
+// It reports whether the provider could be watched.
+func (v *Viper) watchRemoteChannel(ctx context.Context, rp RemoteProvider) bool {
+	respc, quit := RemoteConfig.WatchChannel(rp)
+	if respc == nil {
+		v.logger.Error("watch remote config: provider returned no channel", "provider", rp.Provider())
+
+		return false
+	}
+
+	// A payload may be received before its signature is updated:
+	// the signature is watched to apply the rejected payload once it is.
+	var (
+		sigc    <-chan *RemoteResponse
+		sigQuit chan bool
+	)
+
+	if p, ok := rp.(*defaultRemoteProvider); v.remoteVerifier != nil && (!ok || !p.prefix) {
+		sigc, sigQuit = RemoteConfig.WatchChannel(v.remoteSignature(rp))
+	}
+
+	v.recordRemoteWatching(rp, true)
+
+	go func(rp RemoteProvider, rc <-chan *RemoteResponse, quit chan bool) {
+		var (
+			pending  *RemoteResponse
+			rejected *RemoteResponse
+			debounce *time.Timer
+			fire     <-chan time.Time
+		)
+
+		defer func() {
+			if debounce != nil {
+				debounce.Stop()
+			}
+
+			if sigQuit != nil {
+				close(sigQuit)
 			}
-		}(respc)
+
+			v.recordRemoteWatching(rp, false)
+		}()
+
+		handle := func(resp *RemoteResponse) {
+			err := v.handleRemoteResponse(rp, resp)
+
+			switch {
+			case resp == nil || resp.Error != nil:
+			case err != nil:
+				rejected = resp
+			default:
+				rejected = nil
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				if quit != nil {
+					close(quit)
+				}
+
+				return
+
+			case b, ok := <-rc:
+				if !ok {
+					if pending != nil {
+						handle(pending)
+					}
+
+					return
+				}
+
+				// Errors are reported right away, updates may be coalesced
+				if v.remoteWatchDebounce <= 0 || b == nil || b.Error != nil {
+					handle(b)
+
+					continue
+				}
+
+				pending = b
+
+				if debounce != nil {
+					debounce.Stop()
+				}
+				debounce = time.NewTimer(v.remoteWatchDebounce)
+				fire = debounce.C
+
+			case <-fire:
+				handle(pending)
+
+				pending = nil
+				fire = nil
+
+			case sig, ok := <-sigc:
+				if !ok {
+					sigc = nil
+
+					continue
+				}
+
+				if sig != nil && sig.Error == nil && rejected != nil {
+					handle(rejected)
+				}
+			}
+		}
+	}(rp, respc, quit)
+
+	return true
+}
+
+// handleRemoteResponse applies a response received from a remote watch channel
+// and notifies the remote change handler. It returns the error of the event.
+func (v *Viper) handleRemoteResponse(rp RemoteProvider, resp *RemoteResponse) error {
+	if resp == nil {
 		return nil
 	}
-	return RemoteConfigError("No Files Found")
+
+	event := RemoteEvent{
+		Provider: rp,
+		Value:    resp.Value,
+		Error:    resp.Error,
+	}
+
+	if event.Error == nil {
+		if p, ok := rp.(*defaultRemoteProvider); ok && p.prefix {
+			// values received for a prefix are single keys: list the whole prefix again
+			_, event.Error = v.getRemotePrefixConfig(p)
+		} else {
+			var plaintext []byte
+
+			plaintext, event.Error = v.openRemotePayload(rp, resp.Value)
+			if event.Error == nil {
+				event.Error = v.applyRemotePayload(rp, plaintext)
+			}
+		}
+	}
+
+	if event.Error == nil {
+		event.Version, event.Hash = v.updateVersion()
+	} else {
+		event.Version, event.Hash = v.Version()
+	}
+
+	v.recordRemoteResult(rp, event.Error)
+
+	if event.Error != nil {
+		v.logger.Error(fmt.Errorf("watch remote config: %w", event.Error).Error())
+	}
+
+	if v.onRemoteConfigChange != nil {
+		v.onRemoteConfigChange(event)
+	}
+
+	return event.Error
 }
 
 // Retrieve the first found remote configuration.
 func (v *Viper) watchKeyValueConfig() error {
-	if len(v.remoteProviders) == 0 {
+	if len(v.remoteProviders) == 0 && len(v.independentRemoteProviders()) == 0 {
 		return RemoteConfigError("No Remote Providers")
 	}
 
+	if len(v.remoteProviders) > 0 {
+		if err := v.watchFirstRemoteConfig(); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+
+	for _, rp := range v.independentRemoteProviders() {
+		_, err := v.watchRemoteConfig(rp)
+		v.recordRemoteResult(rp, err)
+		if err != nil {
+			v.logger.Error(fmt.Errorf("watch remote config: %w", err).Error())
+
+			errs = append(errs, fmt.Errorf("%s: %w", rp.describe(), err))
+		}
+	}
+
+	v.updateVersion()
+
+	return errors.Join(errs...)
+}
+
+func (v *Viper) watchFirstRemoteConfig() error {
 	for _, rp := range v.remoteProviders {
 		val, err := v.watchRemoteConfig(rp)
+		v.recordRemoteResult(rp, err)
 		if err != nil {
 			v.logger.Error(fmt.Errorf("watch remote config: %w", err).Error())
 
 			continue
 		}
-		v.kvstore = val
+		v.kvstore.store(val)
 		return nil
 	}
 	return RemoteConfigError("No Files Found")
 }
 
 func (v *Viper) watchRemoteConfig(provider RemoteProvider) (map[string]any, error) {
+	if rp, ok := provider.(*defaultRemoteProvider); ok && rp.prefix {
+		return v.getRemotePrefixConfig(rp)
+	}
+
 	reader, err := RemoteConfig.Watch(provider)
 	if err != nil {
 		return nil, err
 	}
-	err = v.unmarshalReader(reader, v.kvstore)
-	return v.kvstore, err
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := v.openRemotePayload(provider, b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.applyRemotePayload(provider, plaintext)
+	return v.kvstore.load(), err
 }
@@ -0,0 +1,605 @@
+// Package dynamodb implements a Viper remote configuration provider
+// backed by an Amazon DynamoDB table.
+//
+// Every configuration is stored in a single item of the table
+// identified by a partition key (the application) and a sort key (the environment).
+// The configuration payload itself is stored in a string or binary attribute of the item.
+//
+// The endpoint of the remote provider is the table name
+// and the path is "app/environment":
+//
+//	viper.RemoteConfig = dynamodb.New(dynamodb.WithRegion("eu-west-1"))
+//
+//	viper.AddRemoteProvider("dynamodb", "config", "myapp/production")
+//	viper.SetConfigType("yaml")
+//	viper.ReadRemoteConfig()
+//
+// Watching is implemented with DynamoDB Streams,
+// so the table must have a stream enabled (any view type).
+package dynamodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+
+	"github.com/spf13/viper"
+)
+
+// Option configures a [Provider].
+type Option func(p *Provider)
+
+// WithAWSConfig uses cfg instead of the default AWS configuration
+// (loaded from the environment, shared config files, and instance metadata).
+func WithAWSConfig(cfg aws.Config) Option {
+	return func(p *Provider) {
+		p.awsConfig = &cfg
+	}
+}
+
+// WithRegion overrides the AWS region of the default AWS configuration.
+func WithRegion(region string) Option {
+	return func(p *Provider) {
+		p.loadOptions = append(p.loadOptions, config.WithRegion(region))
+	}
+}
+
+// WithBaseEndpoint sends requests to a custom endpoint (eg. DynamoDB Local or LocalStack).
+func WithBaseEndpoint(endpoint string) Option {
+	return func(p *Provider) {
+		p.baseEndpoint = endpoint
+	}
+}
+
+// WithHTTPClient sends requests with client (eg. to route them through a proxy or use mTLS).
+// It takes precedence over the HTTP client of the AWS configuration.
+//
+// The default client honours the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Provider) {
+		p.httpClient = client
+	}
+}
+
+// WithTransport sends requests with an HTTP client using transport
+// (eg. an instrumented [http.RoundTripper]).
+//
+// Custom transports should use [http.ProxyFromEnvironment]
+// to keep honouring the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(p *Provider) {
+		p.httpClient = &http.Client{Transport: transport}
+	}
+}
+
+// WithKeyAttributes sets the names of the partition and sort key attributes.
+// Defaults to "app" and "environment".
+func WithKeyAttributes(partitionKey, sortKey string) Option {
+	return func(p *Provider) {
+		p.partitionKey = partitionKey
+		p.sortKey = sortKey
+	}
+}
+
+// WithValueAttribute sets the name of the attribute holding the configuration payload.
+// Defaults to "config".
+func WithValueAttribute(name string) Option {
+	return func(p *Provider) {
+		p.valueAttribute = name
+	}
+}
+
+// WithConsistentRead enables strongly consistent reads.
+func WithConsistentRead() Option {
+	return func(p *Provider) {
+		p.consistentRead = true
+	}
+}
+
+// WithTimeout sets the timeout of Get and List operations. Defaults to 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = d
+	}
+}
+
+// WithPollInterval sets how often the table stream is polled for changes. Defaults to 1 second.
+func WithPollInterval(d time.Duration) Option {
+	return func(p *Provider) {
+		p.pollInterval = d
+	}
+}
+
+// Provider reads configuration from an Amazon DynamoDB table.
+// Assign it to [viper.RemoteConfig] to use it.
+type Provider struct {
+	awsConfig    *aws.Config
+	loadOptions  []func(*config.LoadOptions) error
+	baseEndpoint string
+	httpClient   *http.Client
+
+	partitionKey   string
+	sortKey        string
+	valueAttribute string
+	consistentRead bool
+
+	timeout      time.Duration
+	pollInterval time.Duration
+
+	// newTableClient and newStreamsClient create the clients of the table and of its stream.
+	newTableClient   func(cfg aws.Config) tableClient
+	newStreamsClient func(cfg aws.Config) streamsClient
+}
+
+// tableClient is the part of the DynamoDB API used by [Provider].
+type tableClient interface {
+	awsdynamodb.QueryAPIClient
+	GetItem(ctx context.Context, params *awsdynamodb.GetItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.GetItemOutput, error)
+	DescribeTable(ctx context.Context, params *awsdynamodb.DescribeTableInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.DescribeTableOutput, error)
+}
+
+// streamsClient is the part of the DynamoDB Streams API used by [Provider].
+type streamsClient interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
+// New returns a new [Provider].
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		partitionKey:   "app",
+		sortKey:        "environment",
+		valueAttribute: "config",
+		timeout:        10 * time.Second,
+		pollInterval:   time.Second,
+	}
+
+	p.newTableClient = p.client
+	p.newStreamsClient = p.streams
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Get reads the item stored at the "app/environment" path of the remote provider
+// from the table named by its endpoint.
+func (p *Provider) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	app, env, err := splitPath(rp.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	cfg, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := p.getItem(ctx, p.newTableClient(cfg), rp.Endpoint(), app, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(b), nil
+}
+
+// Watch reads the current item stored at the "app/environment" path of the remote provider.
+func (p *Provider) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return p.Get(rp)
+}
+
+// List reads every environment of the application named by the path of the remote provider.
+// Returned keys are formatted as "app/environment".
+func (p *Provider) List(rp viper.RemoteProvider) (map[string][]byte, error) {
+	app, _, _ := strings.Cut(strings.Trim(rp.Path(), "/"), "/")
+	if app == "" {
+		return nil, fmt.Errorf("invalid path %q: app is missing", rp.Path())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	cfg, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := awsdynamodb.NewQueryPaginator(p.newTableClient(cfg), &awsdynamodb.QueryInput{
+		TableName:                aws.String(rp.Endpoint()),
+		KeyConditionExpression:   aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]string{"#pk": p.partitionKey},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: app},
+		},
+		ConsistentRead: aws.Bool(p.consistentRead),
+	})
+
+	kvs := map[string][]byte{}
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			env, ok := item[p.sortKey].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+
+			b, err := p.value(item)
+			if err != nil {
+				return nil, err
+			}
+
+			kvs[app+"/"+env.Value] = b
+		}
+	}
+
+	return kvs, nil
+}
+
+// WatchChannel streams the item stored at the "app/environment" path of the remote provider
+// every time it changes, using the DynamoDB stream of the table.
+// Deleted items are ignored.
+// Sending to (or closing) the returned quit channel stops the watcher.
+func (p *Provider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	resp := make(chan *viper.RemoteResponse)
+	quit := make(chan bool)
+
+	go func() {
+		defer close(resp)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			select {
+			case <-quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		send := func(r *viper.RemoteResponse) bool {
+			select {
+			case resp <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		app, env, err := splitPath(rp.Path())
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+
+			return
+		}
+
+		cfg, err := p.config(ctx)
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+
+			return
+		}
+
+		client := p.newTableClient(cfg)
+
+		table, err := client.DescribeTable(ctx, &awsdynamodb.DescribeTableInput{
+			TableName: aws.String(rp.Endpoint()),
+		})
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+
+			return
+		}
+
+		if table.Table.LatestStreamArn == nil {
+			send(&viper.RemoteResponse{Error: fmt.Errorf("table %q has no stream enabled", rp.Endpoint())})
+
+			return
+		}
+
+		w := &streamWatcher{
+			streams:   p.newStreamsClient(cfg),
+			streamArn: table.Table.LatestStreamArn,
+			iterators: map[string]*string{},
+			types:     map[string]streamtypes.ShardIteratorType{},
+			sequences: map[string]*string{},
+			done:      map[string]bool{},
+		}
+
+		ticker := time.NewTicker(p.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			changed, err := w.poll(ctx, func(keys map[string]streamtypes.AttributeValue) bool {
+				return streamKeyEquals(keys[p.partitionKey], app) && streamKeyEquals(keys[p.sortKey], env)
+			})
+
+			switch {
+			case ctx.Err() != nil:
+				return
+
+			case err != nil:
+				if !send(&viper.RemoteResponse{Error: err}) {
+					return
+				}
+
+			case changed:
+				// Stream records may not contain the new image (depending on the stream view type),
+				// so the current item is read from the table instead.
+				getCtx, getCancel := context.WithTimeout(ctx, p.timeout)
+				b, err := p.getItem(getCtx, client, rp.Endpoint(), app, env)
+				getCancel()
+
+				if errors.Is(err, errItemNotFound) {
+					break
+				}
+
+				if !send(&viper.RemoteResponse{Value: b, Error: err}) {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return resp, quit
+}
+
+var errItemNotFound = errors.New("item not found")
+
+func (p *Provider) getItem(ctx context.Context, client tableClient, table, app, env string) ([]byte, error) {
+	out, err := client.GetItem(ctx, &awsdynamodb.GetItemInput{
+		TableName: aws.String(table),
+		Key: map[string]types.AttributeValue{
+			p.partitionKey: &types.AttributeValueMemberS{Value: app},
+			p.sortKey:      &types.AttributeValueMemberS{Value: env},
+		},
+		ConsistentRead: aws.Bool(p.consistentRead),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("%w: %s/%s in table %q", errItemNotFound, app, env, table)
+	}
+
+	return p.value(out.Item)
+}
+
+// value returns the configuration payload of an item.
+func (p *Provider) value(item map[string]types.AttributeValue) ([]byte, error) {
+	switch v := item[p.valueAttribute].(type) {
+	case *types.AttributeValueMemberS:
+		return []byte(v.Value), nil
+	case *types.AttributeValueMemberB:
+		return v.Value, nil
+	case nil:
+		return nil, fmt.Errorf("attribute %q is missing", p.valueAttribute)
+	default:
+		return nil, fmt.Errorf("attribute %q must be a string or binary", p.valueAttribute)
+	}
+}
+
+func (p *Provider) config(ctx context.Context) (aws.Config, error) {
+	cfg := aws.Config{}
+	if p.awsConfig != nil {
+		cfg = *p.awsConfig
+	} else {
+		var err error
+
+		cfg, err = config.LoadDefaultConfig(ctx, p.loadOptions...)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	if p.httpClient != nil {
+		cfg.HTTPClient = p.httpClient
+	}
+
+	return cfg, nil
+}
+
+func (p *Provider) client(cfg aws.Config) tableClient {
+	return awsdynamodb.NewFromConfig(cfg, func(o *awsdynamodb.Options) {
+		if p.baseEndpoint != "" {
+			o.BaseEndpoint = aws.String(p.baseEndpoint)
+		}
+	})
+}
+
+func (p *Provider) streams(cfg aws.Config) streamsClient {
+	return dynamodbstreams.NewFromConfig(cfg, func(o *dynamodbstreams.Options) {
+		if p.baseEndpoint != "" {
+			o.BaseEndpoint = aws.String(p.baseEndpoint)
+		}
+	})
+}
+
+// streamWatcher follows every shard of a DynamoDB stream from its latest record.
+type streamWatcher struct {
+	streams   streamsClient
+	streamArn *string
+
+	// iterators holds the next shard iterator of every open shard.
+	iterators map[string]*string
+
+	// types holds the iterator type every shard was first read with.
+	types map[string]streamtypes.ShardIteratorType
+
+	// sequences holds the sequence number of the last record read from every shard.
+	sequences map[string]*string
+
+	// done holds the shards that were fully read.
+	done map[string]bool
+}
+
+// poll reads new records from every shard of the stream
+// and reports whether one of them matched.
+func (w *streamWatcher) poll(ctx context.Context, match func(keys map[string]streamtypes.AttributeValue) bool) (bool, error) {
+	if err := w.discoverShards(ctx); err != nil {
+		return false, err
+	}
+
+	changed := false
+
+	for shardID, iterator := range w.iterators {
+		out, err := w.streams.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: iterator,
+		})
+		if err != nil {
+			var expired *streamtypes.ExpiredIteratorException
+			if !errors.As(err, &expired) {
+				return changed, err
+			}
+
+			// Resume after the last record read, so that records are neither replayed nor skipped
+			it, err := w.shardIterator(ctx, shardID)
+			if err != nil {
+				return changed, err
+			}
+
+			w.iterators[shardID] = it
+
+			continue
+		}
+
+		for _, record := range out.Records {
+			if record.Dynamodb != nil && record.Dynamodb.SequenceNumber != nil {
+				w.sequences[shardID] = record.Dynamodb.SequenceNumber
+			}
+
+			if record.EventName == streamtypes.OperationTypeRemove || record.Dynamodb == nil {
+				continue
+			}
+
+			if match(record.Dynamodb.Keys) {
+				changed = true
+			}
+		}
+
+		if out.NextShardIterator == nil {
+			// The shard is closed, its children are picked up by discoverShards
+			delete(w.iterators, shardID)
+			w.done[shardID] = true
+
+			continue
+		}
+
+		w.iterators[shardID] = out.NextShardIterator
+	}
+
+	return changed, nil
+}
+
+// discoverShards requests an iterator for every shard of the stream that is not followed yet.
+// Shards already present when watching starts are read from their latest record,
+// shards created afterwards (eg. after a split) are read from the beginning.
+func (w *streamWatcher) discoverShards(ctx context.Context) error {
+	initial := len(w.iterators) == 0 && len(w.done) == 0
+
+	input := &dynamodbstreams.DescribeStreamInput{
+		StreamArn: w.streamArn,
+	}
+
+	for {
+		out, err := w.streams.DescribeStream(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		for _, shard := range out.StreamDescription.Shards {
+			shardID := aws.ToString(shard.ShardId)
+			if _, ok := w.iterators[shardID]; ok || w.done[shardID] {
+				continue
+			}
+
+			iteratorType := streamtypes.ShardIteratorTypeTrimHorizon
+			if initial {
+				iteratorType = streamtypes.ShardIteratorTypeLatest
+			}
+
+			w.types[shardID] = iteratorType
+
+			it, err := w.shardIterator(ctx, shardID)
+			if err != nil {
+				return err
+			}
+
+			w.iterators[shardID] = it
+		}
+
+		if out.StreamDescription.LastEvaluatedShardId == nil {
+			return nil
+		}
+
+		input.ExclusiveStartShardId = out.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// shardIterator requests an iterator reading a shard after its last record read,
+// or from the position it was first read from when no record was read yet.
+func (w *streamWatcher) shardIterator(ctx context.Context, shardID string) (*string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         w.streamArn,
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: w.types[shardID],
+	}
+
+	if seq, ok := w.sequences[shardID]; ok {
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = seq
+	}
+
+	out, err := w.streams.GetShardIterator(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.ShardIterator, nil
+}
+
+func streamKeyEquals(v streamtypes.AttributeValue, s string) bool {
+	sv, ok := v.(*streamtypes.AttributeValueMemberS)
+
+	return ok && sv.Value == s
+}
+
+// splitPath splits an "app/environment" path.
+func splitPath(path string) (string, string, error) {
+	app, env, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	if app == "" || env == "" {
+		return "", "", fmt.Errorf("invalid path %q: expected app/environment", path)
+	}
+
+	return app, env, nil
+}
@@ -0,0 +1,295 @@
+package dynamodb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type remoteProvider struct {
+	endpoint string
+	path     string
+}
+
+func (rp remoteProvider) Provider() string      { return "dynamodb" }
+func (rp remoteProvider) Endpoint() string      { return rp.endpoint }
+func (rp remoteProvider) Path() string          { return rp.path }
+func (rp remoteProvider) SecretKeyring() string { return "" }
+
+// fakeTable is an in-memory table keyed by "app/environment".
+type fakeTable struct {
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+}
+
+func newFakeTable() *fakeTable {
+	return &fakeTable{items: map[string]map[string]types.AttributeValue{}}
+}
+
+func (t *fakeTable) put(app, env string, value types.AttributeValue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.items[app+"/"+env] = map[string]types.AttributeValue{
+		"app":         &types.AttributeValueMemberS{Value: app},
+		"environment": &types.AttributeValueMemberS{Value: env},
+		"config":      value,
+	}
+}
+
+func (t *fakeTable) GetItem(_ context.Context, params *awsdynamodb.GetItemInput, _ ...func(*awsdynamodb.Options)) (*awsdynamodb.GetItemOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	app := params.Key["app"].(*types.AttributeValueMemberS).Value
+	env := params.Key["environment"].(*types.AttributeValueMemberS).Value
+
+	return &awsdynamodb.GetItemOutput{Item: t.items[app+"/"+env]}, nil
+}
+
+func (t *fakeTable) Query(_ context.Context, params *awsdynamodb.QueryInput, _ ...func(*awsdynamodb.Options)) (*awsdynamodb.QueryOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	app := params.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+
+	out := &awsdynamodb.QueryOutput{}
+	for _, item := range t.items {
+		if item["app"].(*types.AttributeValueMemberS).Value == app {
+			out.Items = append(out.Items, item)
+		}
+	}
+
+	return out, nil
+}
+
+func (t *fakeTable) DescribeTable(_ context.Context, _ *awsdynamodb.DescribeTableInput, _ ...func(*awsdynamodb.Options)) (*awsdynamodb.DescribeTableOutput, error) {
+	return &awsdynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{LatestStreamArn: aws.String("stream")},
+	}, nil
+}
+
+// fakeStream is a stream with a single shard.
+type fakeStream struct {
+	mu        sync.Mutex
+	records   []streamtypes.Record
+	expire    bool
+	iterators []*dynamodbstreams.GetShardIteratorInput
+}
+
+func (s *fakeStream) push(app, env, seq string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, streamtypes.Record{
+		EventName: streamtypes.OperationTypeModify,
+		Dynamodb: &streamtypes.StreamRecord{
+			Keys: map[string]streamtypes.AttributeValue{
+				"app":         &streamtypes.AttributeValueMemberS{Value: app},
+				"environment": &streamtypes.AttributeValueMemberS{Value: env},
+			},
+			SequenceNumber: aws.String(seq),
+		},
+	})
+}
+
+func (s *fakeStream) expireIterator() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire = true
+}
+
+func (s *fakeStream) iteratorRequests() []*dynamodbstreams.GetShardIteratorInput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*dynamodbstreams.GetShardIteratorInput(nil), s.iterators...)
+}
+
+func (s *fakeStream) DescribeStream(_ context.Context, _ *dynamodbstreams.DescribeStreamInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	return &dynamodbstreams.DescribeStreamOutput{
+		StreamDescription: &streamtypes.StreamDescription{
+			Shards: []streamtypes.Shard{{ShardId: aws.String("shard-0")}},
+		},
+	}, nil
+}
+
+func (s *fakeStream) GetShardIterator(_ context.Context, params *dynamodbstreams.GetShardIteratorInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.iterators = append(s.iterators, params)
+
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String("iterator")}, nil
+}
+
+func (s *fakeStream) GetRecords(_ context.Context, _ *dynamodbstreams.GetRecordsInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expire {
+		s.expire = false
+
+		return nil, &streamtypes.ExpiredIteratorException{}
+	}
+
+	records := s.records
+	s.records = nil
+
+	return &dynamodbstreams.GetRecordsOutput{Records: records, NextShardIterator: aws.String("iterator")}, nil
+}
+
+func newFakeProvider(table *fakeTable, stream *fakeStream) *Provider {
+	p := New(WithAWSConfig(aws.Config{}), WithPollInterval(10*time.Millisecond))
+	p.newTableClient = func(aws.Config) tableClient { return table }
+	p.newStreamsClient = func(aws.Config) streamsClient { return stream }
+
+	return p
+}
+
+func TestProvider_Get(t *testing.T) {
+	table := newFakeTable()
+	table.put("myapp", "production", &types.AttributeValueMemberS{Value: "foo: bar"})
+	table.put("myapp", "staging", &types.AttributeValueMemberB{Value: []byte("foo: baz")})
+
+	p := newFakeProvider(table, &fakeStream{})
+
+	r, err := p.Get(remoteProvider{endpoint: "config", path: "myapp/production"})
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "foo: bar", string(b))
+
+	r, err = p.Get(remoteProvider{endpoint: "config", path: "myapp/staging"})
+	require.NoError(t, err)
+
+	b, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "foo: baz", string(b))
+
+	_, err = p.Get(remoteProvider{endpoint: "config", path: "myapp/development"})
+	require.ErrorIs(t, err, errItemNotFound)
+
+	_, err = p.Get(remoteProvider{endpoint: "config", path: "myapp"})
+	require.Error(t, err)
+}
+
+func TestProvider_List(t *testing.T) {
+	table := newFakeTable()
+	table.put("myapp", "production", &types.AttributeValueMemberS{Value: "foo: bar"})
+	table.put("myapp", "staging", &types.AttributeValueMemberS{Value: "foo: baz"})
+	table.put("otherapp", "production", &types.AttributeValueMemberS{Value: "foo: qux"})
+
+	p := newFakeProvider(table, &fakeStream{})
+
+	kvs, err := p.List(remoteProvider{endpoint: "config", path: "myapp"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]byte{
+		"myapp/production": []byte("foo: bar"),
+		"myapp/staging":    []byte("foo: baz"),
+	}, kvs)
+}
+
+func TestProvider_WatchChannel(t *testing.T) {
+	table := newFakeTable()
+	table.put("myapp", "production", &types.AttributeValueMemberS{Value: "foo: bar"})
+
+	stream := &fakeStream{}
+
+	p := newFakeProvider(table, stream)
+
+	resp, quit := p.WatchChannel(remoteProvider{endpoint: "config", path: "myapp/production"})
+	defer close(quit)
+
+	require.Eventually(t, func() bool { return len(stream.iteratorRequests()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, streamtypes.ShardIteratorTypeLatest, stream.iteratorRequests()[0].ShardIteratorType)
+
+	// Changes of other items are ignored
+	stream.push("myapp", "staging", "100")
+
+	table.put("myapp", "production", &types.AttributeValueMemberS{Value: "foo: baz"})
+	stream.push("myapp", "production", "101")
+
+	select {
+	case r := <-resp:
+		require.NoError(t, r.Error)
+		assert.Equal(t, "foo: baz", string(r.Value))
+	case <-time.After(time.Second):
+		t.Fatal("no response received")
+	}
+
+	t.Run("ExpiredIterator", func(t *testing.T) {
+		stream.expireIterator()
+
+		require.Eventually(t, func() bool { return len(stream.iteratorRequests()) == 2 }, time.Second, 5*time.Millisecond)
+
+		input := stream.iteratorRequests()[1]
+		assert.Equal(t, streamtypes.ShardIteratorTypeAfterSequenceNumber, input.ShardIteratorType)
+		assert.Equal(t, "101", aws.ToString(input.SequenceNumber))
+
+		select {
+		case r := <-resp:
+			t.Fatalf("unexpected response: %+v", r)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.requests = append(rt.requests, req)
+	rt.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.0"}},
+		Body:       io.NopCloser(strings.NewReader(`{"Item":{"app":{"S":"myapp"},"environment":{"S":"production"},"config":{"S":"foo: bar"}}}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestWithTransport(t *testing.T) {
+	transport := &recordingTransport{}
+
+	p := New(
+		WithAWSConfig(aws.Config{
+			Region: "eu-west-1",
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"}, nil
+			}),
+		}),
+		WithBaseEndpoint("http://dynamodb.test"),
+		WithTransport(transport),
+	)
+
+	r, err := p.Get(remoteProvider{endpoint: "config", path: "myapp/production"})
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "foo: bar", string(b))
+
+	require.Len(t, transport.requests, 1)
+	assert.Equal(t, "dynamodb.test", transport.requests[0].URL.Host)
+	assert.Equal(t, "DynamoDB_20120810.GetItem", transport.requests[0].Header.Get("X-Amz-Target"))
+}
@@ -0,0 +1,173 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sagikazarmark/crypt/encoding/secconf"
+
+	"github.com/spf13/viper"
+)
+
+var errFirestoreInvalidPath = errors.New("firestore path must point to a document (collection/document)")
+
+// firestoreDocument mirrors the document layout used by crypt.
+type firestoreDocument struct {
+	Data []byte `firestore:"data"`
+}
+
+// firestoreSnapshots iterates over the snapshots of a document.
+type firestoreSnapshots interface {
+	Next() (firestoreSnapshot, error)
+	Stop()
+}
+
+// firestoreSnapshot is a snapshot of a document.
+type firestoreSnapshot interface {
+	Exists() bool
+	DataTo(p any) error
+}
+
+// openFirestoreSnapshots listens to the snapshots of the document at path
+// and returns a function closing the client. It is replaced in tests.
+var openFirestoreSnapshots = func(ctx context.Context, projectID, path string) (firestoreSnapshots, func(), error) {
+	client, err := firestore.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc := client.Doc(path)
+	if doc == nil {
+		client.Close()
+
+		return nil, nil, errFirestoreInvalidPath
+	}
+
+	return documentSnapshots{doc.Snapshots(ctx)}, func() { client.Close() }, nil
+}
+
+type documentSnapshots struct {
+	it *firestore.DocumentSnapshotIterator
+}
+
+func (s documentSnapshots) Next() (firestoreSnapshot, error) {
+	snap, err := s.it.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+func (s documentSnapshots) Stop() {
+	s.it.Stop()
+}
+
+// firestoreWatchChannel streams every update of the document at the path of the remote provider
+// using a Firestore snapshot listener, instead of the polling watcher of crypt.
+// The initial snapshot and deleted documents are ignored.
+func firestoreWatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	resp := make(chan *viper.RemoteResponse)
+	quit := make(chan bool)
+
+	go func() {
+		defer close(resp)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Snapshot iterators block until the next update, so quitting cancels the context
+		go func() {
+			select {
+			case <-quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		send := func(r *viper.RemoteResponse) bool {
+			select {
+			case resp <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var keyring []byte
+		if rp.SecretKeyring() != "" {
+			var err error
+
+			keyring, err = os.ReadFile(rp.SecretKeyring())
+			if err != nil {
+				send(&viper.RemoteResponse{Error: err})
+
+				return
+			}
+		}
+
+		it, closeClient, err := openFirestoreSnapshots(ctx, rp.Endpoint(), rp.Path())
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+
+			return
+		}
+		defer closeClient()
+		defer it.Stop()
+
+		initial := true
+
+		for {
+			snap, err := it.Next()
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				send(&viper.RemoteResponse{Error: err})
+
+				return
+			}
+
+			if initial {
+				initial = false
+
+				continue
+			}
+
+			if !snap.Exists() {
+				continue
+			}
+
+			var data firestoreDocument
+			if err := snap.DataTo(&data); err != nil {
+				if !send(&viper.RemoteResponse{Error: err}) {
+					return
+				}
+
+				continue
+			}
+
+			value := data.Data
+			if keyring != nil {
+				value, err = secconf.Decode(value, bytes.NewReader(keyring))
+				if err != nil {
+					if !send(&viper.RemoteResponse{Error: err}) {
+						return
+					}
+
+					continue
+				}
+			}
+
+			if !send(&viper.RemoteResponse{Value: value}) {
+				return
+			}
+		}
+	}()
+
+	return resp, quit
+}
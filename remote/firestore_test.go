@@ -0,0 +1,172 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spf13/viper"
+)
+
+type remoteProvider struct {
+	provider string
+	endpoint string
+	path     string
+}
+
+func (rp remoteProvider) Provider() string      { return rp.provider }
+func (rp remoteProvider) Endpoint() string      { return rp.endpoint }
+func (rp remoteProvider) Path() string          { return rp.path }
+func (rp remoteProvider) SecretKeyring() string { return "" }
+
+type fakeSnapshot struct {
+	exists bool
+	data   []byte
+	err    error
+}
+
+func (s fakeSnapshot) Exists() bool { return s.exists }
+
+func (s fakeSnapshot) DataTo(p any) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	p.(*firestoreDocument).Data = s.data
+
+	return nil
+}
+
+// fakeSnapshots returns the snapshots sent to it, blocking like a snapshot listener until the next one.
+type fakeSnapshots struct {
+	ctx       context.Context
+	snapshots chan fakeSnapshot
+	errs      chan error
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (s *fakeSnapshots) Next() (firestoreSnapshot, error) {
+	select {
+	case snap := <-s.snapshots:
+		return snap, nil
+	case err := <-s.errs:
+		return nil, err
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *fakeSnapshots) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+}
+
+func (s *fakeSnapshots) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopped
+}
+
+func withFakeFirestore(t *testing.T) (*fakeSnapshots, *[]string) {
+	t.Helper()
+
+	snapshots := &fakeSnapshots{
+		snapshots: make(chan fakeSnapshot),
+		errs:      make(chan error),
+	}
+
+	var opened []string
+
+	open := openFirestoreSnapshots
+	t.Cleanup(func() { openFirestoreSnapshots = open })
+
+	openFirestoreSnapshots = func(ctx context.Context, projectID, path string) (firestoreSnapshots, func(), error) {
+		opened = append(opened, projectID+":"+path)
+		snapshots.ctx = ctx
+
+		return snapshots, func() {}, nil
+	}
+
+	return snapshots, &opened
+}
+
+func receive(t *testing.T, resp <-chan *viper.RemoteResponse) *viper.RemoteResponse {
+	t.Helper()
+
+	select {
+	case r := <-resp:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("no response received")
+
+		return nil
+	}
+}
+
+func TestFirestoreWatchChannel(t *testing.T) {
+	snapshots, opened := withFakeFirestore(t)
+
+	resp, quit := remoteConfigProvider{}.WatchChannel(remoteProvider{
+		provider: "firestore",
+		endpoint: "my-project",
+		path:     "config/app",
+	})
+
+	// The initial snapshot and deleted documents are skipped
+	snapshots.snapshots <- fakeSnapshot{exists: true, data: []byte("foo: bar")}
+	snapshots.snapshots <- fakeSnapshot{exists: false}
+	snapshots.snapshots <- fakeSnapshot{exists: true, data: []byte("foo: baz")}
+
+	r := receive(t, resp)
+	require.NoError(t, r.Error)
+	assert.Equal(t, "foo: baz", string(r.Value))
+	assert.Equal(t, []string{"my-project:config/app"}, *opened)
+
+	// Documents that cannot be decoded are reported without stopping the watcher
+	snapshots.snapshots <- fakeSnapshot{exists: true, err: errors.New("invalid document")}
+
+	r = receive(t, resp)
+	require.EqualError(t, r.Error, "invalid document")
+
+	snapshots.snapshots <- fakeSnapshot{exists: true, data: []byte("foo: qux")}
+
+	r = receive(t, resp)
+	require.NoError(t, r.Error)
+	assert.Equal(t, "foo: qux", string(r.Value))
+
+	close(quit)
+
+	require.Eventually(t, snapshots.isStopped, time.Second, 5*time.Millisecond)
+
+	_, ok := <-resp
+	assert.False(t, ok)
+}
+
+func TestFirestoreWatchChannel_Error(t *testing.T) {
+	snapshots, _ := withFakeFirestore(t)
+
+	resp, quit := remoteConfigProvider{}.WatchChannel(remoteProvider{
+		provider: "firestore",
+		endpoint: "my-project",
+		path:     "config/app",
+	})
+	defer close(quit)
+
+	snapshots.errs <- errors.New("permission denied")
+
+	r := receive(t, resp)
+	require.EqualError(t, r.Error, "permission denied")
+
+	_, ok := <-resp
+	assert.False(t, ok)
+}
@@ -0,0 +1,295 @@
+// Package natskv implements a Viper remote configuration provider
+// backed by NATS JetStream Key-Value buckets.
+//
+// Unlike the crypt based "nats" support of the remote package,
+// it uses native JetStream watchers to stream updates to WatchChannel
+// and supports the authentication options of the NATS client.
+//
+// Configuration is addressed as "bucket/key" through the path of the remote provider:
+//
+//	viper.RemoteConfig = natskv.New(natskv.WithCredentials("/etc/nats/app.creds"))
+//
+//	viper.AddRemoteProvider("nats", "nats://127.0.0.1:4222", "config/app.yaml")
+//	viper.SetConfigType("yaml")
+//	viper.ReadRemoteConfig()
+package natskv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/spf13/viper"
+)
+
+// Option configures a [Provider].
+type Option func(p *Provider)
+
+// WithCredentials authenticates using a NATS credentials (JWT and NKey seed) file.
+func WithCredentials(file string) Option {
+	return func(p *Provider) {
+		p.natsOptions = append(p.natsOptions, nats.UserCredentials(file))
+	}
+}
+
+// WithNKeySeed authenticates using an NKey seed file.
+func WithNKeySeed(seedFile string) Option {
+	return func(p *Provider) {
+		opt, err := nats.NkeyOptionFromSeed(seedFile)
+		if err != nil {
+			p.err = errors.Join(p.err, fmt.Errorf("nkey seed: %w", err))
+
+			return
+		}
+
+		p.natsOptions = append(p.natsOptions, opt)
+	}
+}
+
+// WithUserInfo authenticates using a username and password.
+func WithUserInfo(user, password string) Option {
+	return func(p *Provider) {
+		p.natsOptions = append(p.natsOptions, nats.UserInfo(user, password))
+	}
+}
+
+// WithToken authenticates using a token.
+func WithToken(token string) Option {
+	return func(p *Provider) {
+		p.natsOptions = append(p.natsOptions, nats.Token(token))
+	}
+}
+
+// WithNATSOptions passes arbitrary options to the NATS connection.
+func WithNATSOptions(opts ...nats.Option) Option {
+	return func(p *Provider) {
+		p.natsOptions = append(p.natsOptions, opts...)
+	}
+}
+
+// WithTimeout sets the timeout of Get and List operations. Defaults to 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = d
+	}
+}
+
+// Provider reads configuration from NATS JetStream Key-Value buckets.
+// Assign it to [viper.RemoteConfig] to use it.
+type Provider struct {
+	natsOptions []nats.Option
+	timeout     time.Duration
+
+	// openKeyValue opens a bucket and returns a function closing its connection.
+	openKeyValue func(ctx context.Context, endpoint, bucket string) (jetstream.KeyValue, func(), error)
+
+	err error
+}
+
+// New returns a new [Provider].
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		timeout: 10 * time.Second,
+	}
+
+	p.openKeyValue = p.keyValue
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Get reads the value stored at the "bucket/key" path of the remote provider.
+func (p *Provider) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	bucket, key, err := splitPath(rp.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	kv, closeConn, err := p.openKeyValue(ctx, rp.Endpoint(), bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer closeConn()
+
+	entry, err := kv.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(entry.Value()), nil
+}
+
+// Watch reads the current value stored at the "bucket/key" path of the remote provider.
+func (p *Provider) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return p.Get(rp)
+}
+
+// List reads every key of the bucket starting with the key prefix of the "bucket/prefix" path
+// of the remote provider. Returned keys are prefixed with the bucket name.
+func (p *Provider) List(rp viper.RemoteProvider) (map[string][]byte, error) {
+	bucket, prefix, _ := strings.Cut(strings.Trim(rp.Path(), "/"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid path %q: bucket is missing", rp.Path())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	kv, closeConn, err := p.openKeyValue(ctx, rp.Endpoint(), bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer closeConn()
+
+	lister, err := kv.ListKeys(ctx)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrNoKeysFound) {
+			return map[string][]byte{}, nil
+		}
+
+		return nil, err
+	}
+
+	kvs := map[string][]byte{}
+
+	for key := range lister.Keys() {
+		if prefix != "" && key != prefix && !strings.HasPrefix(key, prefix+"/") {
+			continue
+		}
+
+		entry, err := kv.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		kvs[bucket+"/"+key] = entry.Value()
+	}
+
+	return kvs, nil
+}
+
+// WatchChannel streams every update of the "bucket/key" path of the remote provider
+// using a native JetStream watcher. Deleted and purged keys are ignored.
+// Sending to (or closing) the returned quit channel stops the watcher.
+func (p *Provider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	resp := make(chan *viper.RemoteResponse)
+	quit := make(chan bool)
+
+	go func() {
+		defer close(resp)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		send := func(r *viper.RemoteResponse) bool {
+			select {
+			case resp <- r:
+				return true
+			case <-quit:
+				return false
+			}
+		}
+
+		bucket, key, err := splitPath(rp.Path())
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+
+			return
+		}
+
+		kv, closeConn, err := p.openKeyValue(ctx, rp.Endpoint(), bucket)
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+
+			return
+		}
+		defer closeConn()
+
+		watcher, err := kv.Watch(ctx, key, jetstream.UpdatesOnly(), jetstream.IgnoreDeletes())
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+
+			return
+		}
+		defer watcher.Stop()
+
+		for {
+			select {
+			case <-quit:
+				return
+
+			case entry, ok := <-watcher.Updates():
+				if !ok {
+					send(&viper.RemoteResponse{Error: errors.New("nats kv watcher stopped")})
+
+					return
+				}
+
+				// nil marks the end of the initial values
+				if entry == nil {
+					continue
+				}
+
+				if !send(&viper.RemoteResponse{Value: entry.Value()}) {
+					return
+				}
+			}
+		}
+	}()
+
+	return resp, quit
+}
+
+func (p *Provider) keyValue(ctx context.Context, endpoint, bucket string) (jetstream.KeyValue, func(), error) {
+	if p.err != nil {
+		return nil, nil, p.err
+	}
+
+	nc, err := nats.Connect(strings.ReplaceAll(endpoint, ";", ","), p.natsOptions...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+
+		return nil, nil, err
+	}
+
+	kv, err := js.KeyValue(ctx, bucket)
+	if err != nil {
+		nc.Close()
+
+		return nil, nil, err
+	}
+
+	return kv, nc.Close, nil
+}
+
+// splitPath splits a "bucket/key" path.
+func splitPath(path string) (string, string, error) {
+	bucket, key, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid path %q: expected bucket/key", path)
+	}
+
+	return bucket, key, nil
+}
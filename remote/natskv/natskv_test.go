@@ -0,0 +1,219 @@
+package natskv
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type remoteProvider struct {
+	path string
+}
+
+func (rp remoteProvider) Provider() string      { return "nats" }
+func (rp remoteProvider) Endpoint() string      { return "nats://127.0.0.1:4222" }
+func (rp remoteProvider) Path() string          { return rp.path }
+func (rp remoteProvider) SecretKeyring() string { return "" }
+
+// fakeKeyValue is an in-memory bucket.
+// Methods that are not implemented panic through the nil embedded interface.
+type fakeKeyValue struct {
+	jetstream.KeyValue
+
+	values  map[string]string
+	updates chan jetstream.KeyValueEntry
+
+	mu      sync.Mutex
+	watched string
+	stopped bool
+}
+
+func (kv *fakeKeyValue) Get(_ context.Context, key string) (jetstream.KeyValueEntry, error) {
+	value, ok := kv.values[key]
+	if !ok {
+		return nil, jetstream.ErrKeyNotFound
+	}
+
+	return fakeEntry{key: key, value: value}, nil
+}
+
+func (kv *fakeKeyValue) ListKeys(_ context.Context, _ ...jetstream.WatchOpt) (jetstream.KeyLister, error) {
+	if len(kv.values) == 0 {
+		return nil, jetstream.ErrNoKeysFound
+	}
+
+	keys := make(chan string, len(kv.values))
+	for key := range kv.values {
+		keys <- key
+	}
+	close(keys)
+
+	return fakeLister{keys: keys}, nil
+}
+
+func (kv *fakeKeyValue) Watch(_ context.Context, key string, _ ...jetstream.WatchOpt) (jetstream.KeyWatcher, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	kv.watched = key
+
+	return fakeWatcher{kv: kv}, nil
+}
+
+func (kv *fakeKeyValue) isStopped() bool {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	return kv.stopped
+}
+
+type fakeEntry struct {
+	jetstream.KeyValueEntry
+
+	key   string
+	value string
+}
+
+func (e fakeEntry) Key() string   { return e.key }
+func (e fakeEntry) Value() []byte { return []byte(e.value) }
+
+type fakeLister struct {
+	keys chan string
+}
+
+func (l fakeLister) Keys() <-chan string { return l.keys }
+func (l fakeLister) Stop() error         { return nil }
+
+type fakeWatcher struct {
+	kv *fakeKeyValue
+}
+
+func (w fakeWatcher) Updates() <-chan jetstream.KeyValueEntry { return w.kv.updates }
+
+func (w fakeWatcher) Stop() error {
+	w.kv.mu.Lock()
+	defer w.kv.mu.Unlock()
+
+	w.kv.stopped = true
+
+	return nil
+}
+
+func newFakeProvider(kv *fakeKeyValue) (*Provider, *[]string) {
+	var buckets []string
+
+	p := New()
+	p.openKeyValue = func(_ context.Context, _, bucket string) (jetstream.KeyValue, func(), error) {
+		buckets = append(buckets, bucket)
+
+		return kv, func() {}, nil
+	}
+
+	return p, &buckets
+}
+
+func TestProvider_Get(t *testing.T) {
+	p, buckets := newFakeProvider(&fakeKeyValue{values: map[string]string{"app.yaml": "foo: bar"}})
+
+	r, err := p.Get(remoteProvider{path: "config/app.yaml"})
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "foo: bar", string(b))
+	assert.Equal(t, []string{"config"}, *buckets)
+
+	_, err = p.Get(remoteProvider{path: "config/missing.yaml"})
+	require.ErrorIs(t, err, jetstream.ErrKeyNotFound)
+
+	_, err = p.Get(remoteProvider{path: "config"})
+	require.Error(t, err)
+}
+
+func TestProvider_List(t *testing.T) {
+	p, _ := newFakeProvider(&fakeKeyValue{values: map[string]string{
+		"app":          "root",
+		"app/database": "host: localhost",
+		"app/server":   "port: 8080",
+		"application":  "other",
+	}})
+
+	kvs, err := p.List(remoteProvider{path: "config/app"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]byte{
+		"config/app":          []byte("root"),
+		"config/app/database": []byte("host: localhost"),
+		"config/app/server":   []byte("port: 8080"),
+	}, kvs)
+
+	t.Run("EmptyBucket", func(t *testing.T) {
+		p, _ := newFakeProvider(&fakeKeyValue{})
+
+		kvs, err := p.List(remoteProvider{path: "config"})
+		require.NoError(t, err)
+		assert.Empty(t, kvs)
+	})
+}
+
+func TestProvider_WatchChannel(t *testing.T) {
+	kv := &fakeKeyValue{updates: make(chan jetstream.KeyValueEntry)}
+
+	p, _ := newFakeProvider(kv)
+
+	resp, quit := p.WatchChannel(remoteProvider{path: "config/app.yaml"})
+
+	// The end of the initial values is skipped
+	kv.updates <- nil
+	kv.updates <- fakeEntry{key: "app.yaml", value: "foo: baz"}
+
+	select {
+	case r := <-resp:
+		require.NoError(t, r.Error)
+		assert.Equal(t, "foo: baz", string(r.Value))
+	case <-time.After(time.Second):
+		t.Fatal("no response received")
+	}
+
+	kv.mu.Lock()
+	assert.Equal(t, "app.yaml", kv.watched)
+	kv.mu.Unlock()
+
+	close(quit)
+
+	require.Eventually(t, kv.isStopped, time.Second, 5*time.Millisecond)
+
+	_, ok := <-resp
+	assert.False(t, ok)
+}
+
+func TestProvider_WatchChannel_WatcherStopped(t *testing.T) {
+	kv := &fakeKeyValue{updates: make(chan jetstream.KeyValueEntry)}
+
+	p, _ := newFakeProvider(kv)
+
+	resp, quit := p.WatchChannel(remoteProvider{path: "config/app.yaml"})
+	defer close(quit)
+
+	close(kv.updates)
+
+	select {
+	case r := <-resp:
+		require.Error(t, r.Error)
+	case <-time.After(time.Second):
+		t.Fatal("no response received")
+	}
+}
+
+func TestWithNKeySeed(t *testing.T) {
+	p := New(WithNKeySeed("testdata/missing.nk"))
+
+	_, err := p.Get(remoteProvider{path: "config/app.yaml"})
+	require.ErrorContains(t, err, "nkey seed")
+}
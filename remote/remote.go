@@ -8,6 +8,7 @@ package remote
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"strings"
@@ -44,10 +45,37 @@ func (rc remoteConfigProvider) Watch(rp viper.RemoteProvider) (io.Reader, error)
 	return bytes.NewReader(resp), nil
 }
 
+func (rc remoteConfigProvider) List(rp viper.RemoteProvider) (map[string][]byte, error) {
+	cm, err := getConfigManager(rp)
+	if err != nil {
+		return nil, err
+	}
+	list, err := cm.List(rp.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	kvs := make(map[string][]byte, len(list))
+	for _, kv := range list {
+		kvs[kv.Key] = kv.Value
+	}
+
+	return kvs, nil
+}
+
 func (rc remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	if rp.Provider() == "firestore" {
+		return firestoreWatchChannel(rp)
+	}
+
 	cm, err := getConfigManager(rp)
 	if err != nil {
-		return nil, nil
+		// report the error through the response channel instead of dropping it
+		errCh := make(chan *viper.RemoteResponse, 1)
+		errCh <- &viper.RemoteResponse{Error: err}
+		close(errCh)
+
+		return errCh, make(chan bool)
 	}
 	quit := make(chan bool)
 	quitwc := make(chan bool)
@@ -60,10 +88,20 @@ func (rc remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *vi
 			case <-quitwc:
 				quit <- true
 				return
-			case resp := <-cr:
-				vr <- &viper.RemoteResponse{
+			case resp, ok := <-cr:
+				if !ok {
+					close(vr)
+					return
+				}
+
+				select {
+				case vr <- &viper.RemoteResponse{
 					Error: resp.Error,
 					Value: resp.Value,
+				}:
+				case <-quitwc:
+					quit <- true
+					return
 				}
 			}
 		}
@@ -76,6 +114,10 @@ func getConfigManager(rp viper.RemoteProvider) (crypt.ConfigManager, error) {
 	var cm crypt.ConfigManager
 	var err error
 
+	if rp.Provider() == "dynamodb" {
+		return nil, errors.New("dynamodb is not supported by crypt: use the github.com/spf13/viper/remote/dynamodb provider")
+	}
+
 	endpoints := strings.Split(rp.Endpoint(), ";")
 	if rp.SecretKeyring() != "" {
 		var kr *os.File
@@ -0,0 +1,121 @@
+package viper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// WithRemoteCache persists the last successfully fetched remote payload of each provider
+// in dir (on the filesystem set with SetFs) and serves it from ReadRemoteConfig
+// when none of the remote providers can be reached.
+//
+// Cached payloads older than ttl are ignored.
+// With WithRemoteSignature, payloads are cached along with their signature and verified again when served. A ttl of zero or less never expires the cache.
+// Use RemoteConfigStale to find out whether the remote configuration was served from the cache.
+func WithRemoteCache(dir string, ttl time.Duration) Option {
+	return optionFunc(func(v *Viper) {
+		v.remoteCacheDir = dir
+		v.remoteCacheTTL = ttl
+	})
+}
+
+// RemoteConfigStale reports whether the remote configuration was served from the cache
+// because the remote providers could not be reached (see WithRemoteCache).
+func RemoteConfigStale() bool { return v.RemoteConfigStale() }
+
+func (v *Viper) RemoteConfigStale() bool {
+	return v.remoteStale
+}
+
+// remoteCacheFile returns the cache file of a remote provider.
+func (v *Viper) remoteCacheFile(rp RemoteProvider) string {
+	sum := sha256.Sum256([]byte(rp.Provider() + "\x00" + rp.Endpoint() + "\x00" + rp.Path()))
+
+	return filepath.Join(v.remoteCacheDir, hex.EncodeToString(sum[:]))
+}
+
+func (v *Viper) writeRemoteCache(rp RemoteProvider, b, signature []byte) {
+	if v.remoteCacheDir == "" {
+		return
+	}
+
+	if err := v.fs.MkdirAll(v.remoteCacheDir, 0o700); err != nil {
+		v.logger.Error(fmt.Errorf("write remote config cache: %w", err).Error())
+
+		return
+	}
+
+	filename := v.remoteCacheFile(rp)
+
+	if err := afero.WriteFile(v.fs, filename, b, 0o600); err != nil {
+		v.logger.Error(fmt.Errorf("write remote config cache: %w", err).Error())
+
+		return
+	}
+
+	if v.remoteVerifier == nil {
+		return
+	}
+
+	if err := afero.WriteFile(v.fs, filename+v.remoteSignatureSuffix, signature, 0o600); err != nil {
+		v.logger.Error(fmt.Errorf("write remote config cache: %w", err).Error())
+	}
+}
+
+func (v *Viper) readRemoteCache(rp *defaultRemoteProvider) (map[string]any, error) {
+	filename := v.remoteCacheFile(rp)
+
+	stat, err := v.fs.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.remoteCacheTTL > 0 && time.Since(stat.ModTime()) > v.remoteCacheTTL {
+		return nil, fmt.Errorf("cached remote config %s expired", filename)
+	}
+
+	b, err := afero.ReadFile(v.fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.remoteVerifier != nil {
+		if rp.prefix {
+			return nil, RemoteConfigError("signature verification is not supported for prefix providers")
+		}
+
+		signature, err := afero.ReadFile(v.fs, filename+v.remoteSignatureSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("read cached remote config signature: %w", err)
+		}
+
+		if err := v.remoteVerifier.Verify(b, signature); err != nil {
+			return nil, fmt.Errorf("verify cached remote config signature: %w", err)
+		}
+	}
+
+	if rp.prefix {
+		var kvs map[string][]byte
+
+		if err := json.Unmarshal(b, &kvs); err != nil {
+			return nil, err
+		}
+
+		return v.setRemoteKeyValues(rp, kvs), nil
+	}
+
+	b, err = v.decryptRemote(rp, b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.applyRemotePayload(rp, b)
+
+	return v.kvstore.load(), err
+}
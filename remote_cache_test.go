@@ -0,0 +1,126 @@
+package viper
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteCache(t *testing.T) {
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"/config/app.json": []byte(`{"foo": "bar"}`),
+	})
+
+	fs := afero.NewMemMapFs()
+
+	newViper := func() *Viper {
+		v := NewWithOptions(WithRemoteCache("/cache", time.Hour))
+		v.SetFs(fs)
+		v.SetConfigType("json")
+		require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json"))
+
+		return v
+	}
+
+	v := newViper()
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, "bar", v.GetString("foo"))
+	assert.False(t, v.RemoteConfigStale())
+
+	fake.err = errors.New("connection refused")
+
+	t.Run("StaleOnError", func(t *testing.T) {
+		v := newViper()
+		require.NoError(t, v.ReadRemoteConfig())
+		assert.Equal(t, "bar", v.GetString("foo"))
+		assert.True(t, v.RemoteConfigStale())
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		v := newViper()
+
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, fs.Chtimes(v.remoteCacheFile(v.remoteProviders[0]), old, old))
+
+		assert.Error(t, v.ReadRemoteConfig())
+		assert.Nil(t, v.Get("foo"))
+	})
+}
+
+func TestRemoteCache_Signature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	payload := []byte(`{"foo": "bar"}`)
+
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"/config/app.json":     payload,
+		"/config/app.json.sig": ed25519.Sign(privateKey, payload),
+	})
+
+	fs := afero.NewMemMapFs()
+
+	newViper := func() *Viper {
+		v := NewWithOptions(WithRemoteCache("/cache", 0), WithRemoteSignature(Ed25519Verifier(publicKey), ""))
+		v.SetFs(fs)
+		v.SetConfigType("json")
+		require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json"))
+
+		return v
+	}
+
+	v := newViper()
+	require.NoError(t, v.ReadRemoteConfig())
+
+	fake.err = errors.New("connection refused")
+
+	v = newViper()
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, "bar", v.GetString("foo"))
+	assert.True(t, v.RemoteConfigStale())
+
+	t.Run("Tampered", func(t *testing.T) {
+		v := newViper()
+		require.NoError(t, afero.WriteFile(fs, v.remoteCacheFile(v.remoteProviders[0]), []byte(`{"foo": "baz"}`), 0o600))
+
+		assert.Error(t, v.ReadRemoteConfig())
+		assert.Nil(t, v.Get("foo"))
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		v := newViper()
+		require.NoError(t, afero.WriteFile(fs, v.remoteCacheFile(v.remoteProviders[0]), payload, 0o600))
+		require.NoError(t, fs.Remove(v.remoteCacheFile(v.remoteProviders[0])+".sig"))
+
+		assert.Error(t, v.ReadRemoteConfig())
+		assert.Nil(t, v.Get("foo"))
+	})
+}
+
+func TestRemoteCache_Prefix(t *testing.T) {
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"myapp/db/host": []byte("localhost"),
+	})
+
+	fs := afero.NewMemMapFs()
+
+	v := NewWithOptions(WithRemoteCache("/cache", 0))
+	v.SetFs(fs)
+	require.NoError(t, v.AddRemoteProviderPrefix("consul", "localhost:8500", "myapp"))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	fake.err = errors.New("connection refused")
+
+	v = NewWithOptions(WithRemoteCache("/cache", 0))
+	v.SetFs(fs)
+	require.NoError(t, v.AddRemoteProviderPrefix("consul", "localhost:8500", "myapp"))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.Equal(t, "localhost", v.GetString("db.host"))
+	assert.True(t, v.RemoteConfigStale())
+}
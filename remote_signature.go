@@ -0,0 +1,115 @@
+package viper
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidRemoteSignature is returned when the signature of a remote configuration payload does not match.
+var ErrInvalidRemoteSignature = errors.New("invalid remote config signature")
+
+// RemoteVerifier verifies the detached signature of a configuration payload read from a remote provider.
+type RemoteVerifier interface {
+	Verify(payload, signature []byte) error
+}
+
+// RemoteVerifierFunc is an adapter to allow the use of ordinary functions as RemoteVerifier.
+type RemoteVerifierFunc func(payload, signature []byte) error
+
+// Verify calls fn(payload, signature).
+func (fn RemoteVerifierFunc) Verify(payload, signature []byte) error {
+	return fn(payload, signature)
+}
+
+// Ed25519Verifier returns a RemoteVerifier checking ed25519 signatures made with the private key of publicKey.
+// Signatures may be stored either raw or base64 encoded.
+func Ed25519Verifier(publicKey ed25519.PublicKey) RemoteVerifier {
+	return RemoteVerifierFunc(func(payload, signature []byte) error {
+		if len(signature) != ed25519.SignatureSize {
+			decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidRemoteSignature, err)
+			}
+
+			signature = decoded
+		}
+
+		if !ed25519.Verify(publicKey, payload, signature) {
+			return ErrInvalidRemoteSignature
+		}
+
+		return nil
+	})
+}
+
+// WithRemoteSignature verifies every payload read from a remote provider with verifier
+// before it is read into the remote configuration registry.
+// The detached signature is read from the same provider, at the path of the configuration
+// followed by suffix (".sig" if empty).
+// Payloads failing verification are rejected.
+// When watching on a channel, the signature is watched as well,
+// so that a payload received before its signature is applied once the signature is updated.
+// Cached payloads (see WithRemoteCache) are stored along with their signature and verified when served.
+//
+// Providers added with AddRemoteProviderPrefix cannot be verified and fail to read.
+func WithRemoteSignature(verifier RemoteVerifier, suffix string) Option {
+	return optionFunc(func(v *Viper) {
+		if suffix == "" {
+			suffix = ".sig"
+		}
+
+		v.remoteVerifier = verifier
+		v.remoteSignatureSuffix = suffix
+	})
+}
+
+// remoteSignatureProvider addresses the detached signature of a remote configuration.
+type remoteSignatureProvider struct {
+	RemoteProvider
+
+	path string
+}
+
+func (rp remoteSignatureProvider) Path() string {
+	return rp.path
+}
+
+// remoteSignature returns the provider of the detached signature of a remote configuration.
+func (v *Viper) remoteSignature(provider RemoteProvider) RemoteProvider {
+	return remoteSignatureProvider{
+		RemoteProvider: provider,
+		path:           provider.Path() + v.remoteSignatureSuffix,
+	}
+}
+
+// verifyRemote verifies a payload read from provider against its detached signature
+// and returns the signature.
+func (v *Viper) verifyRemote(provider RemoteProvider, payload []byte) ([]byte, error) {
+	if v.remoteVerifier == nil {
+		return nil, nil
+	}
+
+	if rp, ok := provider.(*defaultRemoteProvider); ok && rp.prefix {
+		return nil, RemoteConfigError("signature verification is not supported for prefix providers")
+	}
+
+	reader, err := RemoteConfig.Get(v.remoteSignature(provider))
+	if err != nil {
+		return nil, fmt.Errorf("read remote config signature: %w", err)
+	}
+
+	signature, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read remote config signature: %w", err)
+	}
+
+	if err := v.remoteVerifier.Verify(payload, signature); err != nil {
+		return nil, fmt.Errorf("verify remote config signature: %w", err)
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,107 @@
+package viper
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRemoteSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	payload := []byte(`{"foo": "bar"}`)
+	signature := ed25519.Sign(privateKey, payload)
+
+	newViper := func(path string) *Viper {
+		v := NewWithOptions(WithRemoteSignature(Ed25519Verifier(publicKey), ""))
+		v.SetConfigType("json")
+		require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", path))
+
+		return v
+	}
+
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"/config/app.json":          payload,
+		"/config/app.json.sig":      []byte(base64.StdEncoding.EncodeToString(signature)),
+		"/config/raw.json":          payload,
+		"/config/raw.json.sig":      signature,
+		"/config/tampered.json":     []byte(`{"foo": "baz"}`),
+		"/config/tampered.json.sig": signature,
+		"/config/unsigned.json":     payload,
+		"/config/watch.json":        payload,
+		"/config/watch.json.sig":    signature,
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		v := newViper("/config/app.json")
+		require.NoError(t, v.ReadRemoteConfig())
+		assert.Equal(t, "bar", v.GetString("foo"))
+
+		v = newViper("/config/raw.json")
+		require.NoError(t, v.ReadRemoteConfig())
+		assert.Equal(t, "bar", v.GetString("foo"))
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		v := newViper("/config/tampered.json")
+		require.Error(t, v.ReadRemoteConfig())
+		assert.Nil(t, v.Get("foo"))
+
+		_, err := v.getRemoteConfig(v.remoteProviders[0])
+		assert.ErrorIs(t, err, ErrInvalidRemoteSignature)
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		v := newViper("/config/unsigned.json")
+		require.Error(t, v.ReadRemoteConfig())
+		assert.Nil(t, v.Get("foo"))
+	})
+
+	t.Run("WatchSignature", func(t *testing.T) {
+		fake.channels = map[string]chan *RemoteResponse{
+			"/config/watch.json":     make(chan *RemoteResponse),
+			"/config/watch.json.sig": make(chan *RemoteResponse),
+		}
+
+		v := newViper("/config/watch.json")
+		require.NoError(t, v.ReadRemoteConfig())
+
+		events := make(chan RemoteEvent)
+		v.OnRemoteConfigChange(func(e RemoteEvent) {
+			events <- e
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+
+		// The payload is received before its signature is updated
+		updated := []byte(`{"foo": "baz"}`)
+		fake.values["/config/watch.json"] = updated
+		fake.channels["/config/watch.json"] <- &RemoteResponse{Value: updated}
+
+		event := <-events
+		require.ErrorIs(t, event.Error, ErrInvalidRemoteSignature)
+		assert.Equal(t, "bar", v.GetString("foo"))
+
+		newSignature := ed25519.Sign(privateKey, updated)
+		fake.values["/config/watch.json.sig"] = newSignature
+		fake.channels["/config/watch.json.sig"] <- &RemoteResponse{Value: newSignature}
+
+		event = <-events
+		require.NoError(t, event.Error)
+		assert.Equal(t, "baz", v.GetString("foo"))
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		v := NewWithOptions(WithRemoteSignature(Ed25519Verifier(publicKey), ""))
+		require.NoError(t, v.AddRemoteProviderPrefix("consul", "localhost:8500", "config"))
+		require.Error(t, v.ReadRemoteConfig())
+	})
+}
@@ -0,0 +1,127 @@
+package viper
+
+import (
+	"sync"
+	"time"
+)
+
+// RemoteProviderStatus reports the state of a remote provider.
+type RemoteProviderStatus struct {
+	Provider string
+	Endpoint string
+	Path     string
+
+	// LastFetch is the time of the last successful fetch or watch update.
+	LastFetch time.Time
+
+	// LastError is the error reported by the last failed fetch or watch update.
+	// It is cleared by the next successful one.
+	LastError error
+
+	// LastErrorTime is the time LastError was reported.
+	LastErrorTime time.Time
+
+	// Watching reports whether the provider is being watched on a channel.
+	Watching bool
+
+	// Stale reports whether the configuration was served from the cache (see WithRemoteCache).
+	Stale bool
+}
+
+// remoteStatusRegistry tracks the status of remote providers.
+// It is safe for concurrent use, since watchers update it from their own goroutines.
+type remoteStatusRegistry struct {
+	mu       sync.Mutex
+	statuses map[RemoteProvider]*RemoteProviderStatus
+}
+
+func (r *remoteStatusRegistry) update(rp RemoteProvider, fn func(s *RemoteProviderStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.statuses == nil {
+		r.statuses = make(map[RemoteProvider]*RemoteProviderStatus)
+	}
+
+	s, ok := r.statuses[rp]
+	if !ok {
+		s = &RemoteProviderStatus{}
+		r.statuses[rp] = s
+	}
+
+	fn(s)
+}
+
+func (r *remoteStatusRegistry) get(rp RemoteProvider) RemoteProviderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := RemoteProviderStatus{
+		Provider: rp.Provider(),
+		Endpoint: rp.Endpoint(),
+		Path:     rp.Path(),
+	}
+
+	if s, ok := r.statuses[rp]; ok {
+		status.LastFetch = s.LastFetch
+		status.LastError = s.LastError
+		status.LastErrorTime = s.LastErrorTime
+		status.Watching = s.Watching
+		status.Stale = s.Stale
+	}
+
+	return status
+}
+
+// RemoteStatus reports the status of every remote provider, in the order they were added,
+// followed by every remote mount (see AddRemoteMount) and every remote layer (see AddRemoteLayeredProvider).
+// It can be used to implement readiness probes for applications relying on remote configuration.
+func RemoteStatus() []RemoteProviderStatus { return v.RemoteStatus() }
+
+func (v *Viper) RemoteStatus() []RemoteProviderStatus {
+	statuses := make([]RemoteProviderStatus, 0, len(v.remoteProviders)+len(v.remoteMounts)+len(v.remoteLayers))
+
+	for _, rp := range v.remoteProviders {
+		statuses = append(statuses, v.remoteStatus.get(rp))
+	}
+
+	for _, rp := range v.remoteMounts {
+		statuses = append(statuses, v.remoteStatus.get(rp))
+	}
+
+	for _, rp := range v.remoteLayers {
+		statuses = append(statuses, v.remoteStatus.get(rp))
+	}
+
+	return statuses
+}
+
+func (v *Viper) recordRemoteResult(rp RemoteProvider, err error) {
+	now := time.Now()
+
+	v.remoteStatus.update(rp, func(s *RemoteProviderStatus) {
+		if err != nil {
+			s.LastError = err
+			s.LastErrorTime = now
+
+			return
+		}
+
+		s.LastFetch = now
+		s.LastError = nil
+		s.LastErrorTime = time.Time{}
+		s.Stale = false
+	})
+}
+
+func (v *Viper) recordRemoteStale(rp RemoteProvider) {
+	v.remoteStatus.update(rp, func(s *RemoteProviderStatus) {
+		s.Stale = true
+	})
+}
+
+func (v *Viper) recordRemoteWatching(rp RemoteProvider, watching bool) {
+	v.remoteStatus.update(rp, func(s *RemoteProviderStatus) {
+		s.Watching = watching
+	})
+}
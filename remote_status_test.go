@@ -0,0 +1,52 @@
+package viper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteStatus(t *testing.T) {
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"/config/app.json": []byte(`{"foo": "bar"}`),
+	})
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json"))
+
+	status := v.RemoteStatus()
+	require.Len(t, status, 1)
+	assert.Equal(t, "etcd", status[0].Provider)
+	assert.Equal(t, "http://127.0.0.1:4001", status[0].Endpoint)
+	assert.Equal(t, "/config/app.json", status[0].Path)
+	assert.True(t, status[0].LastFetch.IsZero())
+
+	require.NoError(t, v.ReadRemoteConfig())
+
+	status = v.RemoteStatus()
+	assert.False(t, status[0].LastFetch.IsZero())
+	require.NoError(t, status[0].LastError)
+
+	fake.err = errors.New("connection refused")
+	require.Error(t, v.ReadRemoteConfig())
+
+	status = v.RemoteStatus()
+	assert.EqualError(t, status[0].LastError, "connection refused")
+	assert.False(t, status[0].LastErrorTime.IsZero())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+	assert.True(t, v.RemoteStatus()[0].Watching)
+
+	cancel()
+	<-fake.quit
+
+	assert.Eventually(t, func() bool {
+		return !v.RemoteStatus()[0].Watching
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,462 @@
+package viper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRemoteConfig struct {
+	values map[string][]byte
+	err    error
+
+	responses chan *RemoteResponse
+	quit      chan bool
+
+	// channels holds the watch channels of specific paths
+	channels map[string]chan *RemoteResponse
+}
+
+func (f *fakeRemoteConfig) Get(rp RemoteProvider) (io.Reader, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return bytes.NewReader(f.values[rp.Path()]), nil
+}
+
+func (f *fakeRemoteConfig) Watch(rp RemoteProvider) (io.Reader, error) {
+	return f.Get(rp)
+}
+
+func (f *fakeRemoteConfig) WatchChannel(rp RemoteProvider) (<-chan *RemoteResponse, chan bool) {
+	if c, ok := f.channels[rp.Path()]; ok {
+		return c, make(chan bool)
+	}
+
+	return f.responses, f.quit
+}
+
+func (f *fakeRemoteConfig) List(_ RemoteProvider) (map[string][]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return f.values, nil
+}
+
+func withFakeRemoteConfig(t *testing.T, values map[string][]byte) *fakeRemoteConfig {
+	t.Helper()
+
+	orig := RemoteConfig
+	fake := &fakeRemoteConfig{
+		values:    values,
+		responses: make(chan *RemoteResponse),
+		quit:      make(chan bool),
+	}
+	RemoteConfig = fake
+
+	t.Cleanup(func() {
+		RemoteConfig = orig
+	})
+
+	return fake
+}
+
+func TestReadRemoteConfigPrefix(t *testing.T) {
+	withFakeRemoteConfig(t, map[string][]byte{
+		"myapp/":             nil,
+		"myapp/db/host":      []byte("localhost"),
+		"myapp/db/port":      []byte("5432"),
+		"myapp/Log/Level":    []byte("debug"),
+		"myapp2/db/host":     []byte("elsewhere"),
+		"myapp/db/replicas/": nil,
+	})
+
+	v := New()
+	require.NoError(t, v.AddRemoteProviderPrefix("consul", "localhost:8500", "myapp"))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.Equal(t, "localhost", v.GetString("db.host"))
+	assert.Equal(t, 5432, v.GetInt("db.port"))
+	assert.Equal(t, "debug", v.GetString("log.level"))
+	assert.ElementsMatch(t, []string{"db.host", "db.port", "log.level"}, v.AllKeys())
+}
+
+func TestWatchRemoteConfigPrefix(t *testing.T) {
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"myapp/db/host": []byte("localhost"),
+		"myapp/db/port": []byte("5432"),
+	})
+
+	v := New()
+	require.NoError(t, v.AddRemoteProviderPrefix("consul", "localhost:8500", "myapp"))
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, 5432, v.GetInt("db.port"))
+
+	events := make(chan RemoteEvent)
+	v.OnRemoteConfigChange(func(e RemoteEvent) {
+		events <- e
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+
+	// the value of a single key is received: the prefix is listed again
+	fake.values = map[string][]byte{
+		"myapp/db/host":  []byte("db.internal"),
+		"myapp/log/json": []byte("true"),
+	}
+	fake.responses <- &RemoteResponse{Value: []byte("db.internal")}
+
+	event := <-events
+	require.NoError(t, event.Error)
+	assert.Equal(t, "db.internal", v.GetString("db.host"))
+	assert.True(t, v.GetBool("log.json"))
+	assert.False(t, v.IsSet("db.port"), "deleted keys are removed")
+	assert.ElementsMatch(t, []string{"db.host", "log.json"}, v.AllKeys())
+}
+
+func TestAddRemoteMount(t *testing.T) {
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"/config/app.json": []byte(`{"name": "app", "database": {"host": "overridden"}}`),
+		"/app/db":          []byte(`{"host": "localhost", "port": 5432}`),
+		"/app/cache":       []byte(`{"ttl": "1m"}`),
+	})
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("etcd3", "http://127.0.0.1:2379", "/config/app.json"))
+	require.NoError(t, v.AddRemoteMount("etcd3", "http://127.0.0.1:2379", "/app/db", "Database"))
+	require.NoError(t, v.AddRemoteMount("etcd3", "http://127.0.0.1:2379", "/app/cache", "services.cache"))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.Equal(t, "app", v.GetString("name"))
+	assert.Equal(t, "localhost", v.GetString("database.host"))
+	assert.Equal(t, 5432, v.GetInt("database.port"))
+	assert.Equal(t, time.Minute, v.GetDuration("services.cache.ttl"))
+	assert.Len(t, v.RemoteStatus(), 3)
+
+	fake.values["/app/db"] = []byte(`{"host": "db.internal"}`)
+
+	require.NoError(t, v.WatchRemoteConfig())
+	assert.Equal(t, "db.internal", v.GetString("database.host"))
+	assert.Nil(t, v.Get("database.port"), "mount updates replace the whole subtree")
+
+	t.Run("MountsOnly", func(t *testing.T) {
+		v := New()
+		v.SetConfigType("json")
+		require.NoError(t, v.AddRemoteMount("etcd3", "http://127.0.0.1:2379", "/app/db", "database"))
+		require.NoError(t, v.ReadRemoteConfig())
+		assert.Equal(t, "db.internal", v.GetString("database.host"))
+	})
+
+	t.Run("EmptyKey", func(t *testing.T) {
+		assert.Error(t, New().AddRemoteMount("etcd3", "http://127.0.0.1:2379", "/app/db", ""))
+	})
+}
+
+func TestAddRemoteLayeredProvider(t *testing.T) {
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"config/global": []byte(`{"log": {"level": "info", "format": "json"}, "region": "eu"}`),
+		"config/myapp":  []byte(`{"log": {"level": "debug"}, "name": "myapp"}`),
+	})
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteLayeredProvider("consul", "localhost:8500", []string{"config/global", "config/myapp"}))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.Equal(t, "debug", v.GetString("log.level"))
+	assert.Equal(t, "json", v.GetString("log.format"))
+	assert.Equal(t, "eu", v.GetString("region"))
+	assert.Equal(t, "myapp", v.GetString("name"))
+	assert.Len(t, v.RemoteStatus(), 2)
+
+	// Keys removed from a layer are removed from the merged configuration
+	fake.values["config/global"] = []byte(`{"log": {"level": "info"}}`)
+
+	require.NoError(t, v.WatchRemoteConfig())
+	assert.Equal(t, "debug", v.GetString("log.level"))
+	assert.Nil(t, v.Get("log.format"))
+	assert.Nil(t, v.Get("region"))
+
+	// A broken layer is reported and keeps its last known configuration
+	fake.values["config/myapp"] = []byte(`{`)
+
+	err := v.ReadRemoteConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `remote layer "config/myapp"`)
+	assert.Equal(t, "myapp", v.GetString("name"))
+}
+
+func TestAddRemoteLayeredProvider_MergeConflictHandler(t *testing.T) {
+	withFakeRemoteConfig(t, map[string][]byte{
+		"config/global": []byte(`{"log": {"level": "info"}}`),
+		"config/myapp":  []byte(`{"log": {"level": "debug"}}`),
+	})
+
+	v := NewWithOptions(WithMergeConflictHandler(func(_ string, oldV, _ any) any {
+		t.Error("merge conflict handler called while merging remote layers")
+
+		return oldV
+	}))
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteLayeredProvider("consul", "localhost:8500", []string{"config/global", "config/myapp"}))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.Equal(t, "debug", v.GetString("log.level"))
+}
+
+func TestAddRemoteLayeredProvider_ConcurrentWatch(t *testing.T) {
+	fake := withFakeRemoteConfig(t, map[string][]byte{
+		"config/global": []byte(`{"region": "eu"}`),
+		"config/myapp":  []byte(`{"name": "myapp"}`),
+	})
+	fake.channels = map[string]chan *RemoteResponse{
+		"config/global": make(chan *RemoteResponse),
+		"config/myapp":  make(chan *RemoteResponse),
+	}
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteLayeredProvider("consul", "localhost:8500", []string{"config/global", "config/myapp"}))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	events := make(chan RemoteEvent)
+	v.OnRemoteConfigChange(func(e RemoteEvent) {
+		events <- e
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+
+	// Both layers are updated at the same time, run with -race to detect unsynchronized merges
+	const updates = 20
+
+	for path, c := range fake.channels {
+		go func(c chan *RemoteResponse, key string) {
+			for i := 0; i < updates; i++ {
+				c <- &RemoteResponse{Value: []byte(fmt.Sprintf(`{%q: %d}`, key, i))}
+			}
+		}(c, path[len("config/"):])
+	}
+
+	for i := 0; i < 2*updates; i++ {
+		require.NoError(t, (<-events).Error)
+	}
+
+	assert.Equal(t, updates-1, v.GetInt("global"))
+	assert.Equal(t, updates-1, v.GetInt("myapp"))
+}
+
+// reverseDecrypter "decrypts" payloads by reversing them.
+var reverseDecrypter = RemoteDecrypterFunc(func(ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, []byte("enc:")) {
+		return nil, errors.New("not encrypted")
+	}
+
+	plaintext := bytes.Clone(ciphertext[len("enc:"):])
+	slices.Reverse(plaintext)
+
+	return plaintext, nil
+})
+
+func TestAddDecryptedRemoteProvider(t *testing.T) {
+	t.Run("Get", func(t *testing.T) {
+		withFakeRemoteConfig(t, map[string][]byte{
+			"/config/app.json": []byte(`enc:}"rab" :"oof"{`),
+			"/config/raw.json": []byte(`{"foo": "bar"}`),
+		})
+
+		v := New()
+		v.SetConfigType("json")
+		require.NoError(t, v.AddDecryptedRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json", reverseDecrypter))
+		require.NoError(t, v.ReadRemoteConfig())
+		assert.Equal(t, "bar", v.GetString("foo"))
+
+		v = New()
+		v.SetConfigType("json")
+		require.NoError(t, v.AddDecryptedRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/raw.json", reverseDecrypter))
+		assert.Error(t, v.ReadRemoteConfig())
+	})
+
+	t.Run("WatchChannel", func(t *testing.T) {
+		fake := withFakeRemoteConfig(t, nil)
+
+		v := New()
+		v.SetConfigType("json")
+		require.NoError(t, v.AddDecryptedRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json", reverseDecrypter))
+
+		events := make(chan RemoteEvent)
+		v.OnRemoteConfigChange(func(e RemoteEvent) {
+			events <- e
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+
+		fake.responses <- &RemoteResponse{Value: []byte(`enc:}"rab" :"oof"{`)}
+
+		event := <-events
+		require.NoError(t, event.Error)
+		assert.Equal(t, "bar", v.GetString("foo"))
+	})
+}
+
+func TestWatchRemoteConfigOnChannelContext(t *testing.T) {
+	fake := withFakeRemoteConfig(t, nil)
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json"))
+
+	events := make(chan RemoteEvent)
+	v.OnRemoteConfigChange(func(e RemoteEvent) {
+		events <- e
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+
+	fake.responses <- &RemoteResponse{Value: []byte(`{"foo": "bar"}`)}
+
+	event := <-events
+	require.NoError(t, event.Error)
+	assert.Equal(t, "etcd", event.Provider.Provider())
+	assert.Equal(t, "bar", v.GetString("foo"))
+
+	fake.responses <- &RemoteResponse{Error: errors.New("connection lost")}
+
+	event = <-events
+	assert.EqualError(t, event.Error, "connection lost")
+	assert.Equal(t, "bar", v.GetString("foo"))
+
+	cancel()
+
+	_, ok := <-fake.quit
+	assert.False(t, ok, "quit channel should be closed")
+}
+
+func TestWatchRemoteConfigOnChannelDebounce(t *testing.T) {
+	fake := withFakeRemoteConfig(t, nil)
+
+	v := NewWithOptions(WithRemoteWatchDebounce(50 * time.Millisecond))
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json"))
+
+	events := make(chan RemoteEvent, 10)
+	v.OnRemoteConfigChange(func(e RemoteEvent) {
+		events <- e
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+
+	fake.responses <- &RemoteResponse{Value: []byte(`{"foo": "1"}`)}
+	fake.responses <- &RemoteResponse{Error: errors.New("timeout")}
+	fake.responses <- &RemoteResponse{Value: []byte(`{"foo": "2"}`)}
+	fake.responses <- &RemoteResponse{Value: []byte(`{"foo": "3"}`)}
+
+	event := <-events
+	assert.EqualError(t, event.Error, "timeout")
+
+	event = <-events
+	require.NoError(t, event.Error)
+	assert.Equal(t, `{"foo": "3"}`, string(event.Value))
+	assert.Equal(t, "3", v.GetString("foo"))
+
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event: %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAutoRemoteFromEnv(t *testing.T) {
+	t.Run("NotConfigured", func(t *testing.T) {
+		v := New()
+		require.NoError(t, v.AutoRemoteFromEnv())
+		assert.Empty(t, v.remoteProviders)
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		t.Setenv("VIPER_REMOTE_PROVIDER", "etcd3")
+		t.Setenv("VIPER_REMOTE_ENDPOINT", "http://127.0.0.1:2379")
+		t.Setenv("VIPER_REMOTE_PATH", "/config/app.yaml")
+		t.Setenv("VIPER_REMOTE_CONFIG_TYPE", "yaml")
+
+		v := New()
+		require.NoError(t, v.AutoRemoteFromEnv())
+		require.Len(t, v.remoteProviders, 1)
+
+		rp := v.remoteProviders[0]
+		assert.Equal(t, "etcd3", rp.Provider())
+		assert.Equal(t, "http://127.0.0.1:2379", rp.Endpoint())
+		assert.Equal(t, "/config/app.yaml", rp.Path())
+		assert.False(t, rp.prefix)
+		assert.Equal(t, "yaml", v.getConfigType())
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		t.Setenv("VIPER_REMOTE_PROVIDER", "consul")
+		t.Setenv("VIPER_REMOTE_ENDPOINT", "127.0.0.1:8500")
+		t.Setenv("VIPER_REMOTE_PATH", "myapp")
+		t.Setenv("VIPER_REMOTE_PREFIX", "true")
+
+		v := New()
+		require.NoError(t, v.AutoRemoteFromEnv())
+		require.Len(t, v.remoteProviders, 1)
+		assert.True(t, v.remoteProviders[0].prefix)
+	})
+
+	t.Run("EnvLookup", func(t *testing.T) {
+		env := map[string]string{
+			"VIPER_REMOTE_PROVIDER": "etcd3",
+			"VIPER_REMOTE_ENDPOINT": "http://127.0.0.1:2379",
+			"VIPER_REMOTE_PATH":     "/config/app.yaml",
+		}
+
+		v := NewWithOptions(WithEnvLookup(func(key string) (string, bool) {
+			val, ok := env[key]
+			return val, ok
+		}))
+		v.SetConfigType("toml")
+
+		require.NoError(t, v.AutoRemoteFromEnv())
+		require.Len(t, v.remoteProviders, 1)
+		assert.Equal(t, "etcd3", v.remoteProviders[0].Provider())
+		assert.Equal(t, "toml", v.getConfigType(), "the config type is kept when not set")
+	})
+
+	t.Run("MissingEndpoint", func(t *testing.T) {
+		t.Setenv("VIPER_REMOTE_PROVIDER", "consul")
+
+		v := New()
+		assert.Error(t, v.AutoRemoteFromEnv())
+	})
+
+	t.Run("UnsupportedProvider", func(t *testing.T) {
+		t.Setenv("VIPER_REMOTE_PROVIDER", "zookeeper")
+		t.Setenv("VIPER_REMOTE_ENDPOINT", "127.0.0.1:2181")
+
+		v := New()
+		assert.ErrorAs(t, v.AutoRemoteFromEnv(), new(UnsupportedRemoteProviderError))
+	})
+}
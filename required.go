@@ -0,0 +1,56 @@
+package viper
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// isRequiredField reports whether a field is tagged as required,
+// with `required:"true"` or `viper:"required"`.
+func isRequiredField(field reflect.StructField) bool {
+	if required, err := strconv.ParseBool(field.Tag.Get("required")); err == nil && required {
+		return true
+	}
+
+	return slices.Contains(strings.Split(field.Tag.Get("viper"), ","), "required")
+}
+
+// checkRequired returns a ValidationErrors listing the required fields of the struct decoded by config
+// whose keys hold no value in input, the value of the key prefix (or of the whole configuration if empty).
+func (v *Viper) checkRequired(input any, config *mapstructure.DecoderConfig, prefix string) error {
+	typ := reflect.TypeOf(config.Result)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	tagName := config.TagName
+	if tagName == "" {
+		tagName = "mapstructure"
+	}
+
+	m, _ := input.(map[string]any)
+
+	var errs ValidationErrors
+
+	v.walkStructFields(typ, tagName, config.Squash, "", nil, func(key string, field reflect.StructField) {
+		if !isRequiredField(field) || searchFold(m, strings.Split(key, v.keyDelim)) != nil {
+			return
+		}
+
+		errs = append(errs, FieldError{Key: joinKey(prefix, strings.ReplaceAll(key, v.keyDelim, ".")), Rule: "required"})
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
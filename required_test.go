@@ -0,0 +1,42 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredFields(t *testing.T) {
+	type database struct {
+		Host     string `viper:"required"`
+		Password string `mapstructure:"password" required:"true"`
+		Port     int
+	}
+
+	type config struct {
+		APIKey string `mapstructure:"api_key" required:"true"`
+		Debug  bool   `required:"false"`
+		DB     database
+	}
+
+	v := New()
+	v.Set("db.host", "localhost")
+
+	err := v.Unmarshal(&config{})
+	assert.EqualError(t, err, "api_key is required; db.password is required")
+
+	var errs ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, ValidationErrors{
+		{Key: "api_key", Rule: "required"},
+		{Key: "db.password", Rule: "required"},
+	}, errs)
+
+	assert.EqualError(t, v.UnmarshalKey("db", &database{}), "db.password is required")
+	assert.EqualError(t, v.UnmarshalExact(&config{}), "api_key is required; db.password is required")
+
+	v.Set("api_key", "secret")
+	v.Set("db.password", "")
+	require.NoError(t, v.Unmarshal(&config{}), "empty values are set")
+}
@@ -0,0 +1,60 @@
+package viper
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// Secret is a string holding a password or another sensitive value.
+//
+// It is decoded by Unmarshal like any string, but it is printed as RedactedValue by the fmt verbs,
+// by log/slog and in decode errors, so that secrets read from the configuration do not leak into logs by accident.
+// Use Value to get the secret itself.
+//
+// Secrets passed to Set, SetDefault or Load are stored as plain strings,
+// and their keys are marked as secret (see SetKeyVisibility):
+// they are redacted by Export and Debug, but written as is by WriteConfig.
+type Secret string
+
+// Value returns the secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns RedactedValue.
+func (s Secret) String() string {
+	return RedactedValue
+}
+
+// GoString returns RedactedValue.
+func (s Secret) GoString() string {
+	return RedactedValue
+}
+
+// LogValue returns RedactedValue.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(RedactedValue)
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// isSecretType reports whether values of a type are secrets.
+func isSecretType(typ reflect.Type) bool {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return typ == secretType
+}
+
+// setSecretKeys marks the keys of the secrets stored at key as secret.
+func (v *Viper) setSecretKeys(key string, secrets [][]string) {
+	for _, path := range secrets {
+		if key != "" {
+			path = append([]string{key}, path...)
+		}
+
+		v.SetKeyVisibility(strings.Join(path, v.keyDelim), VisibilitySecret)
+	}
+}
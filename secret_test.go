@@ -0,0 +1,106 @@
+package viper
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	v := New()
+	v.Set("db.user", "admin")
+	v.Set("db.password", "hunter2")
+	v.Set("db.options", map[string]any{"token": "abc"})
+
+	var config struct {
+		DB struct {
+			User     string
+			Password Secret
+		}
+	}
+	require.NoError(t, v.Unmarshal(&config))
+
+	password := config.DB.Password
+	assert.Equal(t, "hunter2", password.Value())
+	assert.Equal(t, RedactedValue, fmt.Sprint(password))
+	assert.Equal(t, "[REDACTED] [REDACTED] [REDACTED]", fmt.Sprintf("%s %v %#v", password, password, password))
+	assert.NotContains(t, fmt.Sprintf("%+v %#v", config, config), "hunter2")
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("connecting", "password", password)
+	assert.NotContains(t, buf.String(), "hunter2")
+
+	// Secrets are stored as plain strings and their keys are marked as secret
+	v.Set("db.password", password)
+	assert.Equal(t, "hunter2", v.Get("db.password"))
+	assert.Equal(t, VisibilitySecret, v.KeyVisibility("db.password"))
+
+	var debug bytes.Buffer
+	v.DebugTo(&debug)
+	assert.NotContains(t, debug.String(), "hunter2")
+
+	v.SetKeyVisibility("db.options.token", VisibilitySecret)
+	assert.Equal(t, map[string]any{
+		"db": map[string]any{
+			"user":     "admin",
+			"password": RedactedValue,
+			"options":  map[string]any{"token": RedactedValue},
+		},
+	}, v.Export(ForSupportBundle))
+
+	v.Set("db.password", []string{"hunter2"})
+	err := v.Unmarshal(&config)
+
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, RedactedValue, decodeErr.Fields[0].Value)
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestSecret_Load(t *testing.T) {
+	type config struct {
+		User     string `mapstructure:"user"`
+		Password Secret `mapstructure:"password"`
+		Tokens   map[string]Secret
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			v := New()
+			require.NoError(t, v.Load(config{
+				User:     "admin",
+				Password: "hunter2",
+				Tokens:   map[string]Secret{"api": "abc"},
+			}))
+
+			assert.Equal(t, "hunter2", v.GetString("password"))
+			assert.Equal(t, "abc", v.GetString("tokens.api"))
+			assert.Equal(t, VisibilitySecret, v.KeyVisibility("password"))
+			assert.Equal(t, VisibilitySecret, v.KeyVisibility("tokens.api"))
+			assert.Equal(t, VisibilityPublic, v.KeyVisibility("user"))
+
+			fs := afero.NewMemMapFs()
+			v.SetFs(fs)
+			require.NoError(t, v.WriteConfigAs("/config."+format))
+
+			b, err := afero.ReadFile(fs, "/config."+format)
+			require.NoError(t, err)
+			assert.Contains(t, string(b), "hunter2")
+			assert.NotContains(t, string(b), RedactedValue)
+
+			written := New()
+			written.SetConfigType(format)
+			require.NoError(t, written.ReadConfig(bytes.NewReader(b)))
+
+			var c config
+			require.NoError(t, written.Unmarshal(&c))
+			assert.Equal(t, Secret("hunter2"), c.Password)
+			assert.Equal(t, Secret("abc"), c.Tokens["api"])
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package viper
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// SizeInBytes is a size in bytes decoded by Unmarshal from numbers or from strings with a unit,
+// eg. "512", "10kb", "1.5 GiB" or "2TB".
+//
+// Units are case-insensitive: B, KB, MB, GB, TB and PB are decimal (powers of 1000),
+// KiB, MiB, GiB, TiB and PiB are binary (powers of 1024).
+// Note that GetSizeInBytes reads KB, MB and GB as binary units, for compatibility.
+type SizeInBytes uint64
+
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// UnmarshalText parses a size with an optional unit.
+func (s *SizeInBytes) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(str)
+	}
+
+	number, unit := str[:i], strings.ToLower(strings.TrimSpace(str[i:]))
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return fmt.Errorf("invalid size %q: unknown unit %q", str, unit)
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size %q: %w", str, err)
+	}
+
+	size := n * multiplier
+	if size > math.MaxUint64 {
+		return fmt.Errorf("invalid size %q: out of range", str)
+	}
+
+	*s = SizeInBytes(size)
+
+	return nil
+}
+
+// SizeInBytesHookFunc returns a decode hook parsing strings into SizeInBytes values.
+// It is part of the default decode hook; add it to custom decode hooks (see WithDecodeHook) to keep decoding sizes.
+func SizeInBytesHookFunc() mapstructure.DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(SizeInBytes(0)) {
+			return data, nil
+		}
+
+		var size SizeInBytes
+		if err := size.UnmarshalText([]byte(reflect.ValueOf(data).String())); err != nil {
+			return nil, err
+		}
+
+		return size, nil
+	}
+}
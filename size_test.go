@@ -0,0 +1,53 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeInBytes_UnmarshalText(t *testing.T) {
+	tests := map[string]SizeInBytes{
+		"512":     512,
+		"512b":    512,
+		"10kb":    10_000,
+		"10 KiB":  10 << 10,
+		"1.5 GiB": 3 << 29,
+		"2TB":     2e12,
+		" 3MB ":   3e6,
+		"1PiB":    1 << 50,
+	}
+
+	for input, expected := range tests {
+		var size SizeInBytes
+		require.NoError(t, size.UnmarshalText([]byte(input)), input)
+		assert.Equal(t, expected, size, input)
+	}
+
+	for _, input := range []string{"", "ten", "10 xb", "-1kb", "1.2.3mb"} {
+		var size SizeInBytes
+		assert.Error(t, size.UnmarshalText([]byte(input)), input)
+	}
+}
+
+func TestUnmarshalSizeInBytes(t *testing.T) {
+	v := New()
+	v.Set("cache", "10gb")
+	v.Set("buffer", "64KiB")
+	v.Set("limit", 1024)
+
+	var config struct {
+		Cache  SizeInBytes
+		Buffer SizeInBytes
+		Limit  SizeInBytes
+	}
+
+	require.NoError(t, v.Unmarshal(&config))
+	assert.Equal(t, SizeInBytes(10e9), config.Cache)
+	assert.Equal(t, SizeInBytes(64<<10), config.Buffer)
+	assert.Equal(t, SizeInBytes(1024), config.Limit)
+
+	v.Set("cache", "lots")
+	require.Error(t, v.Unmarshal(&config))
+}
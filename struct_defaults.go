@@ -0,0 +1,132 @@
+package viper
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// WithStructDefaults makes Unmarshal and UnmarshalKey read default values from the "default" tag
+// of struct fields when no source provides their key, eg. to keep defaults next to the field definitions:
+//
+//	type Config struct {
+//		Port  int      `default:"8080"`
+//		Hosts []string `default:"a,b"` // lists are comma separated
+//	}
+//
+// Defaults are converted to the type of the field like values of keys typed with SetKeyType.
+// They apply to nested structs and to the elements of slices of structs.
+func WithStructDefaults() Option {
+	return optionFunc(func(v *Viper) {
+		v.structDefaults = true
+	})
+}
+
+// structDefaultsHookFunc returns a decode hook adding the default values of the fields of structs
+// missing from the maps they are decoded from (see WithStructDefaults).
+// The tag name and squashing of embedded structs are read from config when the hook runs.
+func (v *Viper) structDefaultsHookFunc(config *mapstructure.DecoderConfig) mapstructure.DecodeHookFuncType {
+	return func(_ reflect.Type, t reflect.Type, data any) (any, error) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		input, ok := data.(map[string]any)
+		if !ok || t.Kind() != reflect.Struct {
+			return data, nil
+		}
+
+		tagName := config.TagName
+		if tagName == "" {
+			tagName = "mapstructure"
+		}
+
+		var out map[string]any
+
+		v.walkStructFields(t, tagName, config.Squash, "", nil, func(key string, field reflect.StructField) {
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				return
+			}
+
+			if out == nil {
+				out = deepCopyMap(input)
+			}
+
+			setMissingValue(out, strings.Split(key, v.keyDelim), func() any {
+				return v.structDefaultValue(field.Type, def)
+			})
+		})
+
+		if out == nil {
+			return data, nil
+		}
+
+		return out, nil
+	}
+}
+
+// structDefaultValue converts the default value of a field to the type of the field.
+func (v *Viper) structDefaultValue(typ reflect.Type, def string) any {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8 {
+		list, err := readAsCSV(def)
+		if err != nil {
+			return def
+		}
+
+		return v.castByType(list, reflect.Zero(typ).Interface())
+	}
+
+	return v.castByType(def, reflect.Zero(typ).Interface())
+}
+
+// setMissingValue sets the value of a path in nested maps to the result of val,
+// unless the path already holds a value (matching keys case-insensitively) or is shadowed by a value of a parent.
+func setMissingValue(m map[string]any, path []string, val func() any) {
+	for i, k := range path {
+		existing, ok := lookupFold(m, k)
+
+		if i == len(path)-1 {
+			if !ok {
+				m[k] = val()
+			}
+
+			return
+		}
+
+		if !ok {
+			next := make(map[string]any)
+			m[k] = next
+			m = next
+
+			continue
+		}
+
+		next, isMap := existing.(map[string]any)
+		if !isMap {
+			return
+		}
+
+		m = next
+	}
+}
+
+// lookupFold returns the value of a key of a map, matching keys case-insensitively.
+func lookupFold(m map[string]any, key string) (any, bool) {
+	if val, ok := m[key]; ok {
+		return val, true
+	}
+
+	for k, val := range m {
+		if strings.EqualFold(k, key) {
+			return val, true
+		}
+	}
+
+	return nil, false
+}
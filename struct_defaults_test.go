@@ -0,0 +1,74 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type structDefaultsConfig struct {
+	Port     int           `default:"8080"`
+	Host     string        `default:"localhost"`
+	Timeout  time.Duration `default:"5s"`
+	Ports    []int         `mapstructure:"extra_ports" default:"80,443"`
+	Database struct {
+		Name string `default:"app"`
+		Pool *struct {
+			Size uint `default:"10"`
+		}
+	}
+	Servers []struct {
+		Name   string
+		Weight float64 `default:"1.5"`
+	}
+}
+
+func TestWithStructDefaults(t *testing.T) {
+	v := NewWithOptions(WithStructDefaults(), WithStrictDecoding())
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+host: example.com
+database:
+  name: ""
+servers:
+  - name: a
+  - name: b
+    weight: 2
+`)))
+
+	var config structDefaultsConfig
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, 8080, config.Port)
+	assert.Equal(t, "example.com", config.Host)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, []int{80, 443}, config.Ports)
+	assert.Equal(t, "", config.Database.Name, "values set by a source are kept, even when empty")
+	require.NotNil(t, config.Database.Pool)
+	assert.Equal(t, uint(10), config.Database.Pool.Size)
+	require.Len(t, config.Servers, 2)
+	assert.Equal(t, 1.5, config.Servers[0].Weight)
+	assert.Equal(t, 2.0, config.Servers[1].Weight)
+
+	assert.Nil(t, v.Get("port"), "struct defaults do not affect Get")
+
+	var database struct {
+		Name string `default:"app"`
+		User string `default:"root"`
+	}
+	require.NoError(t, v.UnmarshalKey("database", &database))
+	assert.Equal(t, "root", database.User)
+}
+
+func TestWithoutStructDefaults(t *testing.T) {
+	v := New()
+
+	var config structDefaultsConfig
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Zero(t, config.Port)
+	assert.Nil(t, config.Database.Pool)
+}
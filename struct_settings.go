@@ -0,0 +1,173 @@
+package viper
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/cast"
+)
+
+// structSettings returns the settings Unmarshal decodes into the struct of config, resolving only
+// the keys under the fields the struct declares instead of every key of the configuration (see AllSettings).
+// Fields of recursive types are resolved with every key under them.
+//
+// It returns false when the result could differ from the one of AllSettings:
+// when the unused keys are reported (see UnmarshalWithMetadata), when keys are matched
+// with a custom function or case-sensitively, and for structs with ",remain" fields.
+func (v *Viper) structSettings(config *mapstructure.DecoderConfig) (map[string]any, bool) {
+	if config.Metadata != nil || config.ErrorUnused || config.MatchName != nil || v.caseSensitiveConfig {
+		return nil, false
+	}
+
+	typ := reflect.TypeOf(config.Result)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	tagName := config.TagName
+	if tagName == "" {
+		tagName = "mapstructure"
+	}
+
+	if hasRemainField(typ, tagName, nil) {
+		return nil, false
+	}
+
+	sources := v.keySources()
+
+	seen := make(map[string]bool)
+	var keys []string
+
+	add := func(k string) {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	v.walkStructTree(typ, tagName, config.Squash, "", nil, func(key string, _ reflect.StructField, _ bool) {
+		// keys of structs are resolved like by decodeStructKeys
+		if v.experimentalBindStruct {
+			add(key)
+		}
+
+		// parent keys holding a value shadow the field, eg. when "db" is set to a string
+		for _, k := range v.keysUnder(sources, key) {
+			add(k)
+		}
+	})
+
+	return v.getSettings(keys), true
+}
+
+// keySource is a configuration source of the keys listed by AllKeys:
+// a nested map, or a flat map of keys.
+type keySource struct {
+	m    map[string]any
+	flat bool
+}
+
+// keySources returns the configuration sources in the order AllKeys merges their keys.
+func (v *Viper) keySources() []keySource {
+	return []keySource{
+		{m: castMapStringToMapInterface(v.aliases), flat: true},
+		{m: v.facts},
+		{m: v.override},
+		{m: v.flagKeys(), flat: true},
+		{m: castMapStringSliceToMapInterface(v.env), flat: true},
+		{m: v.prefixBoundEnvKeys(), flat: true},
+		{m: v.config.load()},
+		{m: v.kvstore.load()},
+		{m: v.defaults},
+	}
+}
+
+// keysUnder returns the keys of sources holding a value at key, under key or at a parent of key,
+// the way AllKeys lists them, without flattening the other keys of the sources.
+func (v *Viper) keysUnder(sources []keySource, key string) []string {
+	path := strings.Split(key, v.keyDelim)
+	shadow := make(map[string]bool)
+
+	for _, source := range sources {
+		if source.flat {
+			shadow = v.mergeFlatMap(shadow, v.flatKeysAlong(source.m, key))
+		} else {
+			shadow = v.flattenAndMergeMap(shadow, pathMap(source.m, path), "")
+		}
+	}
+
+	return keySetToList(shadow)
+}
+
+// flatKeysAlong returns the entries of a flat map of keys at key, under key or at a parent of key.
+func (v *Viper) flatKeysAlong(m map[string]any, key string) map[string]any {
+	along := make(map[string]any)
+
+	for k, val := range m {
+		lk := strings.ToLower(k)
+		if lk == key || strings.HasPrefix(lk, key+v.keyDelim) || strings.HasPrefix(key, lk+v.keyDelim) {
+			along[k] = val
+		}
+	}
+
+	return along
+}
+
+// pathMap returns the part of a nested map along path: the value at path,
+// or the value at a parent of path that is not a map.
+func pathMap(m map[string]any, path []string) map[string]any {
+	val, ok := m[path[0]]
+	if !ok {
+		return nil
+	}
+
+	if len(path) > 1 {
+		switch next := val.(type) {
+		case map[string]any:
+			val = pathMap(next, path[1:])
+		case map[any]any:
+			val = pathMap(cast.ToStringMap(next), path[1:])
+		}
+
+		if val == nil {
+			return nil
+		}
+	}
+
+	return map[string]any{path[0]: val}
+}
+
+// hasRemainField reports whether a struct type or its nested structs have a ",remain" field.
+func hasRemainField(typ reflect.Type, tagName string, parents []reflect.Type) bool {
+	if slices.Contains(parents, typ) {
+		return false
+	}
+
+	parents = append(parents, typ)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		_, options, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if slices.Contains(strings.Split(options, ","), "remain") {
+			return true
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && hasRemainField(fieldType, tagName, parents) {
+			return true
+		}
+	}
+
+	return false
+}
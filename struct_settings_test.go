@@ -0,0 +1,100 @@
+package viper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalStructKeys(t *testing.T) {
+	type config struct {
+		Name   string
+		Labels map[string]string
+		Hosts  []string
+		DB     struct {
+			Host string
+			Port int
+		}
+		Endpoint any
+	}
+
+	v := New()
+	v.SetDefault("name", "app")
+	v.SetDefault("labels.team", "core")
+	v.Set("labels.env", "prod")
+	v.Set("hosts", []string{"a", "b"})
+	v.Set("db.host", "localhost")
+	v.Set("endpoint.url", "http://localhost")
+	v.Set("unrelated.key", "value")
+
+	settings, ok := v.structSettings(v.defaultDecoderConfig(&config{}))
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{
+		"name":     "app",
+		"labels":   map[string]any{"team": "core", "env": "prod"},
+		"hosts":    []string{"a", "b"},
+		"db":       map[string]any{"host": "localhost"},
+		"endpoint": map[string]any{"url": "http://localhost"},
+	}, settings)
+
+	var c config
+	require.NoError(t, v.Unmarshal(&c))
+	assert.Equal(t, map[string]string{"team": "core", "env": "prod"}, c.Labels)
+	assert.Equal(t, "localhost", c.DB.Host)
+
+	_, ok = v.structSettings(v.defaultDecoderConfig(&struct {
+		Name  string
+		Other map[string]any `mapstructure:",remain"`
+	}{}))
+	assert.False(t, ok, "remain fields need every key")
+
+	_, ok = v.structSettings(v.defaultDecoderConfig(&map[string]any{}))
+	assert.False(t, ok)
+
+	v.Set("db", "postgres://localhost")
+	err := v.Unmarshal(&c)
+	require.Error(t, err, "parent keys shadowing the struct are decoded")
+}
+
+func TestUnmarshalStructKeys_Recursive(t *testing.T) {
+	type probeNode struct {
+		Name string
+		Next *probeNode
+	}
+
+	t.Setenv("PROBE_NEXT_NEXT_NAME", "c")
+
+	v := New()
+	v.Set("name", "a")
+	v.SetDefault("next.name", "b")
+	require.NoError(t, v.BindEnv("next.next.name", "PROBE_NEXT_NEXT_NAME"))
+
+	var node probeNode
+	require.NoError(t, v.Unmarshal(&node))
+	assert.Equal(t, "a", node.Name)
+	require.NotNil(t, node.Next)
+	assert.Equal(t, "b", node.Next.Name)
+	require.NotNil(t, node.Next.Next)
+	assert.Equal(t, "c", node.Next.Next.Name)
+	assert.Nil(t, node.Next.Next.Next)
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	v := New()
+	for i := 0; i < 1000; i++ {
+		v.Set(fmt.Sprintf("section%d.key", i), i)
+	}
+	v.Set("name", "app")
+
+	var config struct {
+		Name string
+	}
+
+	for i := 0; i < b.N; i++ {
+		if err := v.Unmarshal(&config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
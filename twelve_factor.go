@@ -0,0 +1,116 @@
+package viper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/subosito/gotenv"
+)
+
+// NewTwelveFactor creates a new Viper instance configured from the environment,
+// following the twelve-factor app methodology (https://12factor.net/config).
+//
+// The returned instance:
+//
+//   - reads environment variables prefixed with the upper-cased app name (eg. MYAPP_DB_HOST for "db.host")
+//   - automatically binds them to every key (see AutomaticEnv)
+//   - considers set, but empty environment variables as valid values (see AllowEmptyEnv)
+//   - does not require a config file: ReadInConfig succeeds when none is found (see WithOptionalConfigFile)
+//
+// Options are applied afterwards, so they can override the preset
+// (eg. WithDotenv to load .env files, or EnvKeyReplacer to map more characters).
+// The usual setters can also be called on the returned instance.
+func NewTwelveFactor(appName string, opts ...Option) *Viper {
+	v := New()
+
+	v.SetEnvPrefix(appName)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AllowEmptyEnv(true)
+	v.AutomaticEnv()
+	v.configFileOptional = true
+
+	for _, opt := range opts {
+		opt.apply(v)
+	}
+
+	return v
+}
+
+// WithOptionalConfigFile makes ReadInConfig succeed without reading anything
+// when no config file is found.
+func WithOptionalConfigFile() Option {
+	return optionFunc(func(v *Viper) {
+		v.configFileOptional = true
+	})
+}
+
+// WithDotenv loads variables from .env files into the environment layer.
+//
+// Variables of the real environment take precedence over the ones loaded from .env files,
+// and files take precedence over the ones listed before them.
+// Missing files are ignored, so that .env files can remain optional (eg. in production).
+// Files are read when the option is applied.
+func WithDotenv(paths ...string) Option {
+	return optionFunc(func(v *Viper) {
+		for _, path := range paths {
+			if err := v.loadDotenv(path); err != nil {
+				v.logger.Error("failed to load .env file", "file", path, "error", err)
+			}
+		}
+	})
+}
+
+// LoadDotenv loads variables from .env files into the environment layer, like WithDotenv,
+// so they are read by BindEnv and AutomaticEnv (not into the config file layer).
+// The real environment takes precedence over .env files, which take precedence over config files.
+// Files loaded later take precedence over the ones loaded before them.
+//
+// Without paths, ".env" in the working directory is loaded.
+// Missing files are ignored; LoadDotenv returns an error when a file cannot be read or parsed.
+func LoadDotenv(paths ...string) error { return v.LoadDotenv(paths...) }
+
+func (v *Viper) LoadDotenv(paths ...string) error {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+
+	for _, path := range paths {
+		if err := v.loadDotenv(path); err != nil {
+			return fmt.Errorf("load .env file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// loadDotenv reads the variables of a .env file into the environment layer.
+func (v *Viper) loadDotenv(path string) error {
+	b, err := afero.ReadFile(v.fs, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		v.logger.Debug("no .env file found", "file", path)
+
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	env, err := gotenv.StrictParse(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	if v.dotenv == nil {
+		v.dotenv = make(map[string]string, len(env))
+	}
+
+	for key, value := range env {
+		v.dotenv[key] = value
+	}
+
+	return nil
+}
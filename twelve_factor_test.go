@@ -0,0 +1,70 @@
+package viper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTwelveFactor(t *testing.T) {
+	t.Setenv("MYAPP_DB_HOST", "db.internal")
+	t.Setenv("MYAPP_DB_USER", "")
+
+	v := NewTwelveFactor("myapp")
+	v.AddConfigPath(t.TempDir())
+	require.NoError(t, v.ReadInConfig(), "a missing config file is not an error")
+
+	v.SetDefault("db.user", "root")
+
+	assert.Equal(t, "db.internal", v.GetString("db.host"))
+	assert.True(t, v.IsSet("db.user"))
+	assert.Equal(t, "", v.GetString("db.user"))
+}
+
+func TestWithDotenv(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("MYAPP_DB_HOST=localhost\nMYAPP_DB_PORT=5432\nMYAPP_NAME=app\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env.local"), []byte("MYAPP_DB_PORT=6432\n"), 0o644))
+
+	t.Setenv("MYAPP_DB_HOST", "db.internal")
+
+	v := NewTwelveFactor("myapp", WithDotenv(
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, ".env.local"),
+		filepath.Join(dir, ".env.missing"),
+	))
+
+	assert.Equal(t, "db.internal", v.GetString("db.host"), "the real environment takes precedence")
+	assert.Equal(t, 6432, v.GetInt("db.port"), "later files take precedence")
+	assert.Equal(t, "app", v.GetString("name"))
+}
+
+func TestLoadDotenv(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, ".env", []byte("APP_HOST=localhost\nAPP_PORT=5432\nAPP_NAME=dotenv\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("host: config\nname: config\nuser: root\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "invalid.env", []byte("APP_USER='unterminated\n"), 0o644))
+
+	t.Setenv("APP_HOST", "env")
+
+	v := New()
+	v.SetFs(fs)
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	require.NoError(t, v.LoadDotenv())
+	require.NoError(t, v.LoadDotenv("missing.env"))
+
+	assert.Equal(t, "env", v.GetString("host"), "the real environment takes precedence")
+	assert.Equal(t, "dotenv", v.GetString("name"), ".env files take precedence over config files")
+	assert.Equal(t, 5432, v.GetInt("port"))
+	assert.Equal(t, "root", v.GetString("user"))
+
+	require.Error(t, v.LoadDotenv("invalid.env"))
+}
@@ -0,0 +1,169 @@
+package viper
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/cast"
+)
+
+// RegisterTypedDecoder registers the concrete types the values at a path are decoded into
+// by Unmarshal, UnmarshalKey and UnmarshalExact, chosen by the value of their discriminator key,
+// eg. for a list of outputs of different kinds decoded into an interface:
+//
+//	viper.RegisterTypedDecoder("outputs[].type", map[string]reflect.Type{
+//		"s3":    reflect.TypeOf(S3Output{}),
+//		"kafka": reflect.TypeOf(KafkaOutput{}),
+//	})
+//
+// The last key of the path is the discriminator. Keys suffixed with "[]" stand for every element of a list.
+// The types must be assignable to the fields the values are decoded into.
+// Values with a missing or unregistered discriminator fail the decoding.
+func RegisterTypedDecoder(path string, types map[string]reflect.Type) {
+	v.RegisterTypedDecoder(path, types)
+}
+
+func (v *Viper) RegisterTypedDecoder(path string, types map[string]reflect.Type) {
+	if v.typedDecoders == nil {
+		v.typedDecoders = make(map[string]map[string]reflect.Type)
+	}
+
+	v.typedDecoders[strings.ReplaceAll(strings.ToLower(path), v.keyDelim, ".")] = types
+}
+
+// decodeTypedValues returns a copy of input, the value of the key prefix (or of the whole configuration if empty),
+// where the values of the paths registered with RegisterTypedDecoder are decoded into their concrete types.
+func (v *Viper) decodeTypedValues(input any, config *mapstructure.DecoderConfig, prefix string) (any, error) {
+	paths := make([]string, 0, len(v.typedDecoders))
+	for path := range v.typedDecoders {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok || (prefix != "" && !strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "[]")) {
+			continue
+		}
+
+		var err error
+
+		input, err = v.decodeTypedValue(input, strings.Split(strings.TrimPrefix(rest, "."), "."), v.typedDecoders[path], config, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return input, nil
+}
+
+// decodeTypedValue decodes the values found at path in val, whose key path is key.
+func (v *Viper) decodeTypedValue(val any, path []string, types map[string]reflect.Type, config *mapstructure.DecoderConfig, key string) (any, error) {
+	if len(path) == 1 {
+		return decodeDiscriminated(val, path[0], types, config, key)
+	}
+
+	name, list := strings.CutSuffix(path[0], "[]")
+
+	elem := val
+	m, isMap := val.(map[string]any)
+
+	if name != "" {
+		if !isMap {
+			return val, nil
+		}
+
+		var found bool
+		for k, e := range m {
+			if strings.EqualFold(k, name) {
+				name, elem, found = k, e, true
+
+				break
+			}
+		}
+
+		if !found {
+			return val, nil
+		}
+
+		key = joinKey(key, name)
+	}
+
+	var (
+		out any
+		err error
+	)
+
+	if list {
+		s, ok := elem.([]any)
+		if !ok {
+			return val, nil
+		}
+
+		decoded := make([]any, len(s))
+		for i, e := range s {
+			if decoded[i], err = v.decodeTypedValue(e, path[1:], types, config, fmt.Sprintf("%s[%d]", key, i)); err != nil {
+				return nil, err
+			}
+		}
+
+		out = decoded
+	} else if out, err = v.decodeTypedValue(elem, path[1:], types, config, key); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return out, nil
+	}
+
+	// copy the map, as it may be held by the configuration
+	m = maps.Clone(m)
+	m[name] = out
+
+	return m, nil
+}
+
+// decodeDiscriminated decodes a map into the type registered for the value of its discriminator key.
+func decodeDiscriminated(val any, discriminator string, types map[string]reflect.Type, config *mapstructure.DecoderConfig, key string) (any, error) {
+	m, ok := val.(map[string]any)
+	if !ok {
+		return val, nil
+	}
+
+	kind, ok := lookupFold(m, discriminator)
+	if !ok {
+		return nil, fmt.Errorf("%s: missing %s", key, discriminator)
+	}
+
+	typ, ok := types[cast.ToString(kind)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown %s %q", key, discriminator, cast.ToString(kind))
+	}
+
+	out := reflect.New(typ)
+
+	var md mapstructure.Metadata
+
+	c := *config
+	c.Result = out.Interface()
+	c.Metadata = &md
+
+	if err := decode(m, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+
+	// report unused keys with their full path, except the discriminator
+	if config.Metadata != nil {
+		for _, unused := range md.Unused {
+			if !strings.EqualFold(unused, discriminator) {
+				config.Metadata.Unused = append(config.Metadata.Unused, joinKey(key, unused))
+			}
+		}
+	}
+
+	return out.Elem().Interface(), nil
+}
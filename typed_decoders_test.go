@@ -0,0 +1,79 @@
+package viper
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOutput interface {
+	Kind() string
+}
+
+type testS3Output struct {
+	Bucket string
+}
+
+func (testS3Output) Kind() string { return "s3" }
+
+type testFileOutput struct {
+	Type string
+	Path string
+}
+
+func (*testFileOutput) Kind() string { return "file" }
+
+func TestRegisterTypedDecoder(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+outputs:
+  - type: s3
+    bucket: logs
+  - type: file
+    path: /var/log/app.log
+pipeline:
+  sink:
+    type: s3
+    bucket: archive
+`)))
+
+	v.RegisterTypedDecoder("outputs[].type", map[string]reflect.Type{
+		"s3":   reflect.TypeOf(testS3Output{}),
+		"file": reflect.TypeOf(&testFileOutput{}),
+	})
+	v.RegisterTypedDecoder("pipeline.sink.type", map[string]reflect.Type{
+		"s3": reflect.TypeOf(testS3Output{}),
+	})
+
+	var config struct {
+		Outputs  []testOutput
+		Pipeline struct {
+			Sink testOutput
+		}
+	}
+	require.NoError(t, v.UnmarshalExact(&config))
+
+	assert.Equal(t, []testOutput{
+		testS3Output{Bucket: "logs"},
+		&testFileOutput{Type: "file", Path: "/var/log/app.log"},
+	}, config.Outputs)
+	assert.Equal(t, testS3Output{Bucket: "archive"}, config.Pipeline.Sink)
+
+	var outputs []testOutput
+	require.NoError(t, v.UnmarshalKey("outputs", &outputs))
+	assert.Equal(t, config.Outputs, outputs)
+
+	assert.Equal(t, "s3", v.Get("outputs.0.type"), "the configuration is left untouched")
+
+	v.Set("outputs", []any{map[string]any{"type": "s3", "bukket": "logs"}})
+	err := v.UnmarshalExact(&config)
+	assert.EqualError(t, err, "unknown keys: outputs[0].bukket")
+
+	v.Set("outputs", []any{map[string]any{"type": "kafka"}})
+	err = v.Unmarshal(&config)
+	assert.EqualError(t, err, `outputs[0]: unknown type "kafka"`)
+}
@@ -0,0 +1,174 @@
+package viper
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// UnknownKeysError denotes keys of the configuration that match no field of the struct
+// passed to UnmarshalExact.
+type UnknownKeysError struct {
+	// Keys lists the full paths of the unknown keys, sorted (eg. "db.hosst" or "servers[0].nmae").
+	Keys []string
+
+	// Suggestions maps unknown keys to the closest key of the struct, when one is close enough
+	// to be a likely typo.
+	Suggestions map[string]string
+}
+
+// Error returns the formatted unknown keys error.
+func (e UnknownKeysError) Error() string {
+	keys := make([]string, len(e.Keys))
+	for i, key := range e.Keys {
+		keys[i] = key
+		if suggestion, ok := e.Suggestions[key]; ok {
+			keys[i] += fmt.Sprintf(" (did you mean %s?)", suggestion)
+		}
+	}
+
+	return fmt.Sprintf("unknown keys: %s", strings.Join(keys, ", "))
+}
+
+var sliceIndexRegexp = regexp.MustCompile(`\[\d+\]`)
+
+// IgnoreUnusedKeys makes UnmarshalExact and UnmarshalKeyExact accept the keys matching no field of the struct
+// when their path matches one of the glob patterns (see path.Match), eg. for comments or vendor extensions:
+//
+//	viper.NewWithOptions(viper.IgnoreUnusedKeys("x-*", "vendor"))
+//
+// Patterns match the full paths of keys level by level, so "x-*" ignores "x-comments" but not "db.x-note",
+// which "*.x-*" ignores. Nested keys of matching keys are ignored too, and slice indexes are left out of paths.
+func IgnoreUnusedKeys(patterns ...string) Option {
+	return optionFunc(func(v *Viper) {
+		v.ignoredUnusedKeys = append(v.ignoredUnusedKeys, patterns...)
+	})
+}
+
+// isIgnoredUnusedKey reports whether the path of an unused key, or of one of its parents,
+// matches a pattern of IgnoreUnusedKeys.
+func (v *Viper) isIgnoredUnusedKey(key string) bool {
+	if len(v.ignoredUnusedKeys) == 0 {
+		return false
+	}
+
+	parts := strings.Split(sliceIndexRegexp.ReplaceAllString(key, ""), ".")
+
+	for _, pattern := range v.ignoredUnusedKeys {
+		if !v.caseSensitiveConfig {
+			pattern = strings.ToLower(pattern)
+		}
+
+		pattern = strings.ReplaceAll(pattern, ".", "/")
+
+		for i := 1; i <= len(parts); i++ {
+			if ok, _ := path.Match(pattern, strings.Join(parts[:i], "/")); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// unknownKeysError returns the UnknownKeysError of the unused keys of a decoding into rawVal, or nil.
+// Keys are reported under prefix, the key of the decoded value.
+func (v *Viper) unknownKeysError(rawVal any, config *mapstructure.DecoderConfig, prefix string, unused []string) error {
+	err := UnknownKeysError{
+		Suggestions: make(map[string]string),
+	}
+
+	// paths are made of the names of struct fields: match the case of keys
+	for _, key := range unused {
+		if !v.caseSensitiveConfig {
+			key = strings.ToLower(key)
+		}
+
+		if key = joinKey(prefix, key); !v.isIgnoredUnusedKey(key) {
+			err.Keys = append(err.Keys, key)
+		}
+	}
+
+	if len(err.Keys) == 0 {
+		return nil
+	}
+
+	slices.Sort(err.Keys)
+
+	typ := reflect.TypeOf(rawVal)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return err
+	}
+
+	tagName := config.TagName
+	if tagName == "" {
+		tagName = "mapstructure"
+	}
+
+	var known []string
+	v.walkStructFields(typ, tagName, config.Squash, "", nil, func(key string, _ reflect.StructField) {
+		known = append(known, joinKey(prefix, strings.ReplaceAll(key, v.keyDelim, ".")))
+	})
+
+	for _, key := range err.Keys {
+		if suggestion := closestKey(sliceIndexRegexp.ReplaceAllString(key, ""), known); suggestion != "" {
+			err.Suggestions[key] = suggestion
+		}
+	}
+
+	return err
+}
+
+// closestKey returns the candidate closest to key, if it is close enough to be a likely typo.
+func closestKey(key string, candidates []string) string {
+	var (
+		closest string
+		best    = len(key)/3 + 1
+	)
+
+	for _, candidate := range candidates {
+		if d := levenshtein(key, candidate); d < best {
+			closest, best = candidate, d
+		}
+	}
+
+	return closest
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,126 @@
+package viper
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalExact_UnknownKeys(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+name: app
+db:
+  hosst: localhost
+  port: 5432
+servers:
+  - nmae: web
+verbose: true
+`)))
+
+	var config struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+		Servers []struct {
+			Name string
+		}
+	}
+
+	err := v.UnmarshalExact(&config)
+
+	var unknown UnknownKeysError
+	require.True(t, errors.As(err, &unknown))
+	assert.Equal(t, []string{"db.hosst", "servers[0].nmae", "verbose"}, unknown.Keys)
+	assert.Equal(t, map[string]string{"db.hosst": "db.host"}, unknown.Suggestions)
+	assert.EqualError(t, err, "unknown keys: db.hosst (did you mean db.host?), servers[0].nmae, verbose")
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("host", "host"))
+	assert.Equal(t, 1, levenshtein("hosst", "host"))
+	assert.Equal(t, 2, levenshtein("prot", "port"))
+	assert.Equal(t, 4, levenshtein("", "port"))
+}
+
+func TestUnmarshalKeyExact(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+db:
+  primary:
+    hosst: localhost
+    port: 5432
+`)))
+
+	type database struct {
+		Host string
+		Port int
+		User string
+	}
+
+	var db database
+	err := v.UnmarshalKeyExact("DB.primary", &db)
+	assert.EqualError(t, err, "unknown keys: db.primary.hosst (did you mean db.primary.host?)")
+	assert.Equal(t, 5432, db.Port)
+
+	v = New()
+	v.Set("db.primary.host", "localhost")
+	v.Set("db.primary.port", 5432)
+	require.NoError(t, v.UnmarshalKeyExact("db.primary", &db))
+
+	err = v.UnmarshalKeyExact("db.primary", &database{}, func(c *mapstructure.DecoderConfig) {
+		c.ErrorUnset = true
+	})
+	require.ErrorContains(t, err, "User", "decoder options apply")
+}
+
+func TestIgnoreUnusedKeys(t *testing.T) {
+	v := NewWithOptions(IgnoreUnusedKeys("x-*", "vendor", "*.X-*"))
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+name: app
+x-comments:
+  name: the name of the app
+vendor:
+  acme:
+    enabled: true
+db:
+  host: localhost
+  x-note: primary
+  vendor: acme
+servers:
+  - name: web
+    x-owner: ops
+`)))
+
+	var config struct {
+		Name string
+		DB   struct {
+			Host string
+		}
+		Servers []struct {
+			Name string
+		}
+	}
+
+	err := v.UnmarshalExact(&config)
+
+	var unknown UnknownKeysError
+	require.ErrorAs(t, err, &unknown)
+	assert.Equal(t, []string{"db.vendor"}, unknown.Keys, "patterns match level by level")
+
+	v = NewWithOptions(IgnoreUnusedKeys("x-*", "*.x-*", "db.vendor"))
+	v.Set("name", "app")
+	v.Set("x-comments", "none")
+	v.Set("db.vendor", "acme")
+	require.NoError(t, v.UnmarshalExact(&config))
+	require.NoError(t, v.UnmarshalKeyExact("db", &config.DB))
+}
@@ -0,0 +1,196 @@
+// Package urfavecli binds the flags of github.com/urfave/cli applications to Viper.
+//
+// Both the *cli.Context of urfave/cli v2 and the *cli.Command of urfave/cli v3 implement Source,
+// so that this package does not depend on either version.
+// The slice and timestamp flags of v2 (eg. cli.StringSlice) are read through their Value methods:
+//
+//	Before: func(c *cli.Context) error {
+//		return urfavecli.Bind(viper.GetViper(), c)
+//	},
+package urfavecli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+
+	"github.com/spf13/viper"
+)
+
+// Source is the part of urfave/cli contexts (v2) and commands (v3) used to read flags.
+type Source interface {
+	// FlagNames returns the names of the flags (including their aliases).
+	FlagNames() []string
+
+	// IsSet reports whether a flag was set on the command line or from one of its sources (eg. env vars).
+	IsSet(name string) bool
+
+	// Value returns the value of a flag.
+	Value(name string) any
+}
+
+// Bind binds the flags of src to the keys of v named after them (see viper.Viper.BindFlagValues).
+// When names are given, only these flags are bound, eg. to leave out the aliases returned by FlagNames.
+func Bind(v *viper.Viper, src Source, names ...string) error {
+	return v.BindFlagValues(FlagValueSet(src, names...))
+}
+
+// FlagValueSet adapts the flags of src to viper.FlagValueSet.
+// When names are given, only these flags are visited.
+func FlagValueSet(src Source, names ...string) viper.FlagValueSet {
+	if len(names) == 0 {
+		names = src.FlagNames()
+	}
+
+	return flagValueSet{src: src, names: slices.Compact(slices.Clone(names))}
+}
+
+type flagValueSet struct {
+	src   Source
+	names []string
+}
+
+// VisitAll iterates over the flags of the source.
+func (s flagValueSet) VisitAll(fn func(viper.FlagValue)) {
+	for _, name := range s.names {
+		fn(flagValue{src: s.src, name: name})
+	}
+}
+
+type flagValue struct {
+	src  Source
+	name string
+}
+
+// HasChanged returns whether the flag is set.
+func (f flagValue) HasChanged() bool {
+	return f.src.IsSet(f.name)
+}
+
+// Name returns the name of the flag.
+func (f flagValue) Name() string {
+	return f.name
+}
+
+// value returns the value of the flag, unwrapping the flag values of urfave/cli v2.
+func (f flagValue) value() any {
+	return unwrap(f.src.Value(f.name))
+}
+
+// ValueString returns the value of the flag as a string, formatted like the values of pflag flags.
+func (f flagValue) ValueString() string {
+	switch val := f.value().(type) {
+	case []string:
+		return "[" + csvJoin(val) + "]"
+	case []int:
+		return "[" + csvJoin(cast.ToStringSlice(val)) + "]"
+	case []int64:
+		return "[" + csvJoin(formatSlice(val)) + "]"
+	case []uint:
+		return "[" + csvJoin(formatSlice(val)) + "]"
+	case []float64:
+		return "[" + csvJoin(formatSlice(val)) + "]"
+	case []time.Duration:
+		s := make([]string, len(val))
+		for i, d := range val {
+			s[i] = d.String()
+		}
+
+		return "[" + csvJoin(s) + "]"
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case nil:
+		return ""
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return cast.ToString(val)
+	}
+}
+
+// ValueType returns the type of the flag as a string, named like the types of pflag.
+func (f flagValue) ValueType() string {
+	switch f.value().(type) {
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case int64:
+		return "int64"
+	case uint:
+		return "uint"
+	case uint64:
+		return "uint64"
+	case float64:
+		return "float64"
+	case time.Duration:
+		return "duration"
+	case []string:
+		return "stringSlice"
+	case []int:
+		return "intSlice"
+	case []int64:
+		return "int64Slice"
+	case []uint:
+		return "uintSlice"
+	case []float64:
+		return "float64Slice"
+	case []time.Duration:
+		return "durationSlice"
+	}
+
+	return "string"
+}
+
+// unwrap returns the values held by the flag values of urfave/cli v2 (eg. cli.StringSlice or cli.Timestamp),
+// which Context.Value returns as is: they are structs whose Value method, defined on their pointer,
+// returns the values set. Other values are returned as is.
+func unwrap(val any) any {
+	rv := reflect.ValueOf(val)
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return val
+	}
+
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+
+	method := ptr.MethodByName("Value")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return val
+	}
+
+	out := method.Call(nil)[0]
+	if out.Kind() == reflect.Pointer {
+		if out.IsNil() {
+			return nil
+		}
+
+		out = out.Elem()
+	}
+
+	return out.Interface()
+}
+
+func formatSlice[T any](values []T) []string {
+	s := make([]string, len(values))
+	for i, val := range values {
+		s[i] = fmt.Sprint(val)
+	}
+
+	return s
+}
+
+func csvJoin(values []string) string {
+	var b strings.Builder
+
+	w := csv.NewWriter(&b)
+	_ = w.Write(values)
+	w.Flush()
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,103 @@
+package urfavecli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spf13/viper"
+)
+
+// source mimics the flags of a urfave/cli context or command.
+type source struct {
+	values map[string]any
+	set    map[string]bool
+}
+
+func (s source) FlagNames() []string {
+	return []string{"port", "p", "debug", "hosts", "ports", "timeouts", "name"}
+}
+
+func (s source) IsSet(name string) bool { return s.set[name] }
+
+func (s source) Value(name string) any {
+	switch name {
+	case "p":
+		return s.values["port"]
+	}
+
+	return s.values[name]
+}
+
+func TestBind(t *testing.T) {
+	src := source{
+		values: map[string]any{
+			"port":     9090,
+			"debug":    true,
+			"hosts":    []string{"a", "b,c"},
+			"ports":    []int{80, 443},
+			"timeouts": []time.Duration{time.Second},
+			"name":     "app",
+		},
+		set: map[string]bool{"port": true, "p": true, "debug": true, "hosts": true, "ports": true, "timeouts": true},
+	}
+
+	v := viper.New()
+	v.SetDefault("name", "default")
+	require.NoError(t, Bind(v, src))
+
+	assert.Equal(t, 9090, v.Get("port"))
+	assert.Equal(t, 9090, v.Get("p"))
+	assert.Equal(t, true, v.Get("debug"))
+	assert.Equal(t, []string{"a", "b,c"}, v.GetStringSlice("hosts"))
+	assert.Equal(t, []int{80, 443}, v.GetIntSlice("ports"))
+	assert.Equal(t, []time.Duration{time.Second}, v.Get("timeouts"))
+	assert.Equal(t, "default", v.Get("name"), "unset flags do not override defaults")
+
+	v = viper.New()
+	require.NoError(t, Bind(v, src, "port", "name"))
+
+	assert.ElementsMatch(t, []string{"port", "name"}, v.AllKeys())
+	assert.Equal(t, "app", v.Get("name"))
+}
+
+// The flag values of urfave/cli v2 are returned as is by Context.Value:
+// these types mirror cli.StringSlice, cli.IntSlice, cli.Float64Slice and cli.Timestamp,
+// whose Value methods are defined on their pointer.
+type (
+	stringSlice  struct{ slice []string }
+	intSlice     struct{ slice []int }
+	float64Slice struct{ slice []float64 }
+	timestamp    struct{ timestamp *time.Time }
+)
+
+func (s *stringSlice) Value() []string   { return s.slice }
+func (s *intSlice) Value() []int         { return s.slice }
+func (s *float64Slice) Value() []float64 { return s.slice }
+func (t *timestamp) Value() *time.Time   { return t.timestamp }
+
+func TestBind_V2Values(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	src := source{
+		values: map[string]any{
+			"hosts":    stringSlice{slice: []string{"a", "b,c"}},
+			"ports":    intSlice{slice: []int{80, 443}},
+			"timeouts": float64Slice{slice: []float64{0.5, 2}},
+			"port":     timestamp{timestamp: &started},
+			"name":     timestamp{},
+		},
+		set: map[string]bool{"hosts": true, "ports": true, "timeouts": true, "port": true},
+	}
+
+	v := viper.New()
+	require.NoError(t, Bind(v, src, "hosts", "ports", "timeouts", "port", "name"))
+
+	assert.Equal(t, []string{"a", "b,c"}, v.GetStringSlice("hosts"))
+	assert.Equal(t, []int{80, 443}, v.GetIntSlice("ports"))
+	assert.Equal(t, []float64{0.5, 2}, v.Get("timeouts"))
+	assert.Equal(t, started, v.GetTime("port"))
+	assert.Equal(t, "", v.Get("name"), "unset timestamps are empty")
+}
@@ -11,15 +11,20 @@
 package viper
 
 import (
+	"encoding"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"unicode"
 
 	"github.com/spf13/cast"
+
+	"github.com/spf13/viper/internal/maputil"
 )
 
 // ConfigParseError denotes failing to parse configuration file.
@@ -50,6 +55,265 @@ func toCaseInsensitiveValue(value any) any {
 	return value
 }
 
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// toMapValue converts struct values (and pointers to structs) into nested maps
+// keyed by their mapstructure tag names, so they are stored like any other
+// nested configuration value.
+// Structs nested in slices and maps are converted as well.
+// Structs implementing [encoding.TextMarshaler] (like [time.Time]) are kept as is.
+// Secret values are converted to plain strings.
+// Values holding no struct or Secret are returned as is.
+func toMapValue(value any) any {
+	value, _ = toMapValueSecrets(value)
+
+	return value
+}
+
+// toMapValueSecrets converts a value like toMapValue and also returns the paths
+// of the Secret values it holds, relative to the value.
+// Secrets held by slices are reported at the path of the slice.
+func toMapValueSecrets(value any) (any, [][]string) {
+	if value == nil {
+		return nil, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if !containsConvertibleStruct(rv, map[uintptr]bool{}) {
+		return value, nil
+	}
+
+	c := newStructConverter()
+	value = c.convert(rv)
+
+	return value, c.secrets
+}
+
+func isConvertibleStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && !t.Implements(textMarshalerType) && !reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// containsConvertibleStruct reports whether a value is, or holds, a struct or a Secret converted by toMapValue.
+// seen holds the pointers being visited, to stop at cycles.
+func containsConvertibleStruct(rv reflect.Value, seen map[uintptr]bool) bool {
+	switch rv.Kind() {
+	case reflect.Interface:
+		return !rv.IsNil() && containsConvertibleStruct(rv.Elem(), seen)
+	case reflect.Pointer:
+		return !rv.IsNil() && (isConvertibleStruct(rv.Type()) || isSecretType(rv.Type()))
+	case reflect.Struct:
+		return isConvertibleStruct(rv.Type())
+	case reflect.String:
+		return rv.Type() == secretType
+	case reflect.Slice, reflect.Array:
+		if !mayHoldStruct(rv.Type().Elem()) {
+			return false
+		}
+
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() || seen[rv.Pointer()] {
+				return false
+			}
+
+			seen[rv.Pointer()] = true
+			defer delete(seen, rv.Pointer())
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			if containsConvertibleStruct(rv.Index(i), seen) {
+				return true
+			}
+		}
+	case reflect.Map:
+		if !mayHoldStruct(rv.Type().Elem()) || rv.IsNil() || seen[rv.Pointer()] {
+			return false
+		}
+
+		seen[rv.Pointer()] = true
+		defer delete(seen, rv.Pointer())
+
+		iter := rv.MapRange()
+		for iter.Next() {
+			if containsConvertibleStruct(iter.Value(), seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mayHoldStruct reports whether values of a type may hold a struct or a Secret.
+func mayHoldStruct(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+
+	return t == secretType
+}
+
+// structConverter converts the structs held by a value into nested maps.
+type structConverter struct {
+	// seen holds the pointers being converted: pointers back to them are dropped,
+	// as cyclic values cannot be represented as nested maps.
+	seen map[uintptr]bool
+
+	// path is the path of the value being converted.
+	path []string
+
+	// secrets holds the paths of the converted Secret values.
+	secrets [][]string
+}
+
+func newStructConverter() *structConverter {
+	return &structConverter{seen: map[uintptr]bool{}}
+}
+
+// convertAt converts the value of a key of the value being converted.
+func (c *structConverter) convertAt(key string, rv reflect.Value) any {
+	c.path = append(c.path, key)
+	defer func() { c.path = c.path[:len(c.path)-1] }()
+
+	return c.convert(rv)
+}
+
+func (c *structConverter) convert(rv reflect.Value) any {
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+
+		return c.convert(rv.Elem())
+	case reflect.Pointer:
+		if rv.IsNil() || c.seen[rv.Pointer()] {
+			return nil
+		}
+
+		if !isConvertibleStruct(rv.Type()) && !isSecretType(rv.Type()) {
+			return rv.Interface()
+		}
+
+		c.seen[rv.Pointer()] = true
+		defer delete(c.seen, rv.Pointer())
+
+		return c.convert(rv.Elem())
+	case reflect.String:
+		if rv.Type() != secretType {
+			return rv.Interface()
+		}
+
+		// Secrets are stored as plain strings, and only redacted when shown
+		if !slices.ContainsFunc(c.secrets, func(path []string) bool { return slices.Equal(path, c.path) }) {
+			c.secrets = append(c.secrets, slices.Clone(c.path))
+		}
+
+		return rv.String()
+	case reflect.Struct:
+		if !isConvertibleStruct(rv.Type()) {
+			return rv.Interface()
+		}
+
+		m := map[string]any{}
+		c.fieldsToMap(rv, m)
+
+		return m
+	case reflect.Slice, reflect.Array:
+		if !containsConvertibleStruct(rv, c.seen) {
+			return rv.Interface()
+		}
+
+		if rv.Kind() == reflect.Slice {
+			c.seen[rv.Pointer()] = true
+			defer delete(c.seen, rv.Pointer())
+		}
+
+		s := make([]any, rv.Len())
+		for i := range s {
+			s[i] = c.convert(rv.Index(i))
+		}
+
+		return s
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || !containsConvertibleStruct(rv, c.seen) {
+			return rv.Interface()
+		}
+
+		c.seen[rv.Pointer()] = true
+		defer delete(c.seen, rv.Pointer())
+
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = c.convertAt(iter.Key().String(), iter.Value())
+		}
+
+		return m
+	}
+
+	return rv.Interface()
+}
+
+// fieldsToMap copies the exported fields of rv into m
+// following the naming rules of mapstructure tags.
+func (c *structConverter) fieldsToMap(rv reflect.Value, m map[string]any) {
+	t := rv.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+
+		opts := strings.Split(options, ",")
+		fv := rv.Field(i)
+
+		if slices.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		if slices.Contains(opts, "squash") {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() == reflect.Struct {
+				c.fieldsToMap(fv, m)
+
+				continue
+			}
+		}
+
+		// Keys captured by a ",remain" field belong to the enclosing struct.
+		if slices.Contains(opts, "remain") && fv.Kind() == reflect.Map && fv.Type().Key().Kind() == reflect.String {
+			iter := fv.MapRange()
+			for iter.Next() {
+				if _, exists := m[iter.Key().String()]; !exists {
+					m[iter.Key().String()] = c.convertAt(iter.Key().String(), iter.Value())
+				}
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		m[name] = c.convertAt(name, fv)
+	}
+}
+
 // copyAndInsensitiviseMap behaves like insensitiviseMap, but creates a copy of
 // any map it makes case insensitive.
 func copyAndInsensitiviseMap(m map[string]any) map[string]any {
@@ -182,33 +446,18 @@ func parseSizeInBytes(sizeStr string) uint {
 	return safeMul(uint(size), multiplier)
 }
 
-// deepSearch scans deep maps, following the key indexes listed in the
-// sequence "path".
-// The last value is expected to be another map, and is returned.
-//
-// In case intermediate keys do not exist, or map to a non-map value,
-// a new map is created and inserted, and the search continues from there:
-// the initial map "m" may be modified!
-func deepSearch(m map[string]any, path []string) map[string]any {
-	for _, k := range path {
-		m2, ok := m[k]
-		if !ok {
-			// intermediate key does not exist
-			// => create it and continue from there
-			m3 := make(map[string]any)
-			m[k] = m3
-			m = m3
-			continue
-		}
-		m3, ok := m2.(map[string]any)
-		if !ok {
-			// intermediate key is a value
-			// => replace with a new map
-			m3 = make(map[string]any)
-			m[k] = m3
-		}
-		// continue search from here
-		m = m3
-	}
-	return m
+// FlattenMap flattens the nested maps of m into a single level map
+// whose keys are the lower cased paths to each value, joined by delimiter
+// (eg. {"db": {"host": "localhost"}} becomes {"db.host": "localhost"} with the "." delimiter).
+// It follows the rules Viper uses for keys, and is the inverse of UnflattenMap.
+func FlattenMap(m map[string]any, delimiter string) map[string]any {
+	return maputil.Flatten(m, delimiter)
+}
+
+// UnflattenMap builds nested maps from a single level map whose keys are paths joined by delimiter
+// (eg. {"db.host": "localhost"} becomes {"db": {"host": "localhost"}} with the "." delimiter).
+// Keys are lower cased, and nested keys take precedence over values of their parent keys.
+// It follows the rules Viper uses for keys, and is the inverse of FlattenMap.
+func UnflattenMap(m map[string]any, delimiter string) map[string]any {
+	return maputil.Unflatten(m, delimiter)
 }
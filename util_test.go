@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -84,3 +85,129 @@ func TestAbsPathify(t *testing.T) {
 		assert.Equal(t, test.output, got)
 	}
 }
+
+func TestToMapValue(t *testing.T) {
+	type tls struct {
+		Enabled bool   `mapstructure:"enabled"`
+		Cert    string `mapstructure:"cert,omitempty"`
+	}
+
+	type Common struct {
+		Name string `mapstructure:"name"`
+	}
+
+	type server struct {
+		Common   `mapstructure:",squash"`
+		Port     int           `mapstructure:"port"`
+		Timeout  time.Duration `mapstructure:"timeout"`
+		Started  time.Time     `mapstructure:"started"`
+		TLS      *tls          `mapstructure:"tls"`
+		Backends []tls         `mapstructure:"backends"`
+		Tags     []string      `mapstructure:"tags"`
+		Ignored  string        `mapstructure:"-"`
+		internal string
+
+		// options are matched exactly
+		Retries int               `mapstructure:"retries,omitemptyish"`
+		Labels  map[string]string `mapstructure:"labels,remainder"`
+	}
+
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got := toMapValue(server{
+		Common:   Common{Name: "api"},
+		Port:     80,
+		Timeout:  time.Second,
+		Started:  started,
+		TLS:      &tls{Enabled: true},
+		Backends: []tls{{Enabled: false, Cert: "a.pem"}},
+		Tags:     []string{"a"},
+		Ignored:  "ignored",
+		internal: "internal",
+		Labels:   map[string]string{"env": "prod"},
+	})
+
+	expected := map[string]any{
+		"name":    "api",
+		"port":    80,
+		"timeout": time.Second,
+		"started": started,
+		"tls": map[string]any{
+			"enabled": true,
+		},
+		"backends": []any{
+			map[string]any{
+				"enabled": false,
+				"cert":    "a.pem",
+			},
+		},
+		"tags":    []string{"a"},
+		"retries": 0,
+		"labels":  map[string]string{"env": "prod"},
+	}
+
+	assert.Equal(t, expected, got)
+
+	assert.Equal(t, 1, toMapValue(1))
+	assert.Equal(t, started, toMapValue(started))
+	assert.Equal(t, []string{"a"}, toMapValue([]string{"a"}))
+}
+
+func TestToMapValueCycles(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+
+	a := &node{Name: "a"}
+	a.Next = &node{Name: "b", Next: a}
+
+	assert.Equal(t, map[string]any{
+		"Name": "a",
+		"Next": map[string]any{"Name": "b", "Next": nil},
+	}, toMapValue(a))
+
+	v := New()
+	v.Set("list", a)
+	assert.Equal(t, "b", v.Get("list.next.name"))
+
+	// values holding no struct are not copied
+	list := []any{"a", map[string]any{"b": 1}}
+	assert.Same(t, &list[0], &toMapValue(list).([]any)[0])
+
+	m := map[string]any{"a": []any{1}}
+	toMapValue(m).(map[string]any)["b"] = 2
+	assert.Contains(t, m, "b")
+}
+
+func TestFlattenMap(t *testing.T) {
+	nested := map[string]any{
+		"name": "app",
+		"DB": map[string]any{
+			"host": "localhost",
+			"pool": map[any]any{"size": 10},
+		},
+		"tags": []any{"a", "b"},
+	}
+	flat := map[string]any{
+		"name":         "app",
+		"db_host":      "localhost",
+		"db_pool_size": 10,
+		"tags":         []any{"a", "b"},
+	}
+
+	assert.Equal(t, flat, FlattenMap(nested, "_"))
+	assert.Equal(t, map[string]any{
+		"name": "app",
+		"db": map[string]any{
+			"host": "localhost",
+			"pool": map[string]any{"size": 10},
+		},
+		"tags": []any{"a", "b"},
+	}, UnflattenMap(flat, "_"))
+
+	// nested keys take precedence over values of their parent keys
+	assert.Equal(t, map[string]any{
+		"db": map[string]any{"host": "localhost"},
+	}, UnflattenMap(map[string]any{"DB.Host": "localhost", "db": "value"}, "."))
+}
@@ -0,0 +1,107 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConfigValidator validates the configuration read from config files before it is applied.
+// It is called with the instance holding the new configuration; returning an error
+// rejects the configuration and restores the previous one.
+type ConfigValidator func(v *Viper) error
+
+// ConfigValidationError denotes configuration rejected by a ConfigValidator.
+type ConfigValidationError struct {
+	err error
+}
+
+// Error returns the formatted validation error.
+func (ve ConfigValidationError) Error() string {
+	return fmt.Sprintf("While validating config: %s", ve.err.Error())
+}
+
+// Unwrap returns the wrapped error.
+func (ve ConfigValidationError) Unwrap() error {
+	return ve.err
+}
+
+// AddConfigValidator registers a validator of the configuration read from config files.
+//
+// Config files read by ReadInConfig, and read again by WatchConfig, are first parsed
+// into a new configuration. Every validator is then called in the order they were registered,
+// and the new configuration is only kept if all of them succeed.
+// Otherwise the previous configuration is restored, ReadInConfig returns a ConfigValidationError,
+// and WatchConfig reports it to the OnConfigReloadError handler without calling OnConfigChange.
+func AddConfigValidator(fn ConfigValidator) { v.AddConfigValidator(fn) }
+
+func (v *Viper) AddConfigValidator(fn ConfigValidator) {
+	if fn == nil {
+		return
+	}
+
+	v.configValidators = append(v.configValidators, fn)
+}
+
+// OnConfigReloadError sets the event handler that is called when WatchConfig
+// fails to read config files again (eg. a parse or validation error).
+// The previous configuration is kept.
+func OnConfigReloadError(run func(err error)) { v.OnConfigReloadError(run) }
+
+func (v *Viper) OnConfigReloadError(run func(err error)) {
+	v.onConfigReloadError = run
+}
+
+// RequireKeys returns a ConfigValidator failing when any of the keys is not set (see IsSet).
+func RequireKeys(keys ...string) ConfigValidator {
+	return func(v *Viper) error {
+		var missing []string
+
+		for _, key := range keys {
+			if !v.IsSet(key) {
+				missing = append(missing, key)
+			}
+		}
+
+		if len(missing) > 0 {
+			return fmt.Errorf("missing required keys: %s", strings.Join(missing, ", "))
+		}
+
+		return nil
+	}
+}
+
+// ValidateUnmarshal returns a ConfigValidator failing when the configuration
+// cannot be unmarshaled into a new value of the type rawVal points to:
+//
+//	v.AddConfigValidator(viper.ValidateUnmarshal(&Config{}, viper.StrictTypes()))
+//
+// rawVal itself is left untouched.
+func ValidateUnmarshal(rawVal any, opts ...DecoderConfigOption) ConfigValidator {
+	return func(v *Viper) error {
+		typ := reflect.TypeOf(rawVal)
+		if typ == nil || typ.Kind() != reflect.Pointer {
+			return errors.New("ValidateUnmarshal requires a pointer")
+		}
+
+		return v.Unmarshal(reflect.New(typ.Elem()).Interface(), opts...)
+	}
+}
+
+// applyConfig replaces the config file layer with config if every registered validator accepts it.
+func (v *Viper) applyConfig(config map[string]any) error {
+	previous := v.config.store(config)
+
+	for _, validate := range v.configValidators {
+		if err := validate(v); err != nil {
+			v.config.store(previous)
+
+			return ConfigValidationError{err}
+		}
+	}
+
+	v.updateVersion()
+
+	return nil
+}
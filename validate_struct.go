@@ -0,0 +1,249 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WithValidator sets a function validating the values decoded by Unmarshal, UnmarshalKey and UnmarshalExact,
+// eg. ValidateStruct or the Struct method of a go-playground/validator instance.
+// The error it returns is returned by the call.
+func WithValidator(validate func(rawVal any) error) Option {
+	return optionFunc(func(v *Viper) {
+		v.validator = validate
+	})
+}
+
+// UnmarshalValidated unmarshals the config into a Struct like Unmarshal, then validates it with validate.
+// When validate is nil, the struct is validated with ValidateStruct.
+func UnmarshalValidated(rawVal any, validate func(rawVal any) error, opts ...DecoderConfigOption) error {
+	return v.UnmarshalValidated(rawVal, validate, opts...)
+}
+
+func (v *Viper) UnmarshalValidated(rawVal any, validate func(rawVal any) error, opts ...DecoderConfigOption) error {
+	if err := v.Unmarshal(rawVal, opts...); err != nil {
+		return err
+	}
+
+	// already validated by Unmarshal
+	if validate == nil && v.validator != nil {
+		return nil
+	}
+
+	if validate == nil {
+		validate = ValidateStruct
+	}
+
+	return validate(rawVal)
+}
+
+// validate validates a decoded value with the validator of the instance (see WithValidator).
+func (v *Viper) validate(rawVal any) error {
+	if v.validator == nil {
+		return nil
+	}
+
+	return v.validator(rawVal)
+}
+
+// FieldError describes a field failing a rule of its "validate" tag (see ValidateStruct).
+type FieldError struct {
+	// Key is the path of the field, made of the keys the fields are decoded from
+	// and of slice indexes (eg. "servers[0].port").
+	Key string
+
+	// Rule is the failing rule (eg. "min").
+	Rule string
+
+	// Param is the parameter of the rule (eg. "1" for "min=1").
+	Param string
+}
+
+// Error returns the formatted field error.
+func (fe FieldError) Error() string {
+	switch fe.Rule {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Key)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", fe.Key, fe.Param)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", fe.Key, fe.Param)
+	case "len":
+		return fmt.Sprintf("%s must have a length of %s", fe.Key, fe.Param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", fe.Key, fe.Param)
+	default:
+		return fmt.Sprintf("%s: unknown validation rule %q", fe.Key, fe.Rule)
+	}
+}
+
+// ValidationErrors lists the fields failing validation (see ValidateStruct).
+type ValidationErrors []FieldError
+
+// Error returns the formatted validation errors.
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = fe.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateStruct validates a struct (or a pointer to a struct) against the "validate" tags of its fields,
+// which list comma separated rules in the style of go-playground/validator:
+//
+//   - required: the value is not the zero value
+//   - min=N, max=N: numbers are at least or at most N, strings, slices and maps have at least or at most N elements
+//   - len=N: strings, slices and maps have exactly N elements
+//   - oneof=a b c: the value is one of the space separated values
+//
+// Nested structs and the elements of slices and maps are validated too.
+// The error is a ValidationErrors listing every failing field with its key path.
+func ValidateStruct(rawVal any) error {
+	var errs ValidationErrors
+
+	validateValue(reflect.ValueOf(rawVal), "", &errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateValue validates the fields of the structs found in val, whose key path is key.
+func validateValue(val reflect.Value, key string, errs *ValidationErrors) {
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		typ := val.Type()
+
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, options, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if name == "-" {
+				continue
+			}
+
+			fieldKey := key
+			if !slices.Contains(strings.Split(options, ","), "squash") {
+				if name == "" {
+					name = field.Name
+				}
+
+				fieldKey = joinKey(key, strings.ToLower(name))
+			}
+
+			if rules := field.Tag.Get("validate"); rules != "" {
+				validateRules(val.Field(i), fieldKey, rules, errs)
+			}
+
+			validateValue(val.Field(i), fieldKey, errs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			validateValue(val.Index(i), fmt.Sprintf("%s[%d]", key, i), errs)
+		}
+
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), joinKey(key, fmt.Sprint(iter.Key().Interface())), errs)
+		}
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// validateRules checks the value of a field against the rules of its "validate" tag.
+func validateRules(val reflect.Value, key, rules string, errs *ValidationErrors) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		if name != "required" && isNilPointer(val) {
+			continue
+		}
+
+		if !checkRule(reflect.Indirect(val), name, param) {
+			*errs = append(*errs, FieldError{Key: key, Rule: name, Param: param})
+		}
+	}
+}
+
+func isNilPointer(val reflect.Value) bool {
+	return val.Kind() == reflect.Pointer && val.IsNil()
+}
+
+// checkRule reports whether a value satisfies a validation rule.
+func checkRule(val reflect.Value, rule, param string) bool {
+	switch rule {
+	case "required":
+		return val.IsValid() && !val.IsZero()
+
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+
+		size, ok := sizeOf(val)
+		if !ok {
+			return false
+		}
+
+		switch rule {
+		case "min":
+			return size >= limit
+		case "max":
+			return size <= limit
+		default:
+			return size == limit
+		}
+
+	case "oneof":
+		return slices.Contains(strings.Fields(param), fmt.Sprint(val.Interface()))
+
+	default:
+		return false
+	}
+}
+
+// sizeOf returns the value of numbers, and the length of strings, slices and maps.
+func sizeOf(val reflect.Value) (float64, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	case reflect.String:
+		return float64(len([]rune(val.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(val.Len()), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,84 @@
+package viper
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatedConfig struct {
+	Name     string `validate:"required"`
+	Mode     string `validate:"oneof=dev prod"`
+	Database struct {
+		Port  int      `validate:"min=1,max=65535"`
+		Hosts []string `validate:"min=1"`
+	}
+	Servers []struct {
+		Name string `mapstructure:"server_name" validate:"required,max=8"`
+	}
+	Timeout *int `validate:"min=1"`
+}
+
+func readValidatedConfig(t *testing.T, v *Viper) {
+	t.Helper()
+
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+mode: staging
+database:
+  port: 0
+  hosts: [db1]
+servers:
+  - server_name: web
+  - server_name: a-very-long-name
+  - {}
+`)))
+}
+
+func TestValidateStruct(t *testing.T) {
+	v := New()
+	readValidatedConfig(t, v)
+
+	var config validatedConfig
+	err := v.UnmarshalValidated(&config, nil)
+
+	var verrs ValidationErrors
+	require.True(t, errors.As(err, &verrs))
+	assert.Equal(t, ValidationErrors{
+		{Key: "name", Rule: "required"},
+		{Key: "mode", Rule: "oneof", Param: "dev prod"},
+		{Key: "database.port", Rule: "min", Param: "1"},
+		{Key: "servers[1].server_name", Rule: "max", Param: "8"},
+		{Key: "servers[2].server_name", Rule: "required"},
+	}, verrs)
+	assert.Equal(t, "web", config.Servers[0].Name, "the struct is decoded")
+	assert.Contains(t, err.Error(), "database.port must be at least 1")
+
+	v.Set("name", "app")
+	v.Set("mode", "dev")
+	v.Set("database.port", 5432)
+	v.Set("servers", []map[string]any{{"server_name": "web"}})
+	require.NoError(t, v.UnmarshalValidated(&validatedConfig{}, nil))
+}
+
+func TestWithValidator(t *testing.T) {
+	v := NewWithOptions(WithValidator(ValidateStruct))
+	readValidatedConfig(t, v)
+
+	var config validatedConfig
+	require.Error(t, v.Unmarshal(&config))
+	require.Error(t, v.UnmarshalExact(&config))
+
+	var database struct {
+		Port int `validate:"min=1"`
+	}
+	err := v.UnmarshalKey("database", &database)
+	assert.Equal(t, ValidationErrors{{Key: "port", Rule: "min", Param: "1"}}, err)
+
+	custom := errors.New("invalid")
+	err = New().UnmarshalValidated(&database, func(any) error { return custom })
+	assert.ErrorIs(t, err, custom)
+}
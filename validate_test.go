@@ -0,0 +1,111 @@
+package viper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddConfigValidator(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/valid.yaml", []byte("db:\n  host: localhost\n  port: 5432\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/missing.yaml", []byte("db:\n  port: 5432\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/invalid.yaml", []byte("db:\n  host: localhost\n  port: nope\n"), 0o644))
+
+	type config struct {
+		DB struct {
+			Host string
+			Port int
+		}
+	}
+
+	v := New()
+	v.SetFs(fs)
+	v.AddConfigValidator(RequireKeys("db.host"))
+	v.AddConfigValidator(ValidateUnmarshal(&config{}))
+
+	v.SetConfigFile("/etc/app/valid.yaml")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "localhost", v.GetString("db.host"))
+
+	version, _ := v.Version()
+
+	for _, file := range []string{"/etc/app/missing.yaml", "/etc/app/invalid.yaml"} {
+		v.SetConfigFile(file)
+
+		err := v.ReadInConfig()
+		require.Error(t, err, file)
+		assert.ErrorAs(t, err, &ConfigValidationError{}, file)
+
+		// the previous configuration is kept
+		assert.Equal(t, "localhost", v.GetString("db.host"), file)
+		assert.Equal(t, 5432, v.GetInt("db.port"), file)
+
+		newVersion, _ := v.Version()
+		assert.Equal(t, version, newVersion, file)
+	}
+}
+
+func TestValidateUnmarshal_NotAPointer(t *testing.T) {
+	v := New()
+
+	assert.Error(t, ValidateUnmarshal(struct{}{})(v))
+}
+
+func TestWatchConfig_ValidationRollback(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: bar\n"), 0o640))
+
+	v := NewWithOptions(WithWatchDebounce(100 * time.Millisecond))
+	v.SetConfigFile(configFile)
+	v.AddConfigValidator(func(v *Viper) error {
+		if v.GetString("foo") == "" {
+			return errors.New("foo is empty")
+		}
+
+		return nil
+	})
+	require.NoError(t, v.ReadInConfig())
+
+	// settings are read from the watcher goroutine to avoid racing with reloads
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+
+	reloadErrors := make(chan string, 10)
+	v.OnConfigReloadError(func(err error) {
+		reloadErrors <- v.GetString("foo")
+	})
+
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: \"\"\n"), 0o640))
+
+	select {
+	case got := <-reloadErrors:
+		assert.Equal(t, "bar", got, "the previous configuration is kept")
+	case got := <-changes:
+		t.Fatalf("unexpected config change: %q", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload error")
+	}
+
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: baz\n"), 0o640))
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "baz", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+}
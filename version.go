@@ -0,0 +1,69 @@
+package viper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// configVersion tracks the generation of the effective configuration.
+type configVersion struct {
+	mu      sync.Mutex
+	version uint64
+	hash    string
+}
+
+// Version returns the generation of the effective configuration and its content hash
+// (hex encoded SHA-256 of all settings).
+//
+// The version starts at zero and is incremented every time reading or merging configuration
+// (from files, readers, maps or remote providers) changes the effective configuration,
+// so that components can log or propagate which configuration they are running with.
+// Values set with Set, SetDefault or bound from flags and environment variables
+// are taken into account the next time configuration is read.
+func Version() (uint64, string) { return v.Version() }
+
+func (v *Viper) Version() (uint64, string) {
+	v.version.mu.Lock()
+	defer v.version.mu.Unlock()
+
+	return v.version.version, v.version.hash
+}
+
+// updateVersion recomputes the content hash of the effective configuration
+// and increments the version if it changed, refreshing the structs bound with BindStructLive.
+func (v *Viper) updateVersion() (uint64, string) {
+	sum := hashSettings(v.AllSettings())
+
+	v.version.mu.Lock()
+
+	changed := sum != v.version.hash
+	if changed {
+		v.version.version++
+		v.version.hash = sum
+	}
+
+	version, hash := v.version.version, v.version.hash
+
+	v.version.mu.Unlock()
+
+	if changed {
+		v.refreshLiveStructs()
+	}
+
+	return version, hash
+}
+
+func hashSettings(settings map[string]any) string {
+	h := sha256.New()
+
+	// JSON encodes maps with sorted keys, so equal settings produce equal hashes
+	if err := json.NewEncoder(h).Encode(settings); err != nil {
+		h.Reset()
+		fmt.Fprintf(h, "%v", settings)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
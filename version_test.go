@@ -0,0 +1,66 @@
+package viper
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+
+	version, hash := v.Version()
+	assert.Equal(t, uint64(0), version)
+	assert.Empty(t, hash)
+
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("foo: bar\n")))
+
+	version, hash = v.Version()
+	assert.Equal(t, uint64(1), version)
+	assert.NotEmpty(t, hash)
+
+	// Reading the same configuration again does not create a new version
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("foo: bar\n")))
+
+	version, sameHash := v.Version()
+	assert.Equal(t, uint64(1), version)
+	assert.Equal(t, hash, sameHash)
+
+	require.NoError(t, v.MergeConfigMap(map[string]any{"baz": "qux"}))
+
+	version, newHash := v.Version()
+	assert.Equal(t, uint64(2), version)
+	assert.NotEqual(t, hash, newHash)
+}
+
+func TestVersion_RemoteEvent(t *testing.T) {
+	fake := withFakeRemoteConfig(t, nil)
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", "/config/app.json"))
+
+	events := make(chan RemoteEvent)
+	v.OnRemoteConfigChange(func(e RemoteEvent) {
+		events <- e
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, v.WatchRemoteConfigOnChannelContext(ctx))
+
+	fake.responses <- &RemoteResponse{Value: []byte(`{"foo": "bar"}`)}
+
+	event := <-events
+	require.NoError(t, event.Error)
+
+	version, hash := v.Version()
+	assert.Equal(t, uint64(1), version)
+	assert.Equal(t, version, event.Version)
+	assert.Equal(t, hash, event.Hash)
+}
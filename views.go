@@ -0,0 +1,114 @@
+package viper
+
+import (
+	"strings"
+
+	"github.com/spf13/viper/internal/maputil"
+)
+
+// View is a read-only view of a configuration.
+type View interface {
+	Get(key string) any
+	GetString(key string) string
+	GetBool(key string) bool
+	GetInt(key string) int
+	IsSet(key string) bool
+	AllKeys() []string
+	AllSettings() map[string]any
+	Unmarshal(rawVal any, opts ...DecoderConfigOption) error
+	UnmarshalKey(key string, rawVal any, opts ...DecoderConfigOption) error
+}
+
+// FlagsOnly returns a view of the values of the changed flags (see BindPFlag),
+// eg. to write back to a config file only what was set on the command line.
+//
+// Like the other single source views (EnvOnly, FileOnly and DefaultsOnly),
+// it is a snapshot of the known keys (see AllKeys) taken when it is created.
+func FlagsOnly() View { return v.FlagsOnly() }
+
+func (v *Viper) FlagsOnly() View {
+	return v.sourceView(func(key string, _ []string) any {
+		if flag, ok := v.boundFlag(key); ok && flag.HasChanged() {
+			return flagValueOf(flag)
+		}
+
+		return nil
+	})
+}
+
+// EnvOnly returns a view of the values read from environment variables (see FlagsOnly).
+func EnvOnly() View { return v.EnvOnly() }
+
+func (v *Viper) EnvOnly() View {
+	return v.sourceView(func(key string, path []string) any {
+		val, _ := v.findEnv(key, path)
+
+		return val
+	})
+}
+
+// FileOnly returns a view of the values read from config files (see FlagsOnly).
+func FileOnly() View { return v.FileOnly() }
+
+func (v *Viper) FileOnly() View {
+	config := v.config.load()
+
+	return v.sourceView(func(key string, path []string) any {
+		return v.searchIndexableWithPathPrefixes(config, v.configPath(key, path))
+	})
+}
+
+// DefaultsOnly returns a view of the default values (see FlagsOnly).
+func DefaultsOnly() View { return v.DefaultsOnly() }
+
+func (v *Viper) DefaultsOnly() View {
+	return v.sourceView(func(_ string, path []string) any {
+		return v.searchMap(v.defaults, path)
+	})
+}
+
+// sourceView returns a view of the values found by find for the known keys.
+func (v *Viper) sourceView(find func(key string, path []string) any) View {
+	settings := make(map[string]any)
+
+	for _, key := range v.AllKeys() {
+		key = v.realKey(key)
+		path := strings.Split(key, v.keyDelim)
+
+		val := find(key, path)
+		if val == nil {
+			continue
+		}
+
+		deepestMap := maputil.DeepSearch(settings, path[:len(path)-1])
+		deepestMap[path[len(path)-1]] = val
+	}
+
+	snapshot := New()
+	snapshot.keyDelim = v.keyDelim
+	snapshot.config.store(settings)
+
+	return view{snapshot}
+}
+
+// view exposes the read methods of a Viper only,
+// so that views cannot be converted back to a Viper and modified.
+type view struct {
+	v *Viper
+}
+
+func (w view) Get(key string) any          { return w.v.Get(key) }
+func (w view) GetString(key string) string { return w.v.GetString(key) }
+func (w view) GetBool(key string) bool     { return w.v.GetBool(key) }
+func (w view) GetInt(key string) int       { return w.v.GetInt(key) }
+func (w view) IsSet(key string) bool       { return w.v.IsSet(key) }
+func (w view) AllKeys() []string           { return w.v.AllKeys() }
+func (w view) AllSettings() map[string]any { return w.v.AllSettings() }
+
+func (w view) Unmarshal(rawVal any, opts ...DecoderConfigOption) error {
+	return w.v.Unmarshal(rawVal, opts...)
+}
+
+func (w view) UnmarshalKey(key string, rawVal any, opts ...DecoderConfigOption) error {
+	return w.v.UnmarshalKey(key, rawVal, opts...)
+}
@@ -0,0 +1,67 @@
+package viper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceViews(t *testing.T) {
+	t.Setenv("APP_SERVER_HOST", "env.internal")
+
+	v := New()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	v.SetDefault("server.port", 80)
+	v.SetDefault("log.level", "info")
+
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+server:
+  host: file.internal
+  port: 8080
+`)))
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.String("log.level", "warn", "test")
+	flagSet.Bool("debug", false, "test")
+	require.NoError(t, v.BindPFlags(flagSet))
+	require.NoError(t, flagSet.Parse([]string{"--log.level", "debug"}))
+
+	flags := v.FlagsOnly()
+	assert.Equal(t, map[string]any{"log": map[string]any{"level": "debug"}}, flags.AllSettings())
+	assert.False(t, flags.IsSet("debug"))
+
+	env := v.EnvOnly()
+	assert.Equal(t, map[string]any{"server": map[string]any{"host": "env.internal"}}, env.AllSettings())
+
+	file := v.FileOnly()
+	assert.Equal(t, "file.internal", file.GetString("server.host"))
+	assert.Equal(t, 8080, file.GetInt("server.port"))
+	assert.ElementsMatch(t, []string{"server.host", "server.port"}, file.AllKeys())
+
+	defaults := v.DefaultsOnly()
+	assert.Equal(t, map[string]any{
+		"server": map[string]any{"port": 80},
+		"log":    map[string]any{"level": "info"},
+	}, defaults.AllSettings())
+
+	var config struct {
+		Log struct{ Level string }
+	}
+	require.NoError(t, flags.Unmarshal(&config))
+	assert.Equal(t, "debug", config.Log.Level)
+
+	v.Set("server.host", "override")
+	assert.Equal(t, "env.internal", env.GetString("server.host"), "views are snapshots")
+
+	for _, view := range []View{flags, env, file, defaults} {
+		_, ok := view.(*Viper)
+		assert.False(t, ok, "views are read-only")
+	}
+}
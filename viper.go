@@ -21,10 +21,13 @@ package viper
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -33,6 +36,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -42,6 +46,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/spf13/viper/internal/features"
+	"github.com/spf13/viper/internal/maputil"
 )
 
 // ConfigMarshalError happens when failing to marshal the configuration.
@@ -104,6 +109,15 @@ func DecodeHook(hook mapstructure.DecodeHookFunc) DecoderConfigOption {
 	}
 }
 
+// StrictTypes returns a DecoderConfigOption which disables weakly typed input,
+// so type mismatches (eg. "1" decoded into an int) return an error
+// instead of being silently converted.
+func StrictTypes() DecoderConfigOption {
+	return func(c *mapstructure.DecoderConfig) {
+		c.WeaklyTypedInput = false
+	}
+}
+
 // Viper is a prioritized configuration registry. It
 // maintains a set of configuration sources, fetches
 // values to populate those, and provides them according
@@ -154,7 +168,20 @@ type Viper struct {
 	finder Finder
 
 	// A set of remote providers to search for the configuration
-	remoteProviders []*defaultRemoteProvider
+	remoteProviders     []*defaultRemoteProvider
+	remoteMounts        []*defaultRemoteProvider
+	remoteLayers        []*defaultRemoteProvider
+	remoteLayerConfigs  map[*defaultRemoteProvider]map[string]any
+	remoteLayerKeys     []string
+	remotePrefixKeys    map[RemoteProvider][][]string
+	remoteWatchDebounce time.Duration
+	remoteCacheDir      string
+	remoteCacheTTL      time.Duration
+	remoteStale         bool
+	remoteStatus        remoteStatusRegistry
+
+	remoteVerifier        RemoteVerifier
+	remoteSignatureSuffix string
 
 	// Name of file to look for inside the path
 	configName        string
@@ -162,29 +189,95 @@ type Viper struct {
 	configType        string
 	configPermissions os.FileMode
 	envPrefix         string
-
-	automaticEnvApplied bool
-	envKeyReplacer      StringReplacer
-	allowEmptyEnv       bool
+	envPrefixes       map[string]string
+	envPrefixBindings map[string]string
+
+	// Config files read by ReadInConfig and MergeInConfig, in order
+	configSources       []configSource
+	configFragmentDirs  []string
+	configFileOptional  bool
+	caseSensitiveConfig bool
+	configValidators    []ConfigValidator
+	parseCache          bool
+
+	automaticEnvApplied  bool
+	automaticEnvStrict   bool
+	automaticEnvPrefixes []string
+	envFallbackPrefixes  []string
+	envKeyReplacer       StringReplacer
+	envKeyTransformer    func(key string) string
+	envNestingSeparator  string
+	envFileSuffix        string
+	envLookup            func(key string) (string, bool)
+	envSnapshotEnabled   bool
+	envSnapshot          atomic.Pointer[map[string]string]
+	indexedEnv           bool
+	jsonEnv              bool
+	jsonEnvKeys          []string
+	expansionSources     []ExpansionSource
+	allowEmptyEnv        bool
+	dotenv               map[string]string
 
 	parents        []string
-	config         map[string]any
+	config         configLayer
 	override       map[string]any
 	defaults       map[string]any
-	kvstore        map[string]any
+	kvstore        configLayer
+	facts          map[string]any
 	pflags         map[string]FlagValue
 	env            map[string][]string
+	envAllowEmpty  map[string]bool
 	aliases        map[string]string
+	visibility     map[string]Visibility
 	typeByDefValue bool
-
-	onConfigChange func(fsnotify.Event)
+	keyTypes       map[string]reflect.Type
+
+	ignoreUnchangedFlagDefaults bool
+	flagNameMapper              func(flagName string) string
+	deprecatedFlagAliases       bool
+	deprecatedKeys              map[string]string
+	lazyFlagBinding             bool
+	lazyFlagSets                []lazyFlagSet
+	unboundFlags                map[string]bool
+	nestedMapFlags              bool
+	structDefaults              bool
+	validator                   func(rawVal any) error
+	decodeHooks                 []mapstructure.DecodeHookFunc
+	base64Fields                bool
+	textUnmarshalerDecoding     bool
+	typedDecoders               map[string]map[string]reflect.Type
+	ignoredUnusedKeys           []string
+
+	onConfigChange       func(fsnotify.Event)
+	onConfigChangeDiff   func([]KeyChange)
+	onConfigReloadError  func(error)
+	onRemoteConfigChange func(RemoteEvent)
+
+	mergeConflictHandler func(key string, oldV, newV any) any
 
 	logger *slog.Logger
 
 	encoderRegistry EncoderRegistry
 	decoderRegistry DecoderRegistry
 
-	decodeHook mapstructure.DecodeHookFunc
+	decodeHook     mapstructure.DecodeHookFunc
+	strictDecoding bool
+	decoderOptions []DecoderConfigOption
+
+	integerDurationUnit time.Duration
+	timeLayouts         []string
+	timeLocation        *time.Location
+
+	version              configVersion
+	liveStructsMu        sync.Mutex
+	liveStructs          []*liveStruct
+	watches              configWatches
+	watchDebounce        time.Duration
+	watchPollInterval    time.Duration
+	fileWatcher          Watcher
+	skipUnchangedReloads bool
+	reloadInterval       time.Duration
+	reloadBurst          int
 
 	experimentalFinder     bool
 	experimentalBindStruct bool
@@ -197,11 +290,12 @@ func New() *Viper {
 	v.configName = "config"
 	v.configPermissions = os.FileMode(0o644)
 	v.fs = afero.NewOsFs()
-	v.config = make(map[string]any)
+	v.config.store(make(map[string]any))
 	v.parents = []string{}
 	v.override = make(map[string]any)
 	v.defaults = make(map[string]any)
-	v.kvstore = make(map[string]any)
+	v.kvstore.store(make(map[string]any))
+	v.facts = make(map[string]any)
 	v.pflags = make(map[string]FlagValue)
 	v.env = make(map[string][]string)
 	v.aliases = make(map[string]string)
@@ -258,6 +352,81 @@ func EnvKeyReplacer(r StringReplacer) Option {
 	})
 }
 
+// WithEnvFileSuffix makes environment variables bound with BindEnv or AutomaticEnv
+// readable from files: when a variable is not set but its sibling with the given suffix is,
+// the value is read from the file it names, with leading and trailing whitespace removed.
+//
+// This supports the way Docker and Kubernetes distribute secrets as files,
+// eg. with the "_FILE" suffix DB_PASSWORD is read from the file named by DB_PASSWORD_FILE.
+func WithEnvFileSuffix(suffix string) Option {
+	return optionFunc(func(v *Viper) {
+		v.envFileSuffix = suffix
+	})
+}
+
+// WithEnvLookup replaces os.LookupEnv as the source of environment variables
+// (read by BindEnv, AutomaticEnv and value expansion), eg. to provide a fixed environment in tests
+// or to read variables from another store.
+// Lists of indexed variables (see WithIndexedEnv) are not read from the process environment then,
+// as lookup cannot enumerate variables.
+func WithEnvLookup(lookup func(key string) (string, bool)) Option {
+	return optionFunc(func(v *Viper) {
+		v.envLookup = lookup
+	})
+}
+
+// IgnoreUnchangedFlagDefaults makes bound flags take part in the configuration only when they are changed
+// (eg. set on the command line): the default values of unchanged flags are not returned for their keys,
+// and unchanged flags neither shadow nested keys of other sources nor appear in AllKeys.
+func IgnoreUnchangedFlagDefaults() Option {
+	return optionFunc(func(v *Viper) {
+		v.ignoreUnchangedFlagDefaults = true
+	})
+}
+
+// WithFlagNameMapper sets the function mapping flag names to config keys when binding whole flag sets
+// (see BindPFlags, BindFlagValues, BindPFlagsWithPrefix and BindCobra),
+// eg. to bind the "log-level" flag to the "log.level" key:
+//
+//	viper.NewWithOptions(viper.WithFlagNameMapper(func(name string) string {
+//		return strings.ReplaceAll(name, "-", ".")
+//	}))
+func WithFlagNameMapper(mapper func(flagName string) string) Option {
+	return optionFunc(func(v *Viper) {
+		v.flagNameMapper = mapper
+	})
+}
+
+// flagKey returns the config key a flag is bound to when binding a whole flag set.
+func (v *Viper) flagKey(flagName string) string {
+	if v.flagNameMapper == nil {
+		return flagName
+	}
+
+	return v.flagNameMapper(flagName)
+}
+
+// boundFlags returns the bound flags taking part in the configuration (see IgnoreUnchangedFlagDefaults).
+func (v *Viper) boundFlags() map[string]FlagValue {
+	if !v.ignoreUnchangedFlagDefaults && len(v.lazyFlagSets) == 0 {
+		return v.pflags
+	}
+
+	flags := make(map[string]FlagValue, len(v.pflags))
+	add := func(key string, flag FlagValue) {
+		if _, exists := flags[key]; !exists && (!v.ignoreUnchangedFlagDefaults || flag.HasChanged()) {
+			flags[key] = flag
+		}
+	}
+
+	for key, flag := range v.pflags {
+		add(key, flag)
+	}
+	v.visitLazyFlags(add)
+
+	return flags
+}
+
 // WithDecodeHook sets a default decode hook for mapstructure.
 func WithDecodeHook(h mapstructure.DecodeHookFunc) Option {
 	return optionFunc(func(v *Viper) {
@@ -269,6 +438,90 @@ func WithDecodeHook(h mapstructure.DecodeHookFunc) Option {
 	})
 }
 
+// WithDecodeHooks registers decode hooks for every Unmarshal, UnmarshalKey and UnmarshalExact call
+// of the instance (see RegisterDecodeHook).
+func WithDecodeHooks(hooks ...mapstructure.DecodeHookFunc) Option {
+	return optionFunc(func(v *Viper) {
+		for _, hook := range hooks {
+			v.RegisterDecodeHook(hook)
+		}
+	})
+}
+
+// RegisterDecodeHook registers a decode hook for every Unmarshal, UnmarshalKey and UnmarshalExact call,
+// eg. so that a library can decode its own types from the configuration of an application.
+//
+// Registered hooks run in the order they were registered, before the default decode hook
+// (see WithDecodeHook) or the hook set by the options of the call (see DecodeHook).
+func RegisterDecodeHook(hook mapstructure.DecodeHookFunc) { v.RegisterDecodeHook(hook) }
+
+func (v *Viper) RegisterDecodeHook(hook mapstructure.DecodeHookFunc) {
+	if hook == nil {
+		return
+	}
+
+	v.decodeHooks = append(v.decodeHooks, hook)
+}
+
+// WithStrictDecoding disables weakly typed input for every Unmarshal call of the instance.
+// See StrictTypes.
+func WithStrictDecoding() Option {
+	return optionFunc(func(v *Viper) {
+		v.strictDecoding = true
+	})
+}
+
+// WithDefaultDecoderOptions sets DecoderConfigOptions applied to every Unmarshal, UnmarshalKey and UnmarshalExact call
+// of the instance. Options passed to the call itself are applied afterwards and take precedence.
+func WithDefaultDecoderOptions(opts ...DecoderConfigOption) Option {
+	return optionFunc(func(v *Viper) {
+		v.decoderOptions = append(v.decoderOptions, opts...)
+	})
+}
+
+// WithIntegerDurationUnit sets the unit of durations configured as plain numbers (eg. 30 or "30"),
+// which are otherwise interpreted as nanoseconds.
+// It applies to GetDuration and to time.Duration fields decoded by Unmarshal.
+func WithIntegerDurationUnit(unit time.Duration) Option {
+	return optionFunc(func(v *Viper) {
+		v.integerDurationUnit = unit
+	})
+}
+
+// WithTimeLayouts sets the layouts (see time.Parse) of timestamps configured as strings,
+// tried in order before the formats understood by default (eg. RFC3339).
+// It applies to GetTime and to time.Time fields decoded by Unmarshal.
+func WithTimeLayouts(layouts ...string) Option {
+	return optionFunc(func(v *Viper) {
+		v.timeLayouts = append(v.timeLayouts, layouts...)
+	})
+}
+
+// WithTimeLocation sets the location of timestamps configured as strings without a time zone,
+// which are otherwise in UTC.
+// It applies to GetTime and to time.Time fields decoded by Unmarshal.
+func WithTimeLocation(loc *time.Location) Option {
+	return optionFunc(func(v *Viper) {
+		v.timeLocation = loc
+	})
+}
+
+// WithMergeConflictHandler sets a handler that is called during MergeConfig, MergeConfigMap and MergeInConfig
+// whenever the merged configuration would replace an existing value
+// (including changing its type, eg. a nested map to a scalar).
+// The handler receives the full key and both values, and returns the value to keep:
+// return newV to accept the change, oldV to veto it, or any other value to customize the resolution.
+// It is not called when config files are reloaded (eg. by WatchConfig) nor when remote layers are merged.
+func WithMergeConflictHandler(h func(key string, oldV, newV any) any) Option {
+	return optionFunc(func(v *Viper) {
+		if h == nil {
+			return
+		}
+
+		v.mergeConflictHandler = h
+	})
+}
+
 // NewWithOptions creates a new Viper instance.
 func NewWithOptions(opts ...Option) *Viper {
 	v := New()
@@ -311,76 +564,367 @@ func (v *Viper) OnConfigChange(run func(in fsnotify.Event)) {
 	v.onConfigChange = run
 }
 
+// OnConfigChangeDiff sets the event handler that is called with the keys that changed
+// when a config file changes.
+func OnConfigChangeDiff(run func(changes []KeyChange)) { v.OnConfigChangeDiff(run) }
+
+// OnConfigChangeDiff sets the event handler that is called with the keys that changed
+// when a config file changes.
+//
+// Changes are computed by comparing every setting before and after the config files are read again,
+// and are sorted by key. The handler is not called when no setting changed.
+func (v *Viper) OnConfigChangeDiff(run func(changes []KeyChange)) {
+	v.onConfigChangeDiff = run
+}
+
 // WatchConfig starts watching a config file for changes.
+//
+// Deprecated: use WatchConfigE, which returns the setup errors that WatchConfig only logs.
 func WatchConfig() { v.WatchConfig() }
 
-// WatchConfig starts watching a config file for changes.
+// WatchConfig starts watching a config file for changes, like WatchConfigE,
+// and logs the error when watching cannot be set up.
+//
+// Deprecated: use WatchConfigE, which returns the setup errors that WatchConfig only logs.
 func (v *Viper) WatchConfig() {
+	if err := v.WatchConfigE(); err != nil {
+		v.logger.Error(fmt.Sprintf("watch config: %s", err))
+	}
+}
+
+// WatchConfigE starts watching a config file for changes.
+func WatchConfigE() error { return v.WatchConfigE() }
+
+// WatchConfigE starts watching a config file for changes.
+//
+// Every config file that contributed to the current configuration
+// (read by ReadInConfig, then merged by MergeInConfig) is watched.
+// When any of them changes, all of them are read and merged again, in the same order.
+//
+// An error is returned when the config file cannot be found or the watcher cannot be set up,
+// in which case nothing is watched.
+//
+// Use StopWatch to stop watching.
+func (v *Viper) WatchConfigE() error {
+	return v.WatchConfigWithContext(context.Background())
+}
+
+// WatchConfigWithContext starts watching a config file for changes until ctx is cancelled.
+func WatchConfigWithContext(ctx context.Context) error { return v.WatchConfigWithContext(ctx) }
+
+// WatchConfigWithContext starts watching a config file for changes, like WatchConfigE.
+//
+// The watcher is released and its goroutine exits when ctx is cancelled (or when StopWatch is called),
+// so that watching can be tied to the lifecycle of a server or an errgroup.
+func (v *Viper) WatchConfigWithContext(ctx context.Context) error {
+	watch := v.watches.add()
+
+	var setupErr error
+
 	initWG := sync.WaitGroup{}
 	initWG.Add(1)
 	go func() {
-		watcher, err := fsnotify.NewWatcher()
+		defer close(watch.done)
+
+		watcher, err := v.newFileWatcher()
 		if err != nil {
-			v.logger.Error(fmt.Sprintf("failed to create watcher: %s", err))
-			os.Exit(1)
+			setupErr = fmt.Errorf("create watcher: %w", err)
+			initWG.Done()
+			return
 		}
 		defer watcher.Close()
+
+		sources := slices.Clone(v.configSources)
+		if len(sources) == 0 {
+			filename, err := v.getConfigFile()
+			if err != nil {
+				setupErr = fmt.Errorf("get config file: %w", err)
+				initWG.Done()
+				return
+			}
+
+			sources = []configSource{{file: filename}}
+		}
+
 		// we have to watch the entire directory to pick up renames/atomic saves in a cross-platform way
-		filename, err := v.getConfigFile()
-		if err != nil {
-			v.logger.Error(fmt.Sprintf("get config file: %s", err))
-			initWG.Done()
-			return
+		configFiles := make([]string, len(sources))
+		realConfigFiles := make([]string, len(sources))
+		configDirs := make([]string, 0, len(sources))
+		fragmentDirs := make([]string, 0, len(sources))
+		dataLinks := make([]string, len(sources))
+
+		for i, source := range sources {
+			configFiles[i] = filepath.Clean(source.file)
+			realConfigFiles[i], _ = filepath.EvalSymlinks(source.file)
+
+			configDir, _ := filepath.Split(configFiles[i])
+			if source.dir {
+				// fragment directories are watched themselves to pick up added and removed fragments
+				configDir = configFiles[i]
+				fragmentDirs = append(fragmentDirs, configDir)
+			}
+			if !slices.Contains(configDirs, configDir) {
+				configDirs = append(configDirs, configDir)
+			}
+
+			// the directory of the real config file is watched as well when the config file is a symlink,
+			// to pick up changes made to the real file
+			if configDir := symlinkTargetDir(configFiles[i], realConfigFiles[i]); !source.dir && configDir != "" && !slices.Contains(configDirs, configDir) {
+				configDirs = append(configDirs, configDir)
+			}
+
+			// the directory of the "..data" symlink of Kubernetes ConfigMap and Secret volumes is watched
+			// to pick up the symlink being swapped
+			if !source.dir {
+				dataLinks[i] = kubernetesDataLinkOf(configFiles[i])
+			}
+			if mountDir, _ := filepath.Split(dataLinks[i]); mountDir != "" && !slices.Contains(configDirs, mountDir) {
+				configDirs = append(configDirs, mountDir)
+			}
+		}
+
+		// directories currently watched, to watch them again when they are replaced
+		watchedDirs := make(map[string]bool, len(configDirs))
+
+		for _, configDir := range configDirs {
+			if err := watcher.Add(configDir); err != nil {
+				// fragment directories are optional
+				if slices.Contains(fragmentDirs, configDir) && errors.Is(err, fs.ErrNotExist) {
+					v.logger.Warn("config fragment directory does not exist, not watching it", "dir", configDir)
+
+					continue
+				}
+
+				setupErr = fmt.Errorf("watch %s: %w", configDir, err)
+				initWG.Done()
+				return
+			}
+
+			watchedDirs[filepath.Clean(configDir)] = true
 		}
 
-		configFile := filepath.Clean(filename)
-		configDir, _ := filepath.Split(configFile)
-		realConfigFile, _ := filepath.EvalSymlinks(filename)
+		watchDir := func(dir string) {
+			if watchedDirs[dir] {
+				return
+			}
+
+			if err := watcher.Add(dir); err != nil {
+				v.logger.Warn(fmt.Sprintf("watch config directory: %s", err), "dir", dir)
+
+				return
+			}
+
+			watchedDirs[dir] = true
+		}
+
+		// content hash of the config files, when no-op reloads are skipped
+		var contentHash string
+		if v.skipUnchangedReloads {
+			contentHash, _ = v.hashConfigSources(sources)
+		}
+
+		reload := func(event fsnotify.Event) {
+			if v.skipUnchangedReloads {
+				hash, err := v.hashConfigSources(sources)
+				if err == nil && hash == contentHash {
+					v.logger.Debug("config files are unchanged, skipping reload", "event", event.String())
+
+					return
+				}
+
+				contentHash = hash
+			}
+
+			var before map[string]any
+			if v.onConfigChangeDiff != nil {
+				before = v.flatSettings()
+			}
+
+			var err error
+			if len(sources) > 1 {
+				err = v.reloadConfigSources(sources)
+			} else {
+				err = v.ReadInConfig()
+			}
+			if err != nil {
+				v.logger.Error(fmt.Sprintf("read config file: %s", err))
+
+				// the previous configuration is kept
+				if v.onConfigReloadError != nil {
+					v.onConfigReloadError(err)
+				}
+
+				return
+			}
+			if v.onConfigChange != nil {
+				v.onConfigChange(event)
+			}
+			if v.onConfigChangeDiff != nil {
+				if changes := diffSettings(before, v.flatSettings()); len(changes) > 0 {
+					v.onConfigChangeDiff(changes)
+				}
+			}
+		}
+
+		var (
+			// last change detected while watching was paused (see PauseWatch)
+			held *fsnotify.Event
+
+			// last change detected while reloads were rate limited (see WithReloadRateLimit)
+			limited    *fsnotify.Event
+			limitTimer *time.Timer
+			limitFire  <-chan time.Time
+		)
+
+		limiter := newReloadLimiter(v.reloadInterval, v.reloadBurst)
+
+		handle := func(event fsnotify.Event) {
+			if v.watches.isPaused() {
+				held = &event
+
+				return
+			}
+
+			if delay := limiter.reserve(time.Now()); delay > 0 {
+				limited = &event
+
+				if limitTimer == nil {
+					limitTimer = time.NewTimer(delay)
+					limitFire = limitTimer.C
+				}
+
+				return
+			}
+
+			reload(event)
+		}
 
 		eventsWG := sync.WaitGroup{}
 		eventsWG.Add(1)
 		go func() {
+			var (
+				pending  fsnotify.Event
+				debounce *time.Timer
+				fire     <-chan time.Time
+			)
+
+			defer func() {
+				if debounce != nil {
+					debounce.Stop()
+				}
+				if limitTimer != nil {
+					limitTimer.Stop()
+				}
+
+				eventsWG.Done()
+			}()
+
 			for {
 				select {
-				case event, ok := <-watcher.Events:
+				case <-watch.stop:
+					return
+
+				case <-ctx.Done():
+					return
+
+				case reloadHeld := <-watch.resume:
+					// watching may have been paused again in the meantime
+					if held != nil && !v.watches.isPaused() {
+						event := *held
+						held = nil
+
+						if reloadHeld {
+							handle(event)
+						}
+					}
+
+				case <-limitFire:
+					event := *limited
+					limited, limitTimer, limitFire = nil, nil, nil
+
+					handle(event)
+
+				case event, ok := <-watcher.Events():
 					if !ok { // 'Events' channel is closed
-						eventsWG.Done()
 						return
 					}
-					currentConfigFile, _ := filepath.EvalSymlinks(filename)
-					// we only care about the config file with the following cases:
-					// 1 - if the config file was modified or created
-					// 2 - if the real path to the config file changed (eg: k8s ConfigMap replacement)
-					if (filepath.Clean(event.Name) == configFile &&
-						(event.Has(fsnotify.Write) || event.Has(fsnotify.Create))) ||
-						(currentConfigFile != "" && currentConfigFile != realConfigFile) {
-						realConfigFile = currentConfigFile
-						err := v.ReadInConfig()
-						if err != nil {
-							v.logger.Error(fmt.Sprintf("read config file: %s", err))
+
+					name := filepath.Clean(event.Name)
+
+					// a watched directory that is removed or renamed is no longer watched: watch it again if it was replaced
+					if watchedDirs[name] && (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) {
+						delete(watchedDirs, name)
+						watchDir(name)
+					}
+
+					changed := false
+					for i, configFile := range configFiles {
+						currentConfigFile, _ := filepath.EvalSymlinks(sources[i].file)
+						touched := name == configFile || (realConfigFiles[i] != "" && name == realConfigFiles[i])
+
+						// we only care about the config files with the following cases:
+						// 1 - if the config file (or its real file) was modified or created
+						// 2 - if the real path to the config file changed, or the "..data" symlink of a k8s ConfigMap was swapped
+						// 3 - if the config file was replaced after being removed or renamed (eg. editors saving atomically)
+						if sources[i].dir {
+							// 4 - if a fragment was added, removed or edited
+							if filepath.Dir(name) == configFile &&
+								isConfigFragment(filepath.Base(name)) && !event.Has(fsnotify.Chmod) {
+								changed = true
+							}
 						}
-						if v.onConfigChange != nil {
-							v.onConfigChange(event)
+
+						if (touched && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create))) ||
+							(dataLinks[i] != "" && name == dataLinks[i] && event.Has(fsnotify.Create)) ||
+							(currentConfigFile != "" && currentConfigFile != realConfigFiles[i]) {
+							if dir := symlinkTargetDir(configFile, currentConfigFile); !sources[i].dir && dir != "" {
+								watchDir(dir)
+							}
+
+							realConfigFiles[i] = currentConfigFile
+							changed = true
+						} else if touched && (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) {
+							// the new version may already be in place, otherwise it is picked up once created
+							if _, err := v.fs.Stat(sources[i].file); err == nil {
+								changed = true
+							}
 						}
-					} else if filepath.Clean(event.Name) == configFile && event.Has(fsnotify.Remove) {
-						eventsWG.Done()
-						return
 					}
 
-				case err, ok := <-watcher.Errors:
+					if changed {
+						if v.watchDebounce <= 0 {
+							handle(event)
+						} else {
+							// wait for the burst of events (eg. an editor saving a file) to settle
+							pending = event
+
+							if debounce != nil {
+								debounce.Stop()
+							}
+							debounce = time.NewTimer(v.watchDebounce)
+							fire = debounce.C
+						}
+					}
+
+				case <-fire:
+					handle(pending)
+
+					fire = nil
+
+				case err, ok := <-watcher.Errors():
 					if ok { // 'Errors' channel is not closed
 						v.logger.Error(fmt.Sprintf("watcher error: %s", err))
 					}
-					eventsWG.Done()
 					return
 				}
 			}
 		}()
-		watcher.Add(configDir)
 		initWG.Done()   // done initializing the watch in this go routine, so the parent routine can move on...
 		eventsWG.Wait() // now, wait for event loop to end in this go-routine...
 	}()
 	initWG.Wait() // make sure that the go routine above fully ended before returning
+
+	return setupErr
 }
 
 // SetConfigFile explicitly defines the path, name and extension of the config file.
@@ -401,9 +945,41 @@ func SetEnvPrefix(in string) { v.SetEnvPrefix(in) }
 func (v *Viper) SetEnvPrefix(in string) {
 	if in != "" {
 		v.envPrefix = in
+		v.envFallbackPrefixes = nil
 	}
 }
 
+// SetEnvPrefixes defines prefixes that ENVIRONMENT variables will use, in order of precedence.
+// E.g. with SetEnvPrefixes("myapp", "legacyapp"), the env registry looks for "MYAPP_FOO" first,
+// then falls back to "LEGACYAPP_FOO", which eases renaming the variables of an application.
+// GetEnvPrefix returns the first prefix.
+func SetEnvPrefixes(prefixes ...string) { v.SetEnvPrefixes(prefixes...) }
+
+func (v *Viper) SetEnvPrefixes(prefixes ...string) {
+	prefixes = slices.DeleteFunc(slices.Clone(prefixes), func(prefix string) bool { return prefix == "" })
+	if len(prefixes) == 0 {
+		return
+	}
+
+	v.envPrefix = prefixes[0]
+	v.envFallbackPrefixes = prefixes[1:]
+}
+
+// SetEnvPrefixFor defines the prefix that ENVIRONMENT variables use for the keys under keyPrefix,
+// instead of the prefix set with SetEnvPrefix.
+// E.g. with SetEnvPrefixFor("database", "db"), the key "database.host" is read from "DB_HOST".
+// When several key prefixes match a key, the longest one is used.
+// An empty envPrefix reads the keys under keyPrefix from unprefixed variables (eg. "HOST").
+func SetEnvPrefixFor(keyPrefix, envPrefix string) { v.SetEnvPrefixFor(keyPrefix, envPrefix) }
+
+func (v *Viper) SetEnvPrefixFor(keyPrefix, envPrefix string) {
+	if v.envPrefixes == nil {
+		v.envPrefixes = make(map[string]string)
+	}
+
+	v.envPrefixes[strings.ToLower(keyPrefix)] = envPrefix
+}
+
 func GetEnvPrefix() string { return v.GetEnvPrefix() }
 
 func (v *Viper) GetEnvPrefix() string {
@@ -411,6 +987,18 @@ func (v *Viper) GetEnvPrefix() string {
 }
 
 func (v *Viper) mergeWithEnvPrefix(in string) string {
+	if v.envKeyTransformer != nil {
+		return v.envKeyTransformer(in)
+	}
+
+	if envPrefix, rest, ok := v.subtreeEnvPrefix(in); ok {
+		if envPrefix == "" || rest == "" {
+			return strings.ToUpper(envPrefix + rest)
+		}
+
+		return strings.ToUpper(envPrefix + "_" + rest)
+	}
+
 	if v.envPrefix != "" {
 		return strings.ToUpper(v.envPrefix + "_" + in)
 	}
@@ -418,6 +1006,52 @@ func (v *Viper) mergeWithEnvPrefix(in string) string {
 	return strings.ToUpper(in)
 }
 
+// mergeWithEnvPrefixes returns the names of the environment variables of in with each env prefix,
+// in order of precedence (see SetEnvPrefixes).
+func (v *Viper) mergeWithEnvPrefixes(in string) []string {
+	names := []string{v.mergeWithEnvPrefix(in)}
+
+	if _, _, ok := v.subtreeEnvPrefix(in); ok || v.envKeyTransformer != nil {
+		return names
+	}
+
+	for _, prefix := range v.envFallbackPrefixes {
+		names = append(names, strings.ToUpper(prefix+"_"+in))
+	}
+
+	return names
+}
+
+// subtreeEnvPrefix returns the env prefix of the longest key prefix of in (see SetEnvPrefixFor),
+// and the rest of in after the key prefix.
+// The parts of in may be separated by the key delimiter, dots or the env nesting separator.
+func (v *Viper) subtreeEnvPrefix(in string) (envPrefix string, rest string, ok bool) {
+	var matched string
+
+	for keyPrefix, prefix := range v.envPrefixes {
+		if len(keyPrefix) <= len(matched) {
+			continue
+		}
+
+		path := strings.Split(keyPrefix, v.keyDelim)
+
+		for _, sep := range []string{v.keyDelim, ".", v.envNestingSeparator} {
+			if sep == "" {
+				continue
+			}
+
+			joined := strings.Join(path, sep)
+			if in == joined || strings.HasPrefix(in, joined+sep) {
+				matched, envPrefix, rest, ok = keyPrefix, prefix, strings.TrimPrefix(in[len(joined):], sep), true
+
+				break
+			}
+		}
+	}
+
+	return envPrefix, rest, ok
+}
+
 // AllowEmptyEnv tells Viper to consider set,
 // but empty environment variables as valid values instead of falling back.
 // For backward compatibility reasons this is false by default.
@@ -435,13 +1069,63 @@ func (v *Viper) AllowEmptyEnv(allowEmptyEnv bool) {
 // key. This allows env vars which have different keys than the config object
 // keys.
 func (v *Viper) getEnv(key string) (string, bool) {
+	return v.getEnvAllowEmpty(key, v.allowEmptyEnv)
+}
+
+// getEnvAllowEmpty is getEnv, with the choice of considering empty variables as set.
+func (v *Viper) getEnvAllowEmpty(key string, allowEmpty bool) (string, bool) {
 	if v.envKeyReplacer != nil {
 		key = v.envKeyReplacer.Replace(key)
 	}
 
-	val, ok := os.LookupEnv(key)
+	val, ok := v.lookupEnv(key)
+	if !ok && v.envFileSuffix != "" {
+		val, ok = v.readEnvFile(key)
+	}
+
+	return val, ok && (allowEmpty || val != "")
+}
+
+func (v *Viper) lookupEnv(key string) (string, bool) {
+	val, ok := v.lookupRealEnv(key)
+	if !ok {
+		// the real environment takes precedence over .env files
+		val, ok = v.dotenv[key]
+	}
+
+	return val, ok
+}
+
+// lookupRealEnv looks up an environment variable, ignoring .env files (see WithEnvLookup).
+func (v *Viper) lookupRealEnv(key string) (string, bool) {
+	if v.envLookup != nil {
+		return v.envLookup(key)
+	}
+
+	if snapshot := v.envSnapshot.Load(); snapshot != nil {
+		val, ok := (*snapshot)[key]
+		return val, ok
+	}
 
-	return val, ok && (v.allowEmptyEnv || val != "")
+	return os.LookupEnv(key)
+}
+
+// readEnvFile reads the value of an environment variable from the file named by its sibling
+// with the env file suffix (see WithEnvFileSuffix).
+func (v *Viper) readEnvFile(key string) (string, bool) {
+	filename, ok := v.lookupEnv(key + v.envFileSuffix)
+	if !ok || filename == "" {
+		return "", false
+	}
+
+	b, err := afero.ReadFile(v.fs, filename)
+	if err != nil {
+		v.logger.Error(fmt.Errorf("read env file: %w", err).Error(), "env", key+v.envFileSuffix)
+
+		return "", false
+	}
+
+	return strings.TrimSpace(string(b)), true
 }
 
 // ConfigFileUsed returns the file used to populate the config registry.
@@ -516,7 +1200,10 @@ func (v *Viper) searchIndexableWithPathPrefixes(source any, path []string) any {
 
 	// search for path prefixes, starting from the longest one
 	for i := len(path); i > 0; i-- {
-		prefixKey := strings.ToLower(strings.Join(path[0:i], v.keyDelim))
+		prefixKey := strings.Join(path[0:i], v.keyDelim)
+		if !v.caseSensitiveConfig {
+			prefixKey = strings.ToLower(prefixKey)
+		}
 
 		var val any
 		switch sourceIndexable := source.(type) {
@@ -580,7 +1267,7 @@ func (v *Viper) searchMapWithPathPrefixes(
 	pathIndex int,
 	path []string,
 ) any {
-	next, ok := sourceMap[prefixKey]
+	next, ok := v.lookupConfigKey(sourceMap, prefixKey)
 	if !ok {
 		return nil
 	}
@@ -664,16 +1351,31 @@ func (v *Viper) isPathShadowedInFlatMap(path []string, mi any) string {
 //
 //	"foo.bar.baz" in a lower-priority map
 func (v *Viper) isPathShadowedInAutoEnv(path []string) string {
-	var parentKey string
 	for i := 1; i < len(path); i++ {
-		parentKey = strings.Join(path[0:i], v.keyDelim)
-		if _, ok := v.getEnv(v.mergeWithEnvPrefix(parentKey)); ok {
-			return parentKey
+		for _, name := range v.autoEnvNames(v.autoEnvKey(path[0:i])) {
+			if _, ok := v.getEnv(name); ok {
+				return strings.Join(path[0:i], v.keyDelim)
+			}
 		}
 	}
 	return ""
 }
 
+// autoEnvKey returns the env key of a key path, before env prefixes are applied.
+func (v *Viper) autoEnvKey(path []string) string {
+	if v.envNestingSeparator != "" {
+		return v.nestedEnvKey(path)
+	}
+
+	return strings.Join(path, v.keyDelim)
+}
+
+// autoEnvNames returns the names of the environment variables checked by automatic env for an env key,
+// in order of precedence.
+func (v *Viper) autoEnvNames(envKey string) []string {
+	return slices.DeleteFunc(v.mergeWithEnvPrefixes(envKey), func(name string) bool { return !v.automaticEnvAllowed(name) })
+}
+
 // SetTypeByDefaultValue enables or disables the inference of a key value's
 // type when the Get function is used based upon a key's default value as
 // opposed to the value returned based on the normal fetch logic.
@@ -694,6 +1396,32 @@ func (v *Viper) SetTypeByDefaultValue(enable bool) {
 	v.typeByDefValue = enable
 }
 
+// SetKeyType sets the type values of a key are converted to by Get and the Get____ methods,
+// whatever their source, without enabling SetTypeByDefaultValue for every key.
+// For example, after SetKeyType("ports", reflect.TypeOf([]int{})), an environment variable
+// set to "80 443" is returned as []int{80, 443}.
+//
+// The supported types are the ones of SetTypeByDefaultValue: bool, string, integers, floats,
+// time.Time, time.Duration, []string, []int and []time.Duration. Values of keys with other types are not converted.
+// Passing a nil type removes the conversion.
+func SetKeyType(key string, typ reflect.Type) { v.SetKeyType(key, typ) }
+
+func (v *Viper) SetKeyType(key string, typ reflect.Type) {
+	key = v.realKey(strings.ToLower(key))
+
+	if typ == nil {
+		delete(v.keyTypes, key)
+
+		return
+	}
+
+	if v.keyTypes == nil {
+		v.keyTypes = make(map[string]reflect.Type)
+	}
+
+	v.keyTypes[key] = typ
+}
+
 // GetViper gets the global Viper instance.
 func GetViper() *Viper {
 	return v
@@ -710,11 +1438,17 @@ func Get(key string) any { return v.Get(key) }
 
 func (v *Viper) Get(key string) any {
 	lcaseKey := strings.ToLower(key)
-	val := v.find(lcaseKey, true)
+	v.warnDeprecatedKey(lcaseKey)
+
+	val := v.find(key, true)
 	if val == nil {
 		return nil
 	}
 
+	if typ, ok := v.keyTypes[v.realKey(lcaseKey)]; ok {
+		return v.castByType(val, reflect.Zero(typ).Interface())
+	}
+
 	if v.typeByDefValue {
 		// TODO(bep) this branch isn't covered by a single test.
 		valType := val
@@ -724,39 +1458,68 @@ func (v *Viper) Get(key string) any {
 			valType = defVal
 		}
 
-		switch valType.(type) {
-		case bool:
-			return cast.ToBool(val)
-		case string:
-			return cast.ToString(val)
-		case int32, int16, int8, int:
-			return cast.ToInt(val)
-		case uint:
-			return cast.ToUint(val)
-		case uint32:
-			return cast.ToUint32(val)
-		case uint64:
-			return cast.ToUint64(val)
-		case int64:
-			return cast.ToInt64(val)
-		case float64, float32:
-			return cast.ToFloat64(val)
-		case time.Time:
-			return cast.ToTime(val)
-		case time.Duration:
-			return cast.ToDuration(val)
-		case []string:
-			return cast.ToStringSlice(val)
-		case []int:
-			return cast.ToIntSlice(val)
-		case []time.Duration:
-			return cast.ToDurationSlice(val)
-		}
+		return v.castByType(val, valType)
+	}
+
+	return val
+}
+
+// castByType converts val to the type of valType, when it is one of the types supported by
+// SetTypeByDefaultValue and SetKeyType.
+func (v *Viper) castByType(val any, valType any) any {
+	switch valType.(type) {
+	case bool:
+		return cast.ToBool(val)
+	case string:
+		return cast.ToString(val)
+	case int32, int16, int8, int:
+		return cast.ToInt(val)
+	case uint:
+		return cast.ToUint(val)
+	case uint32:
+		return cast.ToUint32(val)
+	case uint64:
+		return cast.ToUint64(val)
+	case int64:
+		return cast.ToInt64(val)
+	case float64, float32:
+		return cast.ToFloat64(val)
+	case time.Time:
+		return v.toTime(val)
+	case time.Duration:
+		return v.toDuration(val)
+	case []string:
+		return cast.ToStringSlice(val)
+	case []int:
+		return cast.ToIntSlice(splitString(val))
+	case []time.Duration:
+		return cast.ToDurationSlice(splitString(val))
 	}
 
 	return val
 }
 
+// splitString splits string values (eg. read from environment variables) around whitespace,
+// like the conversion of strings to []string.
+func splitString(val any) any {
+	if s, ok := val.(string); ok {
+		return strings.Fields(s)
+	}
+
+	return val
+}
+
+// GetRaw returns the value associated with the key exactly as it is stored,
+// without the type inference and conversions applied by Get and the Get____ methods.
+// GetRaw is case-insensitive for a key and follows the same precedence as Get.
+//
+// The returned value is a deep copy: modifying it does not affect the configuration.
+func GetRaw(key string) any { return v.GetRaw(key) }
+
+func (v *Viper) GetRaw(key string) any {
+	return deepCopyValue(v.find(key, true))
+}
+
 // Sub returns new Viper instance representing a sub tree of this instance.
 // Sub is case-insensitive for a key.
 func Sub(key string) *Viper { return v.Sub(key) }
@@ -772,10 +1535,25 @@ func (v *Viper) Sub(key string) *Viper {
 		subv.parents = append([]string(nil), v.parents...)
 		subv.parents = append(subv.parents, strings.ToLower(key))
 		subv.automaticEnvApplied = v.automaticEnvApplied
+		subv.automaticEnvStrict = v.automaticEnvStrict
+		subv.automaticEnvPrefixes = v.automaticEnvPrefixes
 		subv.envPrefix = v.envPrefix
+		subv.envPrefixes = v.envPrefixes
+		subv.envFallbackPrefixes = v.envFallbackPrefixes
 		subv.envKeyReplacer = v.envKeyReplacer
+		subv.envKeyTransformer = v.envKeyTransformer
+		subv.envNestingSeparator = v.envNestingSeparator
+		subv.envFileSuffix = v.envFileSuffix
+		subv.envLookup = v.envLookup
+		subv.envSnapshotEnabled = v.envSnapshotEnabled
+		subv.envSnapshot.Store(v.envSnapshot.Load())
+		subv.indexedEnv = v.indexedEnv
+		subv.jsonEnv = v.jsonEnv
+		subv.jsonEnvKeys = v.jsonEnvKeys
+		subv.fs = v.fs
 		subv.keyDelim = v.keyDelim
-		subv.config = cast.ToStringMap(data)
+		subv.caseSensitiveConfig = v.caseSensitiveConfig
+		subv.config.store(cast.ToStringMap(data))
 		return subv
 	}
 	return nil
@@ -862,14 +1640,69 @@ func (v *Viper) GetFloat64(key string) float64 {
 func GetTime(key string) time.Time { return v.GetTime(key) }
 
 func (v *Viper) GetTime(key string) time.Time {
-	return cast.ToTime(v.Get(key))
+	return v.toTime(v.Get(key))
+}
+
+func (v *Viper) toTime(val any) time.Time {
+	t, _ := v.toTimeE(val)
+
+	return t
+}
+
+// toTimeE converts a value to a time, honoring the time layouts and location of the instance.
+func (v *Viper) toTimeE(val any) (time.Time, error) {
+	loc := v.timeLocation
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if s, ok := val.(string); ok {
+		for _, layout := range v.timeLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(s), loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	return cast.ToTimeInDefaultLocationE(val, loc)
 }
 
 // GetDuration returns the value associated with the key as a duration.
 func GetDuration(key string) time.Duration { return v.GetDuration(key) }
 
 func (v *Viper) GetDuration(key string) time.Duration {
-	return cast.ToDuration(v.Get(key))
+	return v.toDuration(v.Get(key))
+}
+
+// toDuration casts val to time.Duration,
+// interpreting plain numbers with the configured integer duration unit.
+func (v *Viper) toDuration(val any) time.Duration {
+	if v.integerDurationUnit > 0 {
+		if d, ok := numberToDuration(val, v.integerDurationUnit); ok {
+			return d
+		}
+	}
+
+	return cast.ToDuration(val)
+}
+
+// numberToDuration converts a number (or a string holding a number) to a duration of the given unit.
+func numberToDuration(val any, unit time.Duration) (time.Duration, bool) {
+	switch n := val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return time.Duration(cast.ToInt64(n)) * unit, true
+	case float32, float64:
+		return time.Duration(cast.ToFloat64(n) * float64(unit)), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return time.Duration(f * float64(unit)), true
+	}
+
+	return 0, false
 }
 
 // GetIntSlice returns the value associated with the key as a slice of int values.
@@ -922,30 +1755,69 @@ func UnmarshalKey(key string, rawVal any, opts ...DecoderConfigOption) error {
 }
 
 func (v *Viper) UnmarshalKey(key string, rawVal any, opts ...DecoderConfigOption) error {
-	return decode(v.Get(key), v.defaultDecoderConfig(rawVal, opts...))
+	prefix := strings.ReplaceAll(strings.ToLower(key), v.keyDelim, ".")
+	config := v.defaultDecoderConfig(rawVal, opts...)
+
+	input, err := v.decodeTypedValues(v.Get(key), config, prefix)
+	if err != nil {
+		return err
+	}
+
+	if err := decode(input, config); err != nil {
+		return decodeError(input, config, prefix, err)
+	}
+
+	if err := v.checkRequired(input, config, prefix); err != nil {
+		return err
+	}
+
+	return v.validate(rawVal)
 }
 
 // Unmarshal unmarshals the config into a Struct. Make sure that the tags
 // on the fields of the structure are properly set.
+//
+// Fields tagged with `required:"true"` or `viper:"required"` must have a value:
+// the keys of the missing ones are listed by a ValidationErrors.
 func Unmarshal(rawVal any, opts ...DecoderConfigOption) error {
 	return v.Unmarshal(rawVal, opts...)
 }
 
 func (v *Viper) Unmarshal(rawVal any, opts ...DecoderConfigOption) error {
-	keys := v.AllKeys()
+	config := v.defaultDecoderConfig(rawVal, opts...)
 
-	if v.experimentalBindStruct {
-		// TODO: make this optional?
-		structKeys, err := v.decodeStructKeys(rawVal, opts...)
-		if err != nil {
-			return err
+	// only resolve the keys of the struct when possible
+	settings, ok := v.structSettings(config)
+	if !ok {
+		keys := v.AllKeys()
+
+		if v.experimentalBindStruct {
+			// TODO: make this optional?
+			structKeys, err := v.decodeStructKeys(rawVal, opts...)
+			if err != nil {
+				return err
+			}
+
+			keys = append(keys, structKeys...)
 		}
 
-		keys = append(keys, structKeys...)
+		settings = v.getSettings(keys)
 	}
 
-	// TODO: struct keys should be enough?
-	return decode(v.getSettings(keys), v.defaultDecoderConfig(rawVal, opts...))
+	input, err := v.decodeTypedValues(settings, config, "")
+	if err != nil {
+		return err
+	}
+
+	if err := decode(input, config); err != nil {
+		return decodeError(input, config, "", err)
+	}
+
+	if err := v.checkRequired(input, config, ""); err != nil {
+		return err
+	}
+
+	return v.validate(rawVal)
 }
 
 func (v *Viper) decodeStructKeys(input any, opts ...DecoderConfigOption) ([]string, error) {
@@ -973,21 +1845,56 @@ func (v *Viper) defaultDecoderConfig(output any, opts ...DecoderConfigOption) *m
 	if decodeHook == nil {
 		decodeHook = mapstructure.ComposeDecodeHookFunc(
 			mapstructure.StringToTimeDurationHookFunc(),
+			SizeInBytesHookFunc(),
 			// mapstructure.StringToSliceHookFunc(","),
 			stringToWeakSliceHookFunc(","),
 		)
 	}
 
+	if v.integerDurationUnit > 0 {
+		decodeHook = mapstructure.ComposeDecodeHookFunc(numberToDurationHookFunc(v.integerDurationUnit), decodeHook)
+	}
+
+	if len(v.timeLayouts) > 0 || v.timeLocation != nil {
+		decodeHook = mapstructure.ComposeDecodeHookFunc(v.stringToTimeHookFunc(), decodeHook)
+	}
+
 	c := &mapstructure.DecoderConfig{
 		Metadata:         nil,
-		WeaklyTypedInput: true,
+		WeaklyTypedInput: !v.strictDecoding,
 		DecodeHook:       decodeHook,
 	}
 
+	for _, opt := range v.decoderOptions {
+		opt(c)
+	}
+
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	// hooks of the instance run before the hook of the config,
+	// so that they see values before strings are split into slices
+	var hooks []mapstructure.DecodeHookFunc
+	if v.structDefaults {
+		hooks = append(hooks, v.structDefaultsHookFunc(c))
+	}
+	if v.base64Fields {
+		hooks = append(hooks, v.base64FieldsHookFunc(c))
+	}
+	hooks = append(hooks, v.decodeHooks...)
+	if v.textUnmarshalerDecoding {
+		hooks = append(hooks, v.textUnmarshalerHookFunc())
+	}
+
+	if len(hooks) > 0 {
+		if c.DecodeHook != nil {
+			hooks = append(hooks, c.DecodeHook)
+		}
+
+		c.DecodeHook = mapstructure.ComposeDecodeHookFunc(hooks...)
+	}
+
 	// Do not allow overwriting the output
 	c.Result = output
 
@@ -1016,6 +1923,42 @@ func stringToWeakSliceHookFunc(sep string) mapstructure.DecodeHookFunc {
 	}
 }
 
+// numberToDurationHookFunc returns a DecodeHookFunc that converts numbers
+// (and strings holding a number) to durations of the given unit.
+func numberToDurationHookFunc(unit time.Duration) mapstructure.DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if t != reflect.TypeOf(time.Duration(0)) || f == t {
+			return data, nil
+		}
+
+		if d, ok := numberToDuration(data, unit); ok {
+			return d, nil
+		}
+
+		return data, nil
+	}
+}
+
+// stringToTimeHookFunc returns a decode hook parsing strings into times
+// with the time layouts and location of the instance.
+func (v *Viper) stringToTimeHookFunc() mapstructure.DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(time.Time{}) {
+			return data, nil
+		}
+
+		return v.toTimeE(data)
+	}
+}
+
 // decode is a wrapper around mapstructure.Decode that mimics the WeakDecode functionality.
 func decode(input any, config *mapstructure.DecoderConfig) error {
 	decoder, err := mapstructure.NewDecoder(config)
@@ -1025,16 +1968,32 @@ func decode(input any, config *mapstructure.DecoderConfig) error {
 	return decoder.Decode(input)
 }
 
+// UnmarshalWithMetadata unmarshals the config into a Struct like Unmarshal, and returns the metadata of the decoding:
+// the keys that were decoded, the keys matching no field (Unused) and the fields that were left unset (Unset),
+// eg. to lint the configuration. Paths are reported by mapstructure, with the names of the struct fields
+// (eg. "DB.hosst" for the "hosst" key decoded into the DB field).
+func UnmarshalWithMetadata(rawVal any, opts ...DecoderConfigOption) (mapstructure.Metadata, error) {
+	return v.UnmarshalWithMetadata(rawVal, opts...)
+}
+
+func (v *Viper) UnmarshalWithMetadata(rawVal any, opts ...DecoderConfigOption) (mapstructure.Metadata, error) {
+	var md mapstructure.Metadata
+
+	err := v.Unmarshal(rawVal, append(slices.Clip(opts), func(c *mapstructure.DecoderConfig) {
+		c.Metadata = &md
+	})...)
+
+	return md, err
+}
+
 // UnmarshalExact unmarshals the config into a Struct, erroring if a field is nonexistent
-// in the destination struct.
+// in the destination struct. Keys of the configuration matching no field are reported
+// by an UnknownKeysError, with suggestions of the keys likely meant.
 func UnmarshalExact(rawVal any, opts ...DecoderConfigOption) error {
 	return v.UnmarshalExact(rawVal, opts...)
 }
 
 func (v *Viper) UnmarshalExact(rawVal any, opts ...DecoderConfigOption) error {
-	config := v.defaultDecoderConfig(rawVal, opts...)
-	config.ErrorUnused = true
-
 	keys := v.AllKeys()
 
 	if v.experimentalBindStruct {
@@ -1048,7 +2007,51 @@ func (v *Viper) UnmarshalExact(rawVal any, opts ...DecoderConfigOption) error {
 	}
 
 	// TODO: struct keys should be enough?
-	return decode(v.getSettings(keys), config)
+	return v.decodeExact(v.getSettings(keys), "", rawVal, opts...)
+}
+
+// UnmarshalKeyExact takes a single key and unmarshals it into a Struct, erroring like UnmarshalExact
+// if a nested key is nonexistent in the destination struct.
+// Unknown keys are reported with their full path (eg. "db.hosst" for the "hosst" key of "db").
+func UnmarshalKeyExact(key string, rawVal any, opts ...DecoderConfigOption) error {
+	return v.UnmarshalKeyExact(key, rawVal, opts...)
+}
+
+func (v *Viper) UnmarshalKeyExact(key string, rawVal any, opts ...DecoderConfigOption) error {
+	prefix := strings.ReplaceAll(strings.ToLower(key), v.keyDelim, ".")
+
+	return v.decodeExact(v.Get(key), prefix, rawVal, opts...)
+}
+
+// decodeExact decodes input, the value of the key prefix (or of the whole configuration if empty),
+// into rawVal, erroring on keys that are nonexistent in rawVal.
+func (v *Viper) decodeExact(input any, prefix string, rawVal any, opts ...DecoderConfigOption) error {
+	config := v.defaultDecoderConfig(rawVal, opts...)
+
+	// unused keys are reported from the metadata, as the error of mapstructure lacks their full path
+	if config.Metadata == nil {
+		config.Metadata = &mapstructure.Metadata{}
+	}
+	unused := len(config.Metadata.Unused)
+
+	input, err := v.decodeTypedValues(input, config, prefix)
+	if err != nil {
+		return err
+	}
+
+	if err := decode(input, config); err != nil {
+		return decodeError(input, config, prefix, err)
+	}
+
+	if err := v.unknownKeysError(rawVal, config, prefix, config.Metadata.Unused[unused:]); err != nil {
+		return err
+	}
+
+	if err := v.checkRequired(input, config, prefix); err != nil {
+		return err
+	}
+
+	return v.validate(rawVal)
 }
 
 // BindPFlags binds a full flag set to the configuration, using each flag's long
@@ -1056,7 +2059,53 @@ func (v *Viper) UnmarshalExact(rawVal any, opts ...DecoderConfigOption) error {
 func BindPFlags(flags *pflag.FlagSet) error { return v.BindPFlags(flags) }
 
 func (v *Viper) BindPFlags(flags *pflag.FlagSet) error {
-	return v.BindFlagValues(pflagValueSet{flags})
+	if err := v.BindFlagValues(pflagValueSet{flags}); err != nil {
+		return err
+	}
+
+	v.bindDeprecatedPFlags("", flags)
+	v.retainFlagSet("", flags)
+
+	return nil
+}
+
+// BindGoFlags binds a full flag set of the standard library flag package to the configuration,
+// using each flag's name as the config key (see GoFlagValueSet).
+func BindGoFlags(flags *flag.FlagSet) error { return v.BindGoFlags(flags) }
+
+func (v *Viper) BindGoFlags(flags *flag.FlagSet) error {
+	return v.BindFlagValues(GoFlagValueSet(flags))
+}
+
+// BindPFlagsWithPrefix binds a full flag set to the configuration, using each flag's long
+// name under keyPrefix as the config key.
+// E.g. with the "server" key prefix, the "port" flag is bound to the "server.port" key.
+func BindPFlagsWithPrefix(keyPrefix string, flags *pflag.FlagSet) error {
+	return v.BindPFlagsWithPrefix(keyPrefix, flags)
+}
+
+func (v *Viper) BindPFlagsWithPrefix(keyPrefix string, flags *pflag.FlagSet) (err error) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil {
+			return
+		}
+
+		key := v.flagKey(flag.Name)
+		if keyPrefix != "" {
+			key = keyPrefix + v.keyDelim + key
+		}
+
+		err = v.BindFlagValue(key, pflagValue{flag})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	v.bindDeprecatedPFlags(keyPrefix, flags)
+	v.retainFlagSet(keyPrefix, flags)
+
+	return nil
 }
 
 // BindPFlag binds a specific key to a pflag (as used by cobra).
@@ -1077,13 +2126,26 @@ func (v *Viper) BindPFlag(key string, flag *pflag.Flag) error {
 // name as the config key.
 func BindFlagValues(flags FlagValueSet) error { return v.BindFlagValues(flags) }
 
-func (v *Viper) BindFlagValues(flags FlagValueSet) (err error) {
+func (v *Viper) BindFlagValues(flags FlagValueSet) error {
+	return v.BindFlagValuesFiltered(flags, func(FlagValue) bool { return true })
+}
+
+// BindFlagValuesFiltered binds the flags of a FlagValue set for which keep returns true,
+// like BindFlagValues, eg. to skip the "help" and "version" flags of a shared flag set.
+func BindFlagValuesFiltered(flags FlagValueSet, keep func(FlagValue) bool) error {
+	return v.BindFlagValuesFiltered(flags, keep)
+}
+
+func (v *Viper) BindFlagValuesFiltered(flags FlagValueSet, keep func(FlagValue) bool) (err error) {
 	flags.VisitAll(func(flag FlagValue) {
-		if err = v.BindFlagValue(flag.Name(), flag); err != nil {
+		if err != nil || !keep(flag) {
 			return
 		}
+
+		err = v.BindFlagValue(v.flagKey(flag.Name()), flag)
 	})
-	return nil
+
+	return err
 }
 
 // BindFlagValue binds a specific key to a FlagValue.
@@ -1094,9 +2156,28 @@ func (v *Viper) BindFlagValue(key string, flag FlagValue) error {
 		return fmt.Errorf("flag for %q is nil", key)
 	}
 	v.pflags[strings.ToLower(key)] = flag
+	delete(v.unboundFlags, strings.ToLower(key))
 	return nil
 }
 
+// UnbindFlag removes the flag bound to a key with BindPFlag, BindFlagValue or one of the functions binding flag sets,
+// along with the aliases of the deprecated flags bound to the key (see WithDeprecatedFlagAliases).
+// The flags of retained flag sets and command trees (see WithLazyFlagBinding and BindCobra)
+// are no longer looked up for the key, until a flag is bound to it again.
+func UnbindFlag(key string) { v.UnbindFlag(key) }
+
+func (v *Viper) UnbindFlag(key string) {
+	key = strings.ToLower(key)
+
+	delete(v.pflags, key)
+	v.unbindDeprecatedFlags(key)
+
+	if v.unboundFlags == nil {
+		v.unboundFlags = make(map[string]bool)
+	}
+	v.unboundFlags[key] = true
+}
+
 // BindEnv binds a Viper key to a ENV variable.
 // ENV variables are case sensitive.
 // If only a key is provided, it will use the env key matching the key, uppercased.
@@ -1105,22 +2186,68 @@ func (v *Viper) BindFlagValue(key string, flag FlagValue) error {
 // EnvPrefix will be used when set when env name is not provided.
 func BindEnv(input ...string) error { return v.BindEnv(input...) }
 
-func (v *Viper) BindEnv(input ...string) error {
-	if len(input) == 0 {
-		return fmt.Errorf("missing key to bind to")
+func (v *Viper) BindEnv(input ...string) error {
+	if len(input) == 0 {
+		return fmt.Errorf("missing key to bind to")
+	}
+
+	key := strings.ToLower(input[0])
+
+	if len(input) == 1 {
+		v.env[key] = append(v.env[key], v.mergeWithEnvPrefixes(key)...)
+	} else {
+		v.env[key] = append(v.env[key], input[1:]...)
+	}
+
+	return nil
+}
+
+// EnvOptions configures the binding of a key to environment variables (see BindEnvWithOptions).
+type EnvOptions struct {
+	// Names lists the environment variables bound to the key, in order of precedence.
+	// When empty, the name is derived from the key, as with BindEnv.
+	Names []string
+
+	// AllowEmpty considers set, but empty variables bound to the key as valid values
+	// instead of falling back, whatever AllowEmptyEnv is set to.
+	AllowEmpty bool
+}
+
+// BindEnvWithOptions binds a Viper key to ENV variables, like BindEnv,
+// with options applying to this key only.
+func BindEnvWithOptions(key string, opts EnvOptions) error { return v.BindEnvWithOptions(key, opts) }
+
+func (v *Viper) BindEnvWithOptions(key string, opts EnvOptions) error {
+	if err := v.BindEnv(append([]string{key}, opts.Names...)...); err != nil {
+		return err
 	}
 
-	key := strings.ToLower(input[0])
+	key = strings.ToLower(key)
 
-	if len(input) == 1 {
-		v.env[key] = append(v.env[key], v.mergeWithEnvPrefix(key))
+	if opts.AllowEmpty {
+		if v.envAllowEmpty == nil {
+			v.envAllowEmpty = make(map[string]bool)
+		}
+
+		v.envAllowEmpty[key] = true
 	} else {
-		v.env[key] = append(v.env[key], input[1:]...)
+		delete(v.envAllowEmpty, key)
 	}
 
 	return nil
 }
 
+// UnbindEnv removes the environment variables bound to a key with BindEnv or BindEnvWithOptions.
+// Variables read by AutomaticEnv are not affected.
+func UnbindEnv(key string) { v.UnbindEnv(key) }
+
+func (v *Viper) UnbindEnv(key string) {
+	key = strings.ToLower(key)
+
+	delete(v.env, key)
+	delete(v.envAllowEmpty, key)
+}
+
 // MustBindEnv wraps BindEnv in a panic.
 // If there is an error binding an environment variable, MustBindEnv will
 // panic.
@@ -1141,12 +2268,13 @@ func (v *Viper) MustBindEnv(input ...string) {
 // corresponds to a flag, the flag's default value is returned.
 //
 // Note: this assumes a lower-cased key given.
-func (v *Viper) find(lcaseKey string, flagDefault bool) any {
+func (v *Viper) find(key string, flagDefault bool) any {
 	var (
-		val    any
-		exists bool
-		path   = strings.Split(lcaseKey, v.keyDelim)
-		nested = len(path) > 1
+		val      any
+		exists   bool
+		lcaseKey = strings.ToLower(key)
+		path     = strings.Split(lcaseKey, v.keyDelim)
+		nested   = len(path) > 1
 	)
 
 	// compute the path through the nested maps to the nested value
@@ -1159,7 +2287,16 @@ func (v *Viper) find(lcaseKey string, flagDefault bool) any {
 	path = strings.Split(lcaseKey, v.keyDelim)
 	nested = len(path) > 1
 
-	// Set() override first
+	// Read-only facts first
+	val = v.searchMap(v.facts, path)
+	if val != nil {
+		return val
+	}
+	if nested && v.isPathShadowedInDeepMap(path, v.facts) != "" {
+		return nil
+	}
+
+	// Set() override next
 	val = v.searchMap(v.override, path)
 	if val != nil {
 		return val
@@ -1169,79 +2306,41 @@ func (v *Viper) find(lcaseKey string, flagDefault bool) any {
 	}
 
 	// PFlag override next
-	flag, exists := v.pflags[lcaseKey]
+	flag, exists := v.boundFlag(lcaseKey)
 	if exists && flag.HasChanged() {
-		switch flag.ValueType() {
-		case "int", "int8", "int16", "int32", "int64":
-			return cast.ToInt(flag.ValueString())
-		case "bool":
-			return cast.ToBool(flag.ValueString())
-		case "stringSlice", "stringArray":
-			s := strings.TrimPrefix(flag.ValueString(), "[")
-			s = strings.TrimSuffix(s, "]")
-			res, _ := readAsCSV(s)
-			return res
-		case "intSlice":
-			s := strings.TrimPrefix(flag.ValueString(), "[")
-			s = strings.TrimSuffix(s, "]")
-			res, _ := readAsCSV(s)
-			return cast.ToIntSlice(res)
-		case "durationSlice":
-			s := strings.TrimPrefix(flag.ValueString(), "[")
-			s = strings.TrimSuffix(s, "]")
-			slice := strings.Split(s, ",")
-			return cast.ToDurationSlice(slice)
-		case "stringToString":
-			return stringToStringConv(flag.ValueString())
-		case "stringToInt":
-			return stringToIntConv(flag.ValueString())
-		default:
-			return flag.ValueString()
+		return flagValueOf(flag)
+	}
+	if nested && v.nestedMapFlags {
+		if val := v.searchMapFlags(path, false); val != nil {
+			return val
 		}
 	}
-	if nested && v.isPathShadowedInFlatMap(path, v.pflags) != "" {
+	if nested && v.isPathShadowedInFlatMap(path, v.shadowingFlags()) != "" {
 		return nil
 	}
 
 	// Env override next
-	if v.automaticEnvApplied {
-		envKey := strings.Join(append(v.parents, lcaseKey), ".")
-		// even if it hasn't been registered, if automaticEnv is used,
-		// check any Get request
-		if val, ok := v.getEnv(v.mergeWithEnvPrefix(envKey)); ok {
-			return val
-		}
-		if nested && v.isPathShadowedInAutoEnv(path) != "" {
-			return nil
-		}
-	}
-	envkeys, exists := v.env[lcaseKey]
-	if exists {
-		for _, envkey := range envkeys {
-			if val, ok := v.getEnv(envkey); ok {
-				return val
-			}
-		}
-	}
-	if nested && v.isPathShadowedInFlatMap(path, v.env) != "" {
-		return nil
+	if val, ok := v.findEnv(lcaseKey, path); ok {
+		return val
 	}
 
 	// Config file next
-	val = v.searchIndexableWithPathPrefixes(v.config, path)
+	config := v.config.load()
+	val = v.searchIndexableWithPathPrefixes(config, v.configPath(key, path))
 	if val != nil {
 		return val
 	}
-	if nested && v.isPathShadowedInDeepMap(path, v.config) != "" {
+	if nested && v.isPathShadowedInDeepMap(path, config) != "" {
 		return nil
 	}
 
 	// K/V store next
-	val = v.searchMap(v.kvstore, path)
+	kvstore := v.kvstore.load()
+	val = v.searchMap(kvstore, path)
 	if val != nil {
 		return val
 	}
-	if nested && v.isPathShadowedInDeepMap(path, v.kvstore) != "" {
+	if nested && v.isPathShadowedInDeepMap(path, kvstore) != "" {
 		return nil
 	}
 
@@ -1254,37 +2353,14 @@ func (v *Viper) find(lcaseKey string, flagDefault bool) any {
 		return nil
 	}
 
-	if flagDefault {
+	if flagDefault && !v.ignoreUnchangedFlagDefaults {
 		// last chance: if no value is found and a flag does exist for the key,
 		// get the flag's default value even if the flag's value has not been set.
-		if flag, exists := v.pflags[lcaseKey]; exists {
-			switch flag.ValueType() {
-			case "int", "int8", "int16", "int32", "int64":
-				return cast.ToInt(flag.ValueString())
-			case "bool":
-				return cast.ToBool(flag.ValueString())
-			case "stringSlice", "stringArray":
-				s := strings.TrimPrefix(flag.ValueString(), "[")
-				s = strings.TrimSuffix(s, "]")
-				res, _ := readAsCSV(s)
-				return res
-			case "intSlice":
-				s := strings.TrimPrefix(flag.ValueString(), "[")
-				s = strings.TrimSuffix(s, "]")
-				res, _ := readAsCSV(s)
-				return cast.ToIntSlice(res)
-			case "stringToString":
-				return stringToStringConv(flag.ValueString())
-			case "stringToInt":
-				return stringToIntConv(flag.ValueString())
-			case "durationSlice":
-				s := strings.TrimPrefix(flag.ValueString(), "[")
-				s = strings.TrimSuffix(s, "]")
-				slice := strings.Split(s, ",")
-				return cast.ToDurationSlice(slice)
-			default:
-				return flag.ValueString()
-			}
+		if flag, exists := v.boundFlag(lcaseKey); exists {
+			return flagValueOf(flag)
+		}
+		if nested && v.nestedMapFlags {
+			return v.searchMapFlags(path, true)
 		}
 		// last item, no need to check shadowing
 	}
@@ -1292,6 +2368,66 @@ func (v *Viper) find(lcaseKey string, flagDefault bool) any {
 	return nil
 }
 
+// findEnv looks up the value of a key in the environment, following the same order as find.
+// It reports whether the lookup is over, either because a value is found
+// or because the key is shadowed by a variable of one of its parents.
+func (v *Viper) findEnv(lcaseKey string, path []string) (any, bool) {
+	nested := len(path) > 1
+
+	if v.automaticEnvApplied {
+		envKey := strings.Join(append(v.parents, lcaseKey), ".")
+		if v.envNestingSeparator != "" {
+			envKey = v.nestedEnvKey(path)
+		}
+		// even if it hasn't been registered, if automaticEnv is used,
+		// check any Get request
+		for _, name := range v.autoEnvNames(envKey) {
+			if val, ok := v.getEnv(name); ok {
+				return v.envValue(lcaseKey, val), true
+			}
+			if v.indexedEnv {
+				if val, ok := v.getIndexedEnv(name); ok {
+					return val, true
+				}
+			}
+		}
+		if nested {
+			if parentKey := v.isPathShadowedInAutoEnv(path); parentKey != "" {
+				parentPath := path[:strings.Count(parentKey, v.keyDelim)+1]
+				return v.searchJSONEnv(parentKey, path, v.autoEnvNames(v.autoEnvKey(parentPath))...), true
+			}
+		}
+	}
+	envkeys, exists := v.env[lcaseKey]
+	if exists {
+		for _, envkey := range envkeys {
+			if val, ok := v.getEnvAllowEmpty(envkey, v.allowEmptyEnv || v.envAllowEmpty[lcaseKey]); ok {
+				return v.envValue(lcaseKey, val), true
+			}
+		}
+		if v.indexedEnv {
+			for _, envkey := range envkeys {
+				if val, ok := v.getIndexedEnv(envkey); ok {
+					return val, true
+				}
+			}
+		}
+	}
+	if nested && v.isPathShadowedInFlatMap(path, v.env) != "" {
+		return nil, true
+	}
+	if nested && v.jsonEnv {
+		if val := v.searchBoundJSONEnv(path); val != nil {
+			return val, true
+		}
+	}
+	if val, ok := v.findPrefixBoundEnv(path); ok {
+		return val, true
+	}
+
+	return nil, false
+}
+
 func readAsCSV(val string) ([]string, error) {
 	if val == "" {
 		return []string{}, nil
@@ -1354,8 +2490,7 @@ func stringToIntConv(val string) any {
 func IsSet(key string) bool { return v.IsSet(key) }
 
 func (v *Viper) IsSet(key string) bool {
-	lcaseKey := strings.ToLower(key)
-	val := v.find(lcaseKey, false)
+	val := v.find(key, false)
 	return val != nil
 }
 
@@ -1365,6 +2500,55 @@ func AutomaticEnv() { v.AutomaticEnv() }
 
 func (v *Viper) AutomaticEnv() {
 	v.automaticEnvApplied = true
+	v.automaticEnvStrict = false
+}
+
+// AutomaticEnvStrict is like AutomaticEnv, but only environment variables whose name starts with
+// one of the allowed prefixes (eg. "MYAPP_") are checked, so that unrelated variables
+// (eg. HOSTNAME or PATH) are never mistaken for configuration.
+// Without allowed prefixes, only variables under the env prefix (see SetEnvPrefix) are checked.
+//
+// Keys bound with BindEnv are not restricted.
+func AutomaticEnvStrict(allowedPrefixes ...string) { v.AutomaticEnvStrict(allowedPrefixes...) }
+
+func (v *Viper) AutomaticEnvStrict(allowedPrefixes ...string) {
+	v.automaticEnvApplied = true
+	v.automaticEnvStrict = true
+	v.automaticEnvPrefixes = nil
+
+	for _, prefix := range allowedPrefixes {
+		v.automaticEnvPrefixes = append(v.automaticEnvPrefixes, strings.ToUpper(prefix))
+	}
+}
+
+// automaticEnvAllowed reports whether AutomaticEnv may check an environment variable.
+func (v *Viper) automaticEnvAllowed(name string) bool {
+	if !v.automaticEnvStrict {
+		return true
+	}
+
+	prefixes := v.automaticEnvPrefixes
+	if len(prefixes) == 0 {
+		for _, envPrefix := range append([]string{v.envPrefix}, v.envFallbackPrefixes...) {
+			if envPrefix != "" {
+				prefixes = append(prefixes, strings.ToUpper(envPrefix+"_"))
+			}
+		}
+
+		for _, envPrefix := range v.envPrefixes {
+			if envPrefix != "" {
+				prefixes = append(prefixes, strings.ToUpper(envPrefix+"_"))
+			}
+		}
+	}
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // SetEnvKeyReplacer sets the strings.Replacer on the viper object
@@ -1376,6 +2560,31 @@ func (v *Viper) SetEnvKeyReplacer(r *strings.Replacer) {
 	v.envKeyReplacer = r
 }
 
+// SetEnvKeyTransformer sets the function turning keys into the names of their environment variables
+// for BindEnv and AutomaticEnv, for names a replacer cannot express.
+// It replaces the default naming (env prefixes and upper casing); the env key replacer still applies to its result.
+// The function receives lower cased keys, whose parts are joined by the env nesting separator if one is set.
+// Pass nil to restore the default naming.
+func SetEnvKeyTransformer(transform func(key string) string) { v.SetEnvKeyTransformer(transform) }
+
+func (v *Viper) SetEnvKeyTransformer(transform func(key string) string) {
+	v.envKeyTransformer = transform
+}
+
+// SetEnvNestingSeparator sets the separator AutomaticEnv uses between the parts of nested keys
+// in environment variable names, eg. "__" to read "database.pool.size" from DATABASE__POOL__SIZE.
+// Unlike SetEnvKeyReplacer, single underscores in keys (eg. "log_level") are left untouched.
+func SetEnvNestingSeparator(sep string) { v.SetEnvNestingSeparator(sep) }
+
+func (v *Viper) SetEnvNestingSeparator(sep string) {
+	v.envNestingSeparator = sep
+}
+
+// nestedEnvKey joins the parents of the instance and the path of a key with the env nesting separator.
+func (v *Viper) nestedEnvKey(path []string) string {
+	return strings.Join(append(slices.Clone(v.parents), path...), v.envNestingSeparator)
+}
+
 // RegisterAlias creates an alias that provides another accessor for the same key.
 // This enables one to change a name without breaking the application.
 func RegisterAlias(alias, key string) { v.RegisterAlias(alias, key) }
@@ -1393,13 +2602,19 @@ func (v *Viper) registerAlias(alias, key string) {
 			// if we alias something that exists in one of the maps to another
 			// name, we'll never be able to get that value using the original
 			// name, so move the config value to the new realkey.
-			if val, ok := v.config[alias]; ok {
-				delete(v.config, alias)
-				v.config[key] = val
+			moveAlias := func(settings map[string]any) error {
+				if val, ok := settings[alias]; ok {
+					delete(settings, alias)
+					settings[key] = val
+				}
+
+				return nil
+			}
+			if _, ok := v.config.load()[alias]; ok {
+				v.config.update(moveAlias)
 			}
-			if val, ok := v.kvstore[alias]; ok {
-				delete(v.kvstore, alias)
-				v.kvstore[key] = val
+			if _, ok := v.kvstore.load()[alias]; ok {
+				v.kvstore.update(moveAlias)
 			}
 			if val, ok := v.defaults[alias]; ok {
 				delete(v.defaults, alias)
@@ -1436,22 +2651,25 @@ func (v *Viper) InConfig(key string) bool {
 	lcaseKey = v.realKey(lcaseKey)
 	path := strings.Split(lcaseKey, v.keyDelim)
 
-	return v.searchIndexableWithPathPrefixes(v.config, path) != nil
+	return v.searchIndexableWithPathPrefixes(v.config.load(), v.configPath(key, path)) != nil
 }
 
 // SetDefault sets the default value for this key.
 // SetDefault is case-insensitive for a key.
 // Default only used when no value is provided by the user via flag, config or ENV.
+// Struct values are converted into nested maps using their mapstructure tags.
 func SetDefault(key string, value any) { v.SetDefault(key, value) }
 
 func (v *Viper) SetDefault(key string, value any) {
 	// If alias passed in, then set the proper default
 	key = v.realKey(strings.ToLower(key))
+	value, secrets := toMapValueSecrets(value)
 	value = toCaseInsensitiveValue(value)
+	v.setSecretKeys(key, secrets)
 
 	path := strings.Split(key, v.keyDelim)
 	lastKey := strings.ToLower(path[len(path)-1])
-	deepestMap := deepSearch(v.defaults, path[0:len(path)-1])
+	deepestMap := maputil.DeepSearch(v.defaults, path[0:len(path)-1])
 
 	// set innermost value
 	deepestMap[lastKey] = value
@@ -1461,16 +2679,19 @@ func (v *Viper) SetDefault(key string, value any) {
 // Set is case-insensitive for a key.
 // Will be used instead of values obtained via
 // flags, config file, ENV, default, or key/value store.
+// Struct values are converted into nested maps using their mapstructure tags.
 func Set(key string, value any) { v.Set(key, value) }
 
 func (v *Viper) Set(key string, value any) {
 	// If alias passed in, then set the proper override
 	key = v.realKey(strings.ToLower(key))
+	value, secrets := toMapValueSecrets(value)
 	value = toCaseInsensitiveValue(value)
+	v.setSecretKeys(key, secrets)
 
 	path := strings.Split(key, v.keyDelim)
 	lastKey := strings.ToLower(path[len(path)-1])
-	deepestMap := deepSearch(v.override, path[0:len(path)-1])
+	deepestMap := maputil.DeepSearch(v.override, path[0:len(path)-1])
 
 	// set innermost value
 	deepestMap[lastKey] = value
@@ -1482,29 +2703,59 @@ func ReadInConfig() error { return v.ReadInConfig() }
 
 func (v *Viper) ReadInConfig() error {
 	v.logger.Info("attempting to read in config file")
+
+	v.refreshEnvSnapshot()
+
+	var sources []configSource
+
 	filename, err := v.getConfigFile()
-	if err != nil {
+	switch {
+	case err == nil:
+		if !slices.Contains(SupportedExts, v.getConfigType()) {
+			return UnsupportedConfigError(v.getConfigType())
+		}
+
+		sources = append(sources, configSource{file: filename, format: v.getConfigType()})
+
+	// fragments may be the only source of configuration
+	case len(v.configFragmentDirs) > 0 && errors.As(err, &ConfigFileNotFoundError{}):
+		v.logger.Debug("no config file found, reading fragments only", "error", err)
+
+	case v.configFileOptional && errors.As(err, &ConfigFileNotFoundError{}):
+		v.logger.Debug("no config file found", "error", err)
+
+		return nil
+
+	default:
 		return err
 	}
 
-	if !slices.Contains(SupportedExts, v.getConfigType()) {
-		return UnsupportedConfigError(v.getConfigType())
+	for _, dir := range v.configFragmentDirs {
+		sources = append(sources, configSource{file: dir, dir: true})
 	}
 
-	v.logger.Debug("reading file", "file", filename)
-	file, err := afero.ReadFile(v.fs, filename)
-	if err != nil {
-		return err
-	}
+	if len(sources) == 1 && !sources[0].dir {
+		v.logger.Debug("reading file", "file", filename)
+		config, err := v.readConfigFile(filename, v.getConfigType())
+		if err != nil {
+			return err
+		}
 
-	config := make(map[string]any)
+		if err := v.applyConfig(config); err != nil {
+			return err
+		}
 
-	err = v.unmarshalReader(bytes.NewReader(file), config)
-	if err != nil {
+		v.configSources = sources
+
+		return nil
+	}
+
+	if err := v.reloadConfigSources(sources); err != nil {
 		return err
 	}
 
-	v.config = config
+	v.configSources = sources
+
 	return nil
 }
 
@@ -1522,12 +2773,74 @@ func (v *Viper) MergeInConfig() error {
 		return UnsupportedConfigError(v.getConfigType())
 	}
 
-	file, err := afero.ReadFile(v.fs, filename)
+	if v.configType == "" {
+		return errors.New("cannot decode configuration: config type is not set")
+	}
+
+	cfg, err := v.readConfigFile(filename, v.getConfigType())
 	if err != nil {
 		return err
 	}
 
-	return v.MergeConfig(bytes.NewReader(file))
+	if err := v.MergeConfigMap(cfg); err != nil {
+		return err
+	}
+
+	source := configSource{file: filename, format: v.getConfigType()}
+	if !slices.Contains(v.configSources, source) {
+		v.configSources = append(v.configSources, source)
+	}
+
+	return nil
+}
+
+// configSource is a config file (or fragment directory) that contributed to the configuration.
+type configSource struct {
+	file   string
+	format string
+	dir    bool
+}
+
+// reloadConfigSources reads the first config file and merges the other ones into it, in order.
+// Fragment directories are expanded to the fragments they currently hold.
+func (v *Viper) reloadConfigSources(sources []configSource) error {
+	var files []configSource
+
+	for _, source := range sources {
+		if !source.dir {
+			files = append(files, source)
+
+			continue
+		}
+
+		fragments, err := v.readConfigFragments(source.file)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, fragments...)
+	}
+
+	config := make(map[string]any)
+
+	for i, source := range files {
+		v.logger.Debug("reading file", "file", source.file)
+
+		cfg, err := v.readConfigFile(source.file, source.format)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			config = cfg
+
+			continue
+		}
+
+		v.mergeMaps(cfg, config, nil, "", nil)
+	}
+
+	return v.applyConfig(config)
 }
 
 // ReadConfig will read a configuration file, setting existing keys to nil if the
@@ -1539,8 +2852,16 @@ func (v *Viper) ReadConfig(in io.Reader) error {
 		return errors.New("cannot decode configuration: config type is not set")
 	}
 
-	v.config = make(map[string]any)
-	return v.unmarshalReader(in, v.config)
+	config := make(map[string]any)
+	if err := v.unmarshalConfigReader(in, config); err != nil {
+		return err
+	}
+
+	v.config.store(config)
+
+	v.updateVersion()
+
+	return nil
 }
 
 // MergeConfig merges a new configuration with an existing config.
@@ -1552,7 +2873,7 @@ func (v *Viper) MergeConfig(in io.Reader) error {
 	}
 
 	cfg := make(map[string]any)
-	if err := v.unmarshalReader(in, cfg); err != nil {
+	if err := v.unmarshalConfigReader(in, cfg); err != nil {
 		return err
 	}
 	return v.MergeConfigMap(cfg)
@@ -1563,11 +2884,14 @@ func (v *Viper) MergeConfig(in io.Reader) error {
 func MergeConfigMap(cfg map[string]any) error { return v.MergeConfigMap(cfg) }
 
 func (v *Viper) MergeConfigMap(cfg map[string]any) error {
-	if v.config == nil {
-		v.config = make(map[string]any)
-	}
-	insensitiviseMap(cfg)
-	mergeMaps(cfg, v.config, nil)
+	v.normalizeConfigKeys(cfg)
+	v.config.update(func(config map[string]any) error {
+		v.mergeMaps(cfg, config, nil, "", v.mergeConflictHandler)
+
+		return nil
+	})
+	v.updateVersion()
+
 	return nil
 }
 
@@ -1609,7 +2933,7 @@ func (v *Viper) WriteConfigTo(w io.Writer) error {
 		return UnsupportedConfigError(format)
 	}
 
-	return v.marshalWriter(w, format)
+	return v.marshalWriter(w, format, v.AllSettings())
 }
 
 // SafeWriteConfigAs writes current configuration to a given filename if it does not exist.
@@ -1623,7 +2947,21 @@ func (v *Viper) SafeWriteConfigAs(filename string) error {
 	return v.writeConfig(filename, false)
 }
 
+// WriteOverridesAs writes only the values set through Set (the override register)
+// to a given filename, leaving the base configuration file untouched.
+// This is useful to persist user changes to an overlay file (eg. config.local.yaml)
+// that can be merged on top of the base configuration with MergeInConfig.
+func WriteOverridesAs(filename string) error { return v.WriteOverridesAs(filename) }
+
+func (v *Viper) WriteOverridesAs(filename string) error {
+	return v.writeSettings(filename, true, v.override)
+}
+
 func (v *Viper) writeConfig(filename string, force bool) error {
+	return v.writeSettings(filename, force, v.AllSettings())
+}
+
+func (v *Viper) writeSettings(filename string, force bool, settings map[string]any) error {
 	v.logger.Info("attempting to write configuration to file")
 
 	var configType string
@@ -1641,9 +2979,6 @@ func (v *Viper) writeConfig(filename string, force bool) error {
 	if !slices.Contains(SupportedExts, configType) {
 		return UnsupportedConfigError(configType)
 	}
-	if v.config == nil {
-		v.config = make(map[string]any)
-	}
 	flags := os.O_CREATE | os.O_TRUNC | os.O_WRONLY
 	if !force {
 		flags |= os.O_EXCL
@@ -1654,7 +2989,7 @@ func (v *Viper) writeConfig(filename string, force bool) error {
 	}
 	defer f.Close()
 
-	if err := v.marshalWriter(f, configType); err != nil {
+	if err := v.marshalWriter(f, configType, settings); err != nil {
 		return err
 	}
 
@@ -1665,7 +3000,33 @@ func (v *Viper) unmarshalReader(in io.Reader, c map[string]any) error {
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(in)
 
-	format := strings.ToLower(v.getConfigType())
+	if err := v.decodeConfig(buf.Bytes(), v.getConfigType(), c); err != nil {
+		return err
+	}
+
+	insensitiviseMap(c)
+	v.expandConfig(c)
+	return nil
+}
+
+// unmarshalConfigReader behaves like unmarshalReader for maps of the config file layer.
+func (v *Viper) unmarshalConfigReader(in io.Reader, c map[string]any) error {
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(in)
+
+	if err := v.decodeConfig(buf.Bytes(), v.getConfigType(), c); err != nil {
+		return err
+	}
+
+	v.normalizeConfigKeys(c)
+	v.expandConfig(c)
+	return nil
+}
+
+// decodeConfig decodes b in the given format into c.
+// Keys are returned as decoded.
+func (v *Viper) decodeConfig(b []byte, format string, c map[string]any) error {
+	format = strings.ToLower(format)
 
 	if !slices.Contains(SupportedExts, format) {
 		return UnsupportedConfigError(format)
@@ -1676,19 +3037,16 @@ func (v *Viper) unmarshalReader(in io.Reader, c map[string]any) error {
 		return ConfigParseError{err}
 	}
 
-	err = decoder.Decode(buf.Bytes(), c)
+	err = decoder.Decode(b, c)
 	if err != nil {
 		return ConfigParseError{err}
 	}
 
-	insensitiviseMap(c)
 	return nil
 }
 
 // Marshal a map into Writer.
-func (v *Viper) marshalWriter(w io.Writer, configType string) error {
-	c := v.AllSettings()
-
+func (v *Viper) marshalWriter(w io.Writer, configType string, c map[string]any) error {
 	encoder, err := v.encoderRegistry.Encoder(configType)
 	if err != nil {
 		return ConfigMarshalError{err}
@@ -1757,9 +3115,11 @@ func castMapFlagToMapInterface(src map[string]FlagValue) map[string]any {
 // instead of using a `string` as the key for nest structures beyond one level
 // deep. Both map types are supported as there is a go-yaml fork that uses
 // `map[string]any` instead.
-func mergeMaps(src, tgt map[string]any, itgt map[any]any) {
+// The `prefix` parameter is the key path of the maps being merged,
+// used for reporting conflicts to `onConflict` when it is not nil (see WithMergeConflictHandler).
+func (v *Viper) mergeMaps(src, tgt map[string]any, itgt map[any]any, prefix string, onConflict func(key string, oldV, newV any) any) {
 	for sk, sv := range src {
-		tk := keyExists(sk, tgt)
+		tk := v.configKey(sk, tgt)
 		if tk == "" {
 			v.logger.Debug("", "tk", "\"\"", fmt.Sprintf("tgt[%s]", sk), sv)
 			tgt[sk] = sv
@@ -1796,6 +3156,11 @@ func mergeMaps(src, tgt map[string]any, itgt map[any]any) {
 			v.logger.Debug("merging maps (must convert)")
 			tsv, ok := sv.(map[any]any)
 			if !ok {
+				if onConflict != nil {
+					resolveMergeConflict(tgt, itgt, tk, prefix, tv, sv, onConflict)
+					continue
+				}
+
 				v.logger.Error(
 					"Could not cast sv to map[any]any",
 					"key", sk,
@@ -1809,11 +3174,16 @@ func mergeMaps(src, tgt map[string]any, itgt map[any]any) {
 
 			ssv := castToMapStringInterface(tsv)
 			stv := castToMapStringInterface(ttv)
-			mergeMaps(ssv, stv, ttv)
+			v.mergeMaps(ssv, stv, ttv, prefix+tk+v.keyDelim, onConflict)
 		case map[string]any:
 			v.logger.Debug("merging maps")
 			tsv, ok := sv.(map[string]any)
 			if !ok {
+				if onConflict != nil {
+					resolveMergeConflict(tgt, itgt, tk, prefix, tv, sv, onConflict)
+					continue
+				}
+
 				v.logger.Error(
 					"Could not cast sv to map[string]any",
 					"key", sk,
@@ -1824,8 +3194,13 @@ func mergeMaps(src, tgt map[string]any, itgt map[any]any) {
 				)
 				continue
 			}
-			mergeMaps(tsv, ttv, nil)
+			v.mergeMaps(tsv, ttv, nil, prefix+tk+v.keyDelim, onConflict)
 		default:
+			if onConflict != nil {
+				resolveMergeConflict(tgt, itgt, tk, prefix, tv, sv, onConflict)
+				continue
+			}
+
 			v.logger.Debug("setting value")
 			tgt[tk] = sv
 			if itgt != nil {
@@ -1835,22 +3210,116 @@ func mergeMaps(src, tgt map[string]any, itgt map[any]any) {
 	}
 }
 
+// resolveMergeConflict asks onConflict which value to keep
+// when a merge would replace the existing value of tk.
+func resolveMergeConflict(tgt map[string]any, itgt map[any]any, tk, prefix string, oldV, newV any, onConflict func(key string, oldV, newV any) any) {
+	val := newV
+	if !reflect.DeepEqual(oldV, newV) {
+		val = onConflict(prefix+tk, oldV, newV)
+	}
+
+	tgt[tk] = val
+	if itgt != nil {
+		itgt[tk] = val
+	}
+}
+
 // AllKeys returns all keys holding a value, regardless of where they are set.
 // Nested keys are returned with a v.keyDelim separator.
+// The result also contains every registered alias name and computed keys (see WithFacts).
+// Use AllKeysWith for a more precise set of keys.
 func AllKeys() []string { return v.AllKeys() }
 
 func (v *Viper) AllKeys() []string {
 	m := map[string]bool{}
 	// add all paths, by order of descending priority to ensure correct shadowing
 	m = v.flattenAndMergeMap(m, castMapStringToMapInterface(v.aliases), "")
+	m = v.flattenAndMergeMap(m, v.facts, "")
+	m = v.mergeSourceKeys(m)
+
+	return keySetToList(m)
+}
+
+// AllKeysOption configures the set of keys returned by AllKeysWith.
+type AllKeysOption func(*allKeysConfig)
+
+type allKeysConfig struct {
+	includeAliases  bool
+	includeComputed bool
+}
+
+// IncludeAliases makes AllKeysWith return the name of registered aliases
+// whose aliased key holds a value.
+func IncludeAliases() AllKeysOption {
+	return func(c *allKeysConfig) {
+		c.includeAliases = true
+	}
+}
+
+// IncludeComputed makes AllKeysWith return computed keys (see WithFacts).
+func IncludeComputed() AllKeysOption {
+	return func(c *allKeysConfig) {
+		c.includeComputed = true
+	}
+}
+
+// CanonicalOnly makes AllKeysWith return canonical keys only,
+// excluding alias names and computed keys.
+// It resets the effect of IncludeAliases and IncludeComputed passed before it.
+func CanonicalOnly() AllKeysOption {
+	return func(c *allKeysConfig) {
+		c.includeAliases = false
+		c.includeComputed = false
+	}
+}
+
+// AllKeysWith returns all keys holding a value, regardless of where they are set.
+// Nested keys are returned with a v.keyDelim separator.
+// By default only canonical keys are returned; alias names and computed keys
+// can be included with IncludeAliases and IncludeComputed.
+func AllKeysWith(opts ...AllKeysOption) []string { return v.AllKeysWith(opts...) }
+
+func (v *Viper) AllKeysWith(opts ...AllKeysOption) []string {
+	var c allKeysConfig
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	m := map[string]bool{}
+	// add all paths, by order of descending priority to ensure correct shadowing
+	if c.includeComputed {
+		m = v.flattenAndMergeMap(m, v.facts, "")
+	}
+	m = v.mergeSourceKeys(m)
+
+	if c.includeAliases {
+		for alias, key := range v.aliases {
+			if m[v.realKey(key)] {
+				m[alias] = true
+			}
+		}
+	}
+
+	return keySetToList(m)
+}
+
+// mergeSourceKeys merges the keys of every configuration source
+// to the given shadow set, by order of descending priority.
+func (v *Viper) mergeSourceKeys(m map[string]bool) map[string]bool {
 	m = v.flattenAndMergeMap(m, v.override, "")
-	m = v.mergeFlatMap(m, castMapFlagToMapInterface(v.pflags))
+	m = v.mergeFlatMap(m, v.flagKeys())
 	m = v.mergeFlatMap(m, castMapStringSliceToMapInterface(v.env))
-	m = v.flattenAndMergeMap(m, v.config, "")
-	m = v.flattenAndMergeMap(m, v.kvstore, "")
+	m = v.mergeFlatMap(m, v.prefixBoundEnvKeys())
+	m = v.flattenAndMergeMap(m, v.config.load(), "")
+	m = v.flattenAndMergeMap(m, v.kvstore.load(), "")
 	m = v.flattenAndMergeMap(m, v.defaults, "")
 
-	// convert set of paths to list
+	return m
+}
+
+// keySetToList converts a set of paths to a list.
+func keySetToList(m map[string]bool) []string {
 	a := make([]string, 0, len(m))
 	for x := range m {
 		a = append(a, x)
@@ -1937,7 +3406,7 @@ func (v *Viper) getSettings(keys []string) map[string]any {
 		}
 		path := strings.Split(k, v.keyDelim)
 		lastKey := strings.ToLower(path[len(path)-1])
-		deepestMap := deepSearch(m, path[0:len(path)-1])
+		deepestMap := maputil.DeepSearch(m, path[0:len(path)-1])
 		// set innermost value
 		deepestMap[lastKey] = value
 	}
@@ -2014,7 +3483,7 @@ func (v *Viper) getConfigFile() (string, error) {
 }
 
 // Debug prints all configuration registries for debugging
-// purposes.
+// purposes. The values of secret keys are redacted (see SetKeyVisibility).
 func Debug()              { v.Debug() }
 func DebugTo(w io.Writer) { v.DebugTo(w) }
 
@@ -2022,10 +3491,11 @@ func (v *Viper) Debug() { v.DebugTo(os.Stdout) }
 
 func (v *Viper) DebugTo(w io.Writer) {
 	fmt.Fprintf(w, "Aliases:\n%#v\n", v.aliases)
-	fmt.Fprintf(w, "Override:\n%#v\n", v.override)
+	fmt.Fprintf(w, "Facts:\n%#v\n", v.redactSecretKeys(v.facts, ""))
+	fmt.Fprintf(w, "Override:\n%#v\n", v.redactSecretKeys(v.override, ""))
 	fmt.Fprintf(w, "PFlags:\n%#v\n", v.pflags)
 	fmt.Fprintf(w, "Env:\n%#v\n", v.env)
-	fmt.Fprintf(w, "Key/Value Store:\n%#v\n", v.kvstore)
-	fmt.Fprintf(w, "Config:\n%#v\n", v.config)
-	fmt.Fprintf(w, "Defaults:\n%#v\n", v.defaults)
+	fmt.Fprintf(w, "Key/Value Store:\n%#v\n", v.redactSecretKeys(v.kvstore.load(), ""))
+	fmt.Fprintf(w, "Config:\n%#v\n", v.redactSecretKeys(v.config.load(), ""))
+	fmt.Fprintf(w, "Defaults:\n%#v\n", v.redactSecretKeys(v.defaults, ""))
 }
@@ -7,14 +7,18 @@ package viper
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -94,30 +98,30 @@ func initConfigs(v *Viper) {
 	var r io.Reader
 	v.SetConfigType("yaml")
 	r = bytes.NewReader(yamlExample)
-	v.unmarshalReader(r, v.config)
+	v.unmarshalReader(r, v.config.load())
 
 	v.SetConfigType("json")
 	r = bytes.NewReader(jsonExample)
-	v.unmarshalReader(r, v.config)
+	v.unmarshalReader(r, v.config.load())
 
 	v.SetConfigType("toml")
 	r = bytes.NewReader(tomlExample)
-	v.unmarshalReader(r, v.config)
+	v.unmarshalReader(r, v.config.load())
 
 	v.SetConfigType("env")
 	r = bytes.NewReader(dotenvExample)
-	v.unmarshalReader(r, v.config)
+	v.unmarshalReader(r, v.config.load())
 
 	v.SetConfigType("json")
 	remote := bytes.NewReader(remoteExample)
-	v.unmarshalReader(remote, v.kvstore)
+	v.unmarshalReader(remote, v.kvstore.load())
 }
 
 func initConfig(typ, config string, v *Viper) {
 	v.SetConfigType(typ)
 	r := strings.NewReader(config)
 
-	if err := v.unmarshalReader(r, v.config); err != nil {
+	if err := v.unmarshalReader(r, v.config.load()); err != nil {
 		panic(err)
 	}
 }
@@ -486,7 +490,7 @@ func TestUnmarshaling(t *testing.T) {
 	v.SetConfigType("yaml")
 	r := bytes.NewReader(yamlExample)
 
-	v.unmarshalReader(r, v.config)
+	v.unmarshalReader(r, v.config.load())
 	assert.True(t, v.InConfig("name"))
 	assert.True(t, v.InConfig("clothing.jacket"))
 	assert.False(t, v.InConfig("state"))
@@ -497,6 +501,29 @@ func TestUnmarshaling(t *testing.T) {
 	assert.Equal(t, 35, v.Get("age"))
 }
 
+func TestUnmarshalWithMetadata(t *testing.T) {
+	v := New()
+	v.Set("name", "app")
+	v.Set("db.host", "localhost")
+	v.Set("db.hosst", "typo")
+
+	var config struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+	}
+
+	md, err := v.UnmarshalWithMetadata(&config)
+	require.NoError(t, err)
+
+	assert.Equal(t, "localhost", config.DB.Host)
+	assert.ElementsMatch(t, []string{"Name", "DB", "DB.Host"}, md.Keys)
+	assert.Equal(t, []string{"DB.hosst"}, md.Unused)
+	assert.Equal(t, []string{"DB.Port"}, md.Unset)
+}
+
 func TestUnmarshalExact(t *testing.T) {
 	v := New()
 	target := &testUnmarshalExtra{}
@@ -507,6 +534,47 @@ func TestUnmarshalExact(t *testing.T) {
 	assert.Error(t, err, "UnmarshalExact should error when populating a struct from a conf that contains unused fields")
 }
 
+func TestUnmarshalRemain(t *testing.T) {
+	type remainDB struct {
+		Host  string
+		Extra map[string]any `mapstructure:",remain"`
+	}
+
+	type remainConfig struct {
+		Name  string
+		DB    remainDB
+		Other map[string]any `mapstructure:",remain"`
+	}
+
+	for _, opts := range [][]Option{nil, {ExperimentalBindStruct()}} {
+		v := NewWithOptions(opts...)
+		v.SetConfigType("yaml")
+		require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+name: app
+plugin:
+  enabled: true
+db:
+  host: localhost
+  pool:
+    size: 2
+`)))
+
+		var config remainConfig
+		require.NoError(t, v.UnmarshalExact(&config))
+
+		assert.Equal(t, "localhost", config.DB.Host)
+		assert.Equal(t, map[string]any{"pool": map[string]any{"size": 2}}, config.DB.Extra)
+		assert.Equal(t, map[string]any{"plugin": map[string]any{"enabled": true}}, config.Other)
+
+		// Setting the struct back stores the captured keys next to the other fields.
+		v.Set("copy", config)
+		assert.Equal(t, 2, v.Get("copy.db.pool.size"))
+		assert.Equal(t, true, v.Get("copy.plugin.enabled"))
+		assert.False(t, v.IsSet("copy.other"))
+		assert.False(t, v.IsSet("copy.db.extra"))
+	}
+}
+
 func TestOverrides(t *testing.T) {
 	v := New()
 	v.Set("age", 40)
@@ -593,7 +661,7 @@ func TestRemotePrecedence(t *testing.T) {
 
 	// update the kvstore with the remoteExample which should overite the key in v.config
 	remote := bytes.NewReader(remoteExample)
-	require.NoError(t, v.unmarshalReader(remote, v.kvstore), "Error reading json data in to kvstore")
+	require.NoError(t, v.unmarshalReader(remote, v.kvstore.load()), "Error reading json data in to kvstore")
 
 	assert.Equal(t, "0001", v.Get("id"))
 	assert.NotEqual(t, "cronut", v.Get("type"))
@@ -671,6 +739,26 @@ func TestEmptyEnv_Allowed(t *testing.T) {
 	assert.Equal(t, "Cake", v.Get("name"))
 }
 
+func TestBindEnvWithOptions(t *testing.T) {
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(jsonExample)), "Error reading json data")
+
+	require.NoError(t, v.BindEnvWithOptions("type", EnvOptions{AllowEmpty: true}))
+	require.NoError(t, v.BindEnvWithOptions("name", EnvOptions{Names: []string{"CAKE_NAME", "NAME"}}))
+	require.NoError(t, v.BindEnvWithOptions("ppu", EnvOptions{AllowEmpty: true}))
+	require.NoError(t, v.BindEnvWithOptions("ppu", EnvOptions{}))
+
+	t.Setenv("TYPE", "")
+	t.Setenv("CAKE_NAME", "")
+	t.Setenv("NAME", "Pie")
+	t.Setenv("PPU", "")
+
+	assert.Equal(t, "", v.Get("type"))
+	assert.Equal(t, "Pie", v.Get("name"), "empty variables fall back by default")
+	assert.Equal(t, 0.55, v.Get("ppu"))
+}
+
 func TestEnvPrefix(t *testing.T) {
 	v := New()
 	v.SetConfigType("json")
@@ -712,6 +800,100 @@ func TestAutoEnvWithPrefix(t *testing.T) {
 	assert.Equal(t, "13", v.Get("bar"))
 }
 
+func TestAutomaticEnvStrict(t *testing.T) {
+	t.Setenv("HOSTNAME", "container")
+	t.Setenv("MYAPP_PORT", "8080")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("LEGACY_TOKEN", "token")
+
+	t.Run("AllowedPrefixes", func(t *testing.T) {
+		v := New()
+		v.AutomaticEnvStrict("myapp_", "DB_")
+		require.NoError(t, v.BindEnv("token", "LEGACY_TOKEN"))
+
+		assert.Nil(t, v.Get("hostname"))
+		assert.Equal(t, "8080", v.Get("myapp_port"))
+		assert.Equal(t, "db.internal", v.Get("db_host"))
+		assert.Equal(t, "token", v.Get("token"), "bound keys are not restricted")
+	})
+
+	t.Run("EnvPrefix", func(t *testing.T) {
+		v := New()
+		v.SetEnvPrefix("myapp")
+		v.AutomaticEnvStrict()
+
+		assert.Equal(t, "8080", v.Get("port"))
+	})
+
+	t.Run("NoPrefix", func(t *testing.T) {
+		v := New()
+		v.AutomaticEnvStrict()
+
+		assert.Nil(t, v.Get("hostname"))
+		assert.Nil(t, v.Get("myapp_port"))
+
+		// AutomaticEnv lifts the restriction
+		v.AutomaticEnv()
+		assert.Equal(t, "container", v.Get("hostname"))
+	})
+}
+
+func TestSetEnvPrefixFor(t *testing.T) {
+	t.Setenv("APP_NAME", "app")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("DB_REPLICA_HOST", "replica.internal")
+	t.Setenv("REPLICA_HOST", "other.internal")
+	t.Setenv("REDIS_URL", "redis://cache")
+	t.Setenv("HOSTNAME", "container")
+
+	v := New()
+	v.SetEnvPrefix("app")
+	v.SetEnvPrefixFor("database", "db")
+	v.SetEnvPrefixFor("database.replica", "replica")
+	v.SetEnvPrefixFor("cache", "REDIS")
+	v.AutomaticEnvStrict()
+
+	assert.Equal(t, "app", v.Get("name"))
+	assert.Equal(t, "db.internal", v.Get("database.host"))
+	assert.Equal(t, "other.internal", v.Get("database.replica.host"), "the longest key prefix is used")
+
+	v.SetDefault("database.port", 5432)
+	assert.Equal(t, "db.internal", v.Sub("database").Get("host"))
+	assert.Nil(t, v.Get("databasehost"))
+
+	require.NoError(t, v.BindEnv("cache.url"))
+	assert.Equal(t, []string{"REDIS_URL"}, v.env["cache.url"])
+	assert.Equal(t, "redis://cache", v.Get("cache.url"))
+
+	v.SetEnvPrefixFor("cache", "")
+	require.NoError(t, v.BindEnv("cache.hostname"))
+	assert.Equal(t, "container", v.Get("cache.hostname"))
+}
+
+func TestSetEnvPrefixes(t *testing.T) {
+	t.Setenv("MYAPP_HOST", "new")
+	t.Setenv("LEGACYAPP_HOST", "legacy")
+	t.Setenv("LEGACYAPP_PORT", "8080")
+	t.Setenv("LEGACYAPP_DB_NAME", "db")
+
+	v := New()
+	v.SetEnvPrefixes("myapp", "", "legacyapp")
+	v.AutomaticEnvStrict()
+
+	assert.Equal(t, "myapp", v.GetEnvPrefix())
+	assert.Equal(t, "new", v.Get("host"))
+	assert.Equal(t, "8080", v.Get("port"))
+	assert.Nil(t, v.Get("missing"))
+
+	require.NoError(t, v.BindEnv("db_name"))
+	assert.Equal(t, []string{"MYAPP_DB_NAME", "LEGACYAPP_DB_NAME"}, v.env["db_name"])
+	assert.Equal(t, "db", v.Get("db_name"))
+
+	// SetEnvPrefix drops the fallback prefixes
+	v.SetEnvPrefix("myapp")
+	assert.Nil(t, v.Get("port"))
+}
+
 func TestSetEnvKeyReplacer(t *testing.T) {
 	v := New()
 	v.AutomaticEnv()
@@ -731,6 +913,110 @@ func TestEnvKeyReplacer(t *testing.T) {
 	assert.Equal(t, "30s", v.Get("refresh-interval"))
 }
 
+func TestSetEnvKeyTransformer(t *testing.T) {
+	t.Setenv("SVC_HTTP_LISTEN", ":8080")
+	t.Setenv("APP_TOKEN", "token")
+
+	v := New()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyTransformer(func(key string) string {
+		// strip the "server" segment
+		return "SVC_" + strings.ToUpper(strings.ReplaceAll(strings.TrimPrefix(key, "server."), ".", "_"))
+	})
+	v.AutomaticEnv()
+
+	assert.Equal(t, ":8080", v.Get("server.http.listen"))
+	assert.Nil(t, v.Get("token"))
+
+	require.NoError(t, v.BindEnv("http.listen"))
+	assert.Equal(t, []string{"SVC_HTTP_LISTEN"}, v.env["http.listen"])
+
+	v.SetEnvKeyTransformer(nil)
+	assert.Equal(t, "token", v.Get("token"))
+}
+
+func TestSetEnvNestingSeparator(t *testing.T) {
+	v := New()
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+	v.SetEnvNestingSeparator("__")
+
+	v.SetDefault("database.pool.size", 5)
+	v.SetDefault("database.pool_timeout", "1s")
+
+	t.Setenv("APP_DATABASE__POOL__SIZE", "10")
+	t.Setenv("APP_DATABASE__POOL_TIMEOUT", "5s")
+	t.Setenv("APP_DATABASE_POOL_SIZE", "20")
+
+	assert.Equal(t, 10, v.GetInt("database.pool.size"))
+	assert.Equal(t, "5s", v.GetString("database.pool_timeout"))
+
+	// nested keys of sub trees are read from the full key
+	assert.Equal(t, 10, v.Sub("database").GetInt("pool.size"))
+
+	// values of parent keys shadow the defaults of nested keys
+	t.Setenv("APP_DATABASE__POOL__SIZE", "")
+	t.Setenv("APP_DATABASE__POOL", "disabled")
+	assert.Nil(t, v.Get("database.pool.size"))
+}
+
+func TestWithEnvLookup(t *testing.T) {
+	t.Setenv("APP_PROCESS", "process")
+
+	env := map[string]string{
+		"APP_HOST":    "example.com",
+		"APP_PORT":    "8080",
+		"APP_EMPTY":   "",
+		"APP_NAMES_0": "a",
+	}
+
+	v := NewWithOptions(WithEnvLookup(func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}), WithIndexedEnv())
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+	require.NoError(t, v.BindEnv("port"))
+
+	assert.Equal(t, "example.com", v.Get("host"))
+	assert.Equal(t, 8080, v.GetInt("port"))
+	assert.Nil(t, v.Get("empty"))
+	assert.Nil(t, v.Get("process"))
+	assert.Nil(t, v.Get("names"), "custom lookups cannot be enumerated")
+}
+
+func TestWithEnvFileSuffix(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/run/secrets/db_password", []byte("s3cr3t\n"), 0o600))
+	require.NoError(t, afero.WriteFile(fs, "/run/secrets/api_key", []byte("key"), 0o600))
+
+	v := NewWithOptions(WithEnvFileSuffix("_FILE"))
+	v.SetFs(fs)
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	require.NoError(t, v.BindEnv("api.key", "API_KEY"))
+
+	t.Setenv("APP_DB_PASSWORD_FILE", "/run/secrets/db_password")
+	t.Setenv("API_KEY_FILE", "/run/secrets/api_key")
+	t.Setenv("APP_MISSING_FILE", "/run/secrets/missing")
+
+	assert.Equal(t, "s3cr3t", v.GetString("db.password"))
+	assert.Equal(t, "key", v.GetString("api.key"))
+	assert.Nil(t, v.Get("missing"))
+
+	// the variable itself takes precedence over the file
+	t.Setenv("API_KEY", "from-env")
+	assert.Equal(t, "from-env", v.GetString("api.key"))
+
+	// files are only read when the option is set
+	v = New()
+	v.SetFs(fs)
+	require.NoError(t, v.BindEnv("api.key", "API_KEY"))
+	t.Setenv("API_KEY", "")
+	assert.Nil(t, v.Get("api.key"))
+}
+
 func TestEnvSubConfig(t *testing.T) {
 	v := New()
 	v.SetConfigType("yaml")
@@ -835,6 +1121,30 @@ func TestAllKeysWithEnv(t *testing.T) {
 	assert.ElementsMatch(t, []string{"id", "foo.bar"}, v.AllKeys())
 }
 
+func TestAllKeysWith(t *testing.T) {
+	v := NewWithOptions(WithFacts())
+	v.Set("server.host", "localhost")
+	v.SetDefault("name", "app")
+	v.RegisterAlias("host", "server.host")
+	v.RegisterAlias("port", "server.port")
+
+	canonical := []string{"server.host", "name"}
+
+	assert.ElementsMatch(t, canonical, v.AllKeysWith())
+	assert.ElementsMatch(t, append([]string{"host"}, canonical...), v.AllKeysWith(IncludeAliases()))
+	assert.ElementsMatch(t, canonical, v.AllKeysWith(IncludeAliases(), IncludeComputed(), CanonicalOnly()))
+
+	keys := v.AllKeysWith(IncludeComputed())
+	assert.Contains(t, keys, "facts.pid")
+	assert.NotContains(t, keys, "host")
+
+	// AllKeys keeps returning every alias name and computed keys
+	keys = v.AllKeys()
+	assert.Contains(t, keys, "host")
+	assert.Contains(t, keys, "port")
+	assert.Contains(t, keys, "facts.pid")
+}
+
 func TestAliasesOfAliases(t *testing.T) {
 	v := New()
 	v.Set("Title", "Checking Case")
@@ -929,6 +1239,52 @@ func TestUnmarshalWithDefaultDecodeHook(t *testing.T) {
 	}, &C)
 }
 
+func TestRegisterDecodeHook(t *testing.T) {
+	type hostPort struct {
+		Host string
+		Port int
+	}
+
+	hostPortHook := func(rf reflect.Type, rt reflect.Type, data any) (any, error) {
+		if rf.Kind() != reflect.String || rt != reflect.TypeOf(hostPort{}) {
+			return data, nil
+		}
+		host, port, _ := strings.Cut(data.(string), ":")
+		return hostPort{Host: host, Port: cast.ToInt(port)}, nil
+	}
+
+	v := NewWithOptions(WithDecodeHooks(hostPortHook))
+	v.RegisterDecodeHook(nil)
+	v.Set("primary", "db1:5432")
+	v.Set("replicas", "db2:5432,db3:5433")
+	v.Set("timeout", "5s")
+
+	type config struct {
+		Primary  hostPort
+		Replicas []hostPort
+		Timeout  time.Duration
+	}
+
+	expected := config{
+		Primary:  hostPort{"db1", 5432},
+		Replicas: []hostPort{{"db2", 5432}, {"db3", 5433}},
+		Timeout:  5 * time.Second,
+	}
+
+	var C config
+	require.NoError(t, v.Unmarshal(&C))
+	assert.Equal(t, expected, C)
+
+	C = config{}
+	require.NoError(t, v.Unmarshal(&C, DecodeHook(mapstructure.StringToTimeDurationHookFunc())),
+		"registered hooks run with the hook of the call")
+	assert.Equal(t, hostPort{"db1", 5432}, C.Primary)
+
+	var primary hostPort
+	require.NoError(t, v.UnmarshalKey("primary", &primary))
+	assert.Equal(t, hostPort{"db1", 5432}, primary)
+}
+
 func TestUnmarshalWithDecoderOptions(t *testing.T) {
 	v := New()
 	v.Set("credentials", "{\"foo\":\"bar\"}")
@@ -964,6 +1320,145 @@ func TestUnmarshalWithDecoderOptions(t *testing.T) {
 	}, &C)
 }
 
+func TestUnmarshalWithDefaultDecoderOptions(t *testing.T) {
+	v := NewWithOptions(WithDefaultDecoderOptions(
+		StrictTypes(),
+		func(c *mapstructure.DecoderConfig) {
+			c.TagName = "config"
+		},
+	))
+	v.Set("listen_port", 1313)
+
+	type config struct {
+		Port int `config:"listen_port"`
+	}
+
+	var C config
+
+	require.NoError(t, v.Unmarshal(&C))
+	assert.Equal(t, config{Port: 1313}, C)
+
+	v.Set("listen_port", "1313")
+
+	require.Error(t, v.Unmarshal(&C))
+
+	// Per-call options take precedence
+	require.NoError(t, v.Unmarshal(&C, func(c *mapstructure.DecoderConfig) {
+		c.WeaklyTypedInput = true
+	}))
+	assert.Equal(t, config{Port: 1313}, C)
+}
+
+func TestTimeLayouts(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+
+	v := NewWithOptions(WithTimeLayouts("02/01/2006", time.RFC1123), WithTimeLocation(loc))
+	v.Set("release", "31/12/2024")
+	v.Set("updated", "Mon, 02 Jan 2006 15:04:05 MST")
+	v.Set("created", "2024-01-02 03:04:05")
+	v.Set("invalid", "yesterday")
+
+	assert.Equal(t, time.Date(2024, 12, 31, 0, 0, 0, 0, loc), v.GetTime("release"))
+	assert.True(t, time.Date(2024, 1, 2, 3, 4, 5, 0, loc).Equal(v.GetTime("created")), "default formats use the location")
+	assert.True(t, v.GetTime("invalid").IsZero())
+
+	type config struct {
+		Release time.Time
+		Updated time.Time
+	}
+
+	var C config
+
+	require.NoError(t, v.Unmarshal(&C))
+	assert.Equal(t, time.Date(2024, 12, 31, 0, 0, 0, 0, loc), C.Release)
+	assert.Equal(t, 2006, C.Updated.Year())
+
+	var invalid struct{ Invalid time.Time }
+	require.Error(t, v.Unmarshal(&invalid))
+
+	v = New()
+	v.Set("created", "2024-01-02 03:04:05")
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), v.GetTime("created"))
+}
+
+func TestIntegerDurationUnit(t *testing.T) {
+	v := New()
+	v.Set("timeout", 30)
+
+	assert.Equal(t, 30*time.Nanosecond, v.GetDuration("timeout"))
+
+	v = NewWithOptions(WithIntegerDurationUnit(time.Second))
+	v.Set("timeout", 30)
+	v.Set("interval", "1.5")
+	v.Set("delay", float64(2))
+	v.Set("grace", "1m")
+
+	assert.Equal(t, 30*time.Second, v.GetDuration("timeout"))
+	assert.Equal(t, 1500*time.Millisecond, v.GetDuration("interval"))
+	assert.Equal(t, 2*time.Second, v.GetDuration("delay"))
+	assert.Equal(t, time.Minute, v.GetDuration("grace"))
+
+	type config struct {
+		Timeout  time.Duration
+		Interval time.Duration
+		Delay    time.Duration
+		Grace    time.Duration
+	}
+
+	var C config
+
+	require.NoError(t, v.Unmarshal(&C))
+	assert.Equal(t, config{
+		Timeout:  30 * time.Second,
+		Interval: 1500 * time.Millisecond,
+		Delay:    2 * time.Second,
+		Grace:    time.Minute,
+	}, C)
+}
+
+func TestUnmarshalStrictTypes(t *testing.T) {
+	type config struct {
+		Port int
+		Name string
+	}
+
+	t.Run("Weak", func(t *testing.T) {
+		v := New()
+		v.Set("port", "1313")
+		v.Set("name", 42)
+
+		var C config
+
+		require.NoError(t, v.Unmarshal(&C))
+		assert.Equal(t, config{Port: 1313, Name: "42"}, C)
+	})
+
+	t.Run("StrictTypes", func(t *testing.T) {
+		v := New()
+		v.Set("port", "1313")
+
+		var C config
+
+		require.Error(t, v.Unmarshal(&C, StrictTypes()))
+		require.Error(t, v.UnmarshalKey("port", &C.Port, StrictTypes()))
+	})
+
+	t.Run("WithStrictDecoding", func(t *testing.T) {
+		v := NewWithOptions(WithStrictDecoding())
+		v.Set("name", 42)
+
+		var C config
+
+		require.Error(t, v.Unmarshal(&C))
+
+		v.Set("name", "hugo")
+		v.Set("port", 1313)
+
+		require.NoError(t, v.Unmarshal(&C))
+		assert.Equal(t, config{Port: 1313, Name: "hugo"}, C)
+	})
+}
+
 func TestUnmarshalWithAutomaticEnv(t *testing.T) {
 	t.Setenv("PORT", "1313")
 	t.Setenv("NAME", "Steve")
@@ -1122,6 +1617,62 @@ func TestBindPFlags(t *testing.T) {
 	}
 }
 
+func TestBindPFlagsWithPrefix(t *testing.T) {
+	v := New()
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.Int("port", 8080, "test")
+	flagSet.String("host", "localhost", "test")
+
+	require.NoError(t, v.BindPFlagsWithPrefix("server", flagSet))
+	require.NoError(t, flagSet.Parse([]string{"--port", "9090"}))
+
+	assert.Equal(t, 9090, v.Get("server.port"))
+	assert.Equal(t, "localhost", v.Get("server.host"))
+	assert.Nil(t, v.Get("port"))
+	assert.Equal(t, map[string]any{"server": map[string]any{"port": 9090, "host": "localhost"}}, v.AllSettings())
+
+	require.NoError(t, v.BindPFlagsWithPrefix("", flagSet))
+	assert.Equal(t, 9090, v.Get("port"))
+}
+
+func TestBindFlagValuesFiltered(t *testing.T) {
+	v := New()
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.Bool("help", false, "test")
+	flagSet.Bool("version", false, "test")
+	flagSet.Int("port", 8080, "test")
+
+	internal := map[string]bool{"help": true, "version": true}
+	require.NoError(t, v.BindFlagValuesFiltered(PFlagValueSet(flagSet), func(flag FlagValue) bool {
+		return !internal[flag.Name()]
+	}))
+
+	assert.Equal(t, []string{"port"}, v.AllKeys())
+	assert.Equal(t, 8080, v.Get("port"))
+}
+
+func TestWithFlagNameMapper(t *testing.T) {
+	v := NewWithOptions(WithFlagNameMapper(func(name string) string {
+		return strings.ReplaceAll(name, "-", ".")
+	}))
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.String("log-level", "info", "test")
+	flagSet.Int("port", 8080, "test")
+
+	require.NoError(t, v.BindPFlags(flagSet))
+	require.NoError(t, flagSet.Parse([]string{"--log-level", "debug"}))
+
+	assert.Equal(t, "debug", v.Get("log.level"))
+	assert.Equal(t, 8080, v.Get("port"))
+	assert.Nil(t, v.Get("log-level"))
+
+	require.NoError(t, v.BindPFlagsWithPrefix("app", flagSet))
+	assert.Equal(t, "debug", v.Get("app.log.level"))
+
+	require.NoError(t, v.BindPFlag("log-level", flagSet.Lookup("log-level")))
+	assert.Equal(t, "debug", v.Get("log-level"), "flags bound individually keep their key")
+}
+
 func TestBindPFlagsStringSlice(t *testing.T) {
 	tests := []struct {
 		Expected []string
@@ -1384,6 +1935,141 @@ func TestBindPFlagStringToInt(t *testing.T) {
 	}
 }
 
+func TestUnbindFlag(t *testing.T) {
+	v := New()
+	v.SetDefault("port", 80)
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.Int("port", 8080, "test")
+	require.NoError(t, flagSet.Parse([]string{"--port", "9090"}))
+	require.NoError(t, v.BindPFlag("Port", flagSet.Lookup("port")))
+
+	assert.Equal(t, 9090, v.Get("port"))
+
+	v.UnbindFlag("PORT")
+	assert.Equal(t, 80, v.Get("port"))
+	assert.Empty(t, v.pflags)
+
+	v.UnbindFlag("missing")
+
+	t.Run("DeprecatedFlagAliases", func(t *testing.T) {
+		v := NewWithOptions(WithDeprecatedFlagAliases())
+
+		flags := newDeprecatedFlagSet(t)
+		require.NoError(t, v.BindPFlags(flags))
+		require.NoError(t, flags.Parse([]string{"--loglevel", "debug"}))
+		assert.Equal(t, "debug", v.Get("log-level"))
+
+		// the deprecated flag no longer sets its replacement
+		v.UnbindFlag("loglevel")
+		assert.Equal(t, "info", v.Get("log-level"))
+		assert.Empty(t, v.aliases)
+		assert.Empty(t, v.deprecatedKeys)
+
+		v = NewWithOptions(WithDeprecatedFlagAliases())
+		require.NoError(t, v.BindPFlags(flags))
+
+		v.UnbindFlag("log-level")
+		assert.Nil(t, v.Get("log-level"))
+		assert.Nil(t, v.Get("loglevel"))
+		assert.Empty(t, v.aliases)
+		assert.Empty(t, v.deprecatedKeys)
+	})
+
+	t.Run("LazyFlagBinding", func(t *testing.T) {
+		v := NewWithOptions(WithLazyFlagBinding())
+
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.Int("port", 8080, "test")
+		require.NoError(t, v.BindPFlags(flags))
+
+		v.UnbindFlag("port")
+		assert.Nil(t, v.Get("port"))
+		assert.NotContains(t, v.AllKeys(), "port")
+
+		// binding a flag to the key again
+		require.NoError(t, v.BindPFlag("port", flags.Lookup("port")))
+		assert.Equal(t, 8080, v.Get("port"))
+	})
+}
+
+func TestUnbindEnv(t *testing.T) {
+	t.Setenv("APP_HOST", "")
+	t.Setenv("HOST", "env.internal")
+
+	v := New()
+	v.SetDefault("host", "localhost")
+	require.NoError(t, v.BindEnvWithOptions("host", EnvOptions{Names: []string{"APP_HOST"}, AllowEmpty: true}))
+
+	assert.Equal(t, "", v.Get("host"))
+
+	v.UnbindEnv("Host")
+	assert.Equal(t, "localhost", v.Get("host"))
+	assert.Empty(t, v.env)
+	assert.Empty(t, v.envAllowEmpty)
+
+	require.NoError(t, v.BindEnv("host"))
+	assert.Equal(t, "env.internal", v.Get("host"))
+}
+
+func TestBindPFlagTypes(t *testing.T) {
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.Count("verbose", "test")
+	flagSet.Uint16("workers", 0, "test")
+	flagSet.Float64("ratio", 0, "test")
+	flagSet.Duration("timeout", 0, "test")
+	flagSet.DurationSlice("retries", nil, "test")
+	flagSet.Float64Slice("weights", nil, "test")
+	flagSet.Int64Slice("ids", nil, "test")
+	flagSet.BoolSlice("toggles", nil, "test")
+	flagSet.IPSlice("peers", nil, "test")
+	flagSet.StringToInt64("limits", nil, "test")
+	flagSet.BytesHex("key", nil, "test")
+	flagSet.BytesBase64("secret", nil, "test")
+
+	require.NoError(t, flagSet.Parse([]string{
+		"--verbose", "--verbose",
+		"--workers", "4",
+		"--ratio", "0.5",
+		"--timeout", "5s",
+		"--retries", "1s,2s",
+		"--weights", "0.25,0.75",
+		"--ids", "1,2",
+		"--toggles", "true,false",
+		"--peers", "10.0.0.1,10.0.0.2",
+		"--limits", "cpu=2,memory=1024",
+		"--key", "cafe",
+		"--secret", "aGVsbG8=",
+	}))
+
+	v := New()
+	require.NoError(t, v.BindPFlags(flagSet))
+
+	assert.Equal(t, 2, v.Get("verbose"))
+	assert.Equal(t, uint(4), v.Get("workers"))
+	assert.Equal(t, 0.5, v.Get("ratio"))
+	assert.Equal(t, 5*time.Second, v.Get("timeout"))
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, v.Get("retries"))
+	assert.Equal(t, []float64{0.25, 0.75}, v.Get("weights"))
+	assert.Equal(t, []int64{1, 2}, v.Get("ids"))
+	assert.Equal(t, []bool{true, false}, v.Get("toggles"))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, v.Get("peers"))
+	assert.Equal(t, map[string]any{"cpu": int64(2), "memory": int64(1024)}, v.Get("limits"))
+	assert.Equal(t, []byte{0xca, 0xfe}, v.Get("key"))
+	assert.Equal(t, []byte("hello"), v.Get("secret"))
+
+	var config struct {
+		Timeout time.Duration
+		Weights []float64
+		Limits  map[string]int64
+	}
+	require.NoError(t, v.Unmarshal(&config))
+
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, []float64{0.25, 0.75}, config.Weights)
+	assert.Equal(t, map[string]int64{"cpu": 2, "memory": 1024}, config.Limits)
+}
+
 func TestBoundCaseSensitivity(t *testing.T) {
 	v := New()
 	initConfigs(v)
@@ -1657,6 +2343,54 @@ func TestUnwrapParseErrors(t *testing.T) {
 	assert.ErrorAs(t, v.ReadConfig(bytes.NewBuffer(yamlInvalid)), &ConfigParseError{})
 }
 
+func TestSetKeyType(t *testing.T) {
+	t.Setenv("PORTS", "80 443")
+	t.Setenv("DEBUG", "true")
+	t.Setenv("TIMEOUT", "5s")
+
+	v := New()
+	v.AutomaticEnv()
+	v.RegisterAlias("ttl", "timeout")
+	v.SetKeyType("ports", reflect.TypeOf([]int{}))
+	v.SetKeyType("DEBUG", reflect.TypeOf(false))
+	v.SetKeyType("ttl", reflect.TypeOf(time.Duration(0)))
+	v.SetKeyType("name", reflect.TypeOf(map[string]int{}))
+	v.Set("name", "app")
+
+	assert.Equal(t, []int{80, 443}, v.Get("ports"))
+	assert.Equal(t, []int{80, 443}, v.GetIntSlice("ports"))
+	assert.Equal(t, true, v.Get("debug"))
+	assert.Equal(t, 5*time.Second, v.Get("timeout"))
+	assert.Equal(t, "app", v.Get("name"), "unsupported types are not converted")
+
+	v.SetKeyType("debug", nil)
+	assert.Equal(t, "true", v.Get("debug"))
+}
+
+func TestGetRaw(t *testing.T) {
+	v := New()
+	v.SetTypeByDefaultValue(true)
+	v.SetDefault("port", 0)
+	v.SetDefault("tags", []string{"a", "b"})
+	v.Set("port", "8080")
+	v.Set("db", map[string]any{"hosts": []any{"one", "two"}})
+
+	assert.Equal(t, 8080, v.Get("port"))
+	assert.Equal(t, "8080", v.GetRaw("PORT"))
+	assert.Nil(t, v.GetRaw("missing"))
+
+	db := v.GetRaw("db").(map[string]any)
+	db["hosts"].([]any)[0] = "changed"
+	db["user"] = "root"
+
+	tags := v.GetRaw("tags").([]string)
+	tags[0] = "changed"
+
+	assert.Equal(t, []any{"one", "two"}, v.Get("db.hosts"))
+	assert.Nil(t, v.Get("db.user"))
+	assert.Equal(t, []string{"a", "b"}, v.GetStringSlice("tags"))
+}
+
 func TestSub(t *testing.T) {
 	v := New()
 	v.SetConfigType("yaml")
@@ -1685,7 +2419,7 @@ func TestSubWithKeyDelimiter(t *testing.T) {
 	v := NewWithOptions(KeyDelimiter("::"))
 	v.SetConfigType("yaml")
 	r := strings.NewReader(string(yamlExampleWithDot))
-	err := v.unmarshalReader(r, v.config)
+	err := v.unmarshalReader(r, v.config.load())
 	require.NoError(t, err)
 
 	subv := v.Sub("emails")
@@ -1969,6 +2703,34 @@ func TestSafeWriteConfigAsWithExistingFile(t *testing.T) {
 	assert.True(t, ok, "Expected ConfigFileAlreadyExistsError")
 }
 
+func TestWriteOverridesAs(t *testing.T) {
+	v := New()
+	fs := afero.NewMemMapFs()
+	v.SetFs(fs)
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(yamlExample)))
+
+	v.Set("name", "jane")
+	v.Set("clothing.jacket", "denim")
+
+	require.NoError(t, v.WriteOverridesAs("/test/config.local.yaml"))
+
+	read, err := afero.ReadFile(fs, "/test/config.local.yaml")
+	require.NoError(t, err)
+	assert.YAMLEq(t, "name: jane\nclothing:\n  jacket: denim\n", string(read))
+
+	base := New()
+	base.SetFs(fs)
+	base.SetConfigType("yaml")
+	require.NoError(t, base.ReadConfig(bytes.NewBuffer(yamlExample)))
+	base.SetConfigFile("/test/config.local.yaml")
+	require.NoError(t, base.MergeInConfig())
+
+	assert.Equal(t, "jane", base.GetString("name"))
+	assert.Equal(t, "denim", base.GetString("clothing.jacket"))
+	assert.Equal(t, "denim", base.GetString("clothing.trousers"))
+}
+
 func TestWriteHiddenFile(t *testing.T) {
 	v := New()
 	fs := afero.NewMemMapFs()
@@ -2192,7 +2954,7 @@ func TestDotParameter(t *testing.T) {
 
 	// should take precedence over batters defined in jsonExample
 	r := bytes.NewReader([]byte(`{ "batters.batter": [ { "type": "Small" } ] }`))
-	v.unmarshalReader(r, v.config)
+	v.unmarshalReader(r, v.config.load())
 
 	actual := v.Get("batters.batter")
 	expected := []any{map[string]any{"type": "Small"}}
@@ -2338,6 +3100,21 @@ func newViperWithConfigFile(t *testing.T) (*Viper, string) {
 	return v, configFile
 }
 
+// writeConfigFile replaces the content of a watched config file atomically,
+// so that watchers never read it half-written.
+func writeConfigFile(t *testing.T, configFile string, content string) {
+	t.Helper()
+
+	tmp, err := os.CreateTemp(filepath.Dir(configFile), ".config-*.tmp")
+	require.NoError(t, err)
+
+	_, err = tmp.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, tmp.Close())
+	require.NoError(t, os.Chmod(tmp.Name(), 0o640))
+	require.NoError(t, os.Rename(tmp.Name(), configFile))
+}
+
 func newViperWithSymlinkedConfigFile(t *testing.T) (*Viper, string, string) {
 	watchDir := t.TempDir()
 	dataDir1 := path.Join(watchDir, "data1")
@@ -2383,7 +3160,7 @@ func TestWatchFile(t *testing.T) {
 				wg.Done()
 			})
 		})
-		v.WatchConfig()
+		require.NoError(t, v.WatchConfigE())
 		// when overwriting the file and waiting for the custom change notification handler to be triggered
 		err = os.WriteFile(configFile, []byte("foo: baz\n"), 0o640)
 		wg.Wait()
@@ -2399,7 +3176,7 @@ func TestWatchFile(t *testing.T) {
 		}
 		v, watchDir, _ := newViperWithSymlinkedConfigFile(t)
 		wg := sync.WaitGroup{}
-		v.WatchConfig()
+		require.NoError(t, v.WatchConfigE())
 		v.OnConfigChange(func(_ fsnotify.Event) {
 			t.Logf("config file changed")
 			wg.Done()
@@ -2422,6 +3199,86 @@ func TestWatchFile(t *testing.T) {
 	})
 }
 
+func TestWatchFile_FakeWatcher(t *testing.T) {
+	// given a `config.yaml` file being watched, with events delivered by a fake watcher
+	fs := afero.NewMemMapFs()
+	configFile := testutil.AbsFilePath(t, "/etc/viper/config.yaml")
+	require.NoError(t, afero.WriteFile(fs, configFile, []byte("foo: bar\n"), 0o640))
+
+	watcher := newFakeWatcher()
+	v := NewWithOptions(WithFileWatcher(watcher))
+	v.SetFs(fs)
+	v.SetConfigFile(configFile)
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 1)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	// when overwriting the file and notifying the watcher
+	require.NoError(t, afero.WriteFile(fs, configFile, []byte("foo: baz\n"), 0o640))
+	watcher.events <- fsnotify.Event{Name: configFile, Op: fsnotify.Write}
+
+	// then the config value should have changed
+	assert.Equal(t, "baz", <-changes)
+}
+
+func TestWatchConfig_MergedFiles(t *testing.T) {
+	watchDir := t.TempDir()
+	baseFile := path.Join(watchDir, "base.yaml")
+	overrideDir := path.Join(watchDir, "override")
+	overrideFile := path.Join(overrideDir, "override.yaml")
+
+	require.NoError(t, os.Mkdir(overrideDir, 0o777))
+	require.NoError(t, os.WriteFile(baseFile, []byte("foo: bar\nbaz: qux\n"), 0o640))
+	require.NoError(t, os.WriteFile(overrideFile, []byte("baz: override\n"), 0o640))
+
+	v := New()
+	v.SetConfigType("yaml")
+	v.SetConfigFile(baseFile)
+	require.NoError(t, v.ReadInConfig())
+	v.SetConfigFile(overrideFile)
+	require.NoError(t, v.MergeInConfig())
+
+	assert.Equal(t, []configSource{{file: baseFile, format: "yaml"}, {file: overrideFile, format: "yaml"}}, v.configSources)
+	assert.Equal(t, "override", v.Get("baz"))
+
+	// settings are read from the watcher goroutine to avoid racing with reloads
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo") + "/" + v.GetString("baz")
+	})
+	require.NoError(t, v.WatchConfigE())
+
+	waitFor := func(want string) {
+		t.Helper()
+
+		timeout := time.After(5 * time.Second)
+
+		for {
+			select {
+			case got := <-changes:
+				if got == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", want)
+			}
+		}
+	}
+
+	// changing the base file re-applies the override
+	require.NoError(t, os.WriteFile(baseFile, []byte("foo: changed\nbaz: qux\n"), 0o640))
+	waitFor("changed/override")
+
+	// changing the override file is picked up too
+	require.NoError(t, os.WriteFile(overrideFile, []byte("baz: changed\n"), 0o640))
+	waitFor("changed/changed")
+}
+
 func TestUnmarshal_DotSeparatorBackwardCompatibility(t *testing.T) {
 	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
 	flags.String("foo.bar", "cobra_flag", "")
@@ -2464,7 +3321,7 @@ func TestKeyDelimiter(t *testing.T) {
 	v.SetConfigType("yaml")
 	r := strings.NewReader(string(yamlExampleWithDot))
 
-	err := v.unmarshalReader(r, v.config)
+	err := v.unmarshalReader(r, v.config.load())
 	require.NoError(t, err)
 
 	values := map[string]any{
@@ -2538,7 +3395,7 @@ func TestSliceIndexAccess(t *testing.T) {
 	v.SetConfigType("yaml")
 	r := strings.NewReader(string(yamlDeepNestedSlices))
 
-	err := v.unmarshalReader(r, v.config)
+	err := v.unmarshalReader(r, v.config.load())
 	require.NoError(t, err)
 
 	assert.Equal(t, "The Expanse", v.GetString("tv.0.title"))
@@ -2602,6 +3459,31 @@ func TestFlagShadow(t *testing.T) {
 	assert.Equal(t, "", v.GetString("foo.bar1.bar2"))
 }
 
+func TestIgnoreUnchangedFlagDefaults(t *testing.T) {
+	v := NewWithOptions(IgnoreUnchangedFlagDefaults())
+	v.SetDefault("foo.bar1.bar2", "default")
+	v.SetDefault("port", 8080)
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("foo.bar1", "shadowed", "")
+	flags.Int("port", 1138, "")
+	flags.String("name", "app", "")
+	flags.String("host", "localhost", "")
+	require.NoError(t, v.BindPFlags(flags))
+
+	assert.Equal(t, "default", v.GetString("foo.bar1.bar2"), "unchanged flags do not shadow nested keys")
+	assert.Equal(t, 8080, v.GetInt("port"))
+	assert.Nil(t, v.Get("name"), "unchanged flags do not supply values")
+	assert.ElementsMatch(t, []string{"foo.bar1.bar2", "port"}, v.AllKeys())
+
+	require.NoError(t, flags.Parse([]string{"--port", "9090", "--foo.bar1", "changed", "--name", "cli"}))
+
+	assert.Equal(t, 9090, v.GetInt("port"))
+	assert.Equal(t, "cli", v.Get("name"))
+	assert.Equal(t, "", v.GetString("foo.bar1.bar2"))
+	assert.ElementsMatch(t, []string{"foo.bar1", "port", "name"}, v.AllKeys())
+}
+
 func BenchmarkGetBool(b *testing.B) {
 	key := "BenchmarkGetBool"
 	v = New()
@@ -2645,3 +3527,314 @@ func skipWindows(t *testing.T) {
 		t.Skip("Skip test on Windows")
 	}
 }
+
+func TestSetStruct(t *testing.T) {
+	type database struct {
+		Host string `mapstructure:"host"`
+		Port int    `mapstructure:"port"`
+	}
+
+	type config struct {
+		Database database `mapstructure:"database"`
+		Debug    bool
+	}
+
+	v := New()
+	v.SetDefault("app", config{Database: database{Host: "localhost", Port: 5432}})
+	v.Set("server", database{Host: "example.com", Port: 80})
+
+	assert.Equal(t, "localhost", v.GetString("app.database.host"))
+	assert.Equal(t, 5432, v.GetInt("app.database.port"))
+	assert.False(t, v.GetBool("app.debug"))
+	assert.Equal(t, "example.com", v.GetString("server.host"))
+
+	assert.ElementsMatch(
+		t,
+		[]string{"app.database.host", "app.database.port", "app.debug", "server.host", "server.port"},
+		v.AllKeys(),
+	)
+
+	var got config
+	require.NoError(t, v.UnmarshalKey("app", &got))
+	assert.Equal(t, config{Database: database{Host: "localhost", Port: 5432}}, got)
+}
+
+func TestMergeConfigMapWithConflictHandler(t *testing.T) {
+	type conflict struct {
+		key        string
+		oldV, newV any
+	}
+
+	var conflicts []conflict
+
+	v := NewWithOptions(WithMergeConflictHandler(func(key string, oldV, newV any) any {
+		conflicts = append(conflicts, conflict{key, oldV, newV})
+
+		if key == "server.port" {
+			return oldV
+		}
+
+		return newV
+	}))
+
+	require.NoError(t, v.MergeConfigMap(map[string]any{
+		"server": map[string]any{
+			"host": "localhost",
+			"port": 8080,
+		},
+		"name": "app",
+	}))
+
+	require.NoError(t, v.MergeConfigMap(map[string]any{
+		"server": map[string]any{
+			"host": "example.com",
+			"port": 80,
+		},
+		"name": map[string]any{
+			"first": "app",
+		},
+		"debug": true,
+	}))
+
+	assert.Equal(t, "example.com", v.GetString("server.host"))
+	assert.Equal(t, 8080, v.GetInt("server.port"))
+	assert.Equal(t, "app", v.GetString("name.first"))
+	assert.True(t, v.GetBool("debug"))
+
+	assert.ElementsMatch(t, []conflict{
+		{"server.host", "localhost", "example.com"},
+		{"server.port", 8080, 80},
+		{"name", "app", map[string]any{"first": "app"}},
+	}, conflicts)
+
+	t.Run("Fragments", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("port: 8080\n"), 0o644))
+		require.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/10-port.yaml", []byte("port: 80\n"), 0o644))
+
+		v := NewWithOptions(WithMergeConflictHandler(func(_ string, oldV, _ any) any {
+			t.Error("merge conflict handler called while reading config files")
+
+			return oldV
+		}))
+		v.SetFs(fs)
+		v.SetConfigFile("/etc/app/config.yaml")
+		v.AddConfigFragmentDir("/etc/app/conf.d")
+		require.NoError(t, v.ReadInConfig())
+
+		assert.Equal(t, 80, v.GetInt("port"))
+	})
+}
+
+func TestStopWatch(t *testing.T) {
+	v, configFile := newViperWithConfigFile(t)
+
+	// stopping without a watcher is a no-op
+	v.StopWatch()
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+
+	writeConfigFile(t, configFile, "foo: baz\n")
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "baz", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+
+	v.StopWatch()
+	v.StopWatch()
+
+	// the event loop exited: changes are no longer picked up
+	writeConfigFile(t, configFile, "foo: qux\n")
+
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected config change after StopWatch: %q", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	assert.Equal(t, "baz", v.GetString("foo"))
+}
+
+func TestWatchConfigWithContext(t *testing.T) {
+	v, configFile := newViperWithConfigFile(t)
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, v.WatchConfigWithContext(ctx))
+
+	writeConfigFile(t, configFile, "foo: baz\n")
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "baz", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+
+	cancel()
+
+	// the watcher is released once its event loop exited
+	done := make(chan struct{})
+	go func() {
+		v.watches.mu.Lock()
+		watches := slices.Clone(v.watches.watches)
+		v.watches.mu.Unlock()
+
+		for _, watch := range watches {
+			<-watch.done
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watcher to exit")
+	}
+
+	writeConfigFile(t, configFile, "foo: qux\n")
+
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected config change after cancellation: %q", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatchConfig_Error(t *testing.T) {
+	t.Run("ConfigFileNotFound", func(t *testing.T) {
+		v := New()
+		v.SetConfigName("missing")
+		v.AddConfigPath(t.TempDir())
+
+		var notFound ConfigFileNotFoundError
+		require.ErrorAs(t, v.WatchConfigE(), &notFound)
+	})
+
+	t.Run("MissingConfigDir", func(t *testing.T) {
+		v := New()
+		v.SetConfigFile(filepath.Join(t.TempDir(), "missing", "config.yaml"))
+
+		require.ErrorIs(t, v.WatchConfigE(), fs.ErrNotExist)
+	})
+
+	t.Run("Deprecated", func(t *testing.T) {
+		var logs bytes.Buffer
+
+		v := NewWithOptions(WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+		v.SetConfigFile(filepath.Join(t.TempDir(), "missing", "config.yaml"))
+
+		v.WatchConfig()
+
+		assert.Contains(t, logs.String(), "watch config")
+	})
+}
+
+func TestWatchConfig_Debounce(t *testing.T) {
+	configFile := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: bar\n"), 0o640))
+
+	v := NewWithOptions(WithWatchDebounce(200 * time.Millisecond))
+	v.SetConfigFile(configFile)
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	for _, value := range []string{"one", "two", "three"} {
+		require.NoError(t, os.WriteFile(configFile, []byte("foo: "+value+"\n"), 0o640))
+	}
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "three", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected second config change: %q", got)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestOnConfigChangeDiff(t *testing.T) {
+	configFile := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: bar\ndb:\n  host: localhost\n  port: 5432\n"), 0o640))
+
+	// coalesce the events of the write so that the diff is computed once
+	v := NewWithOptions(WithWatchDebounce(100 * time.Millisecond))
+	v.SetConfigFile(configFile)
+	require.NoError(t, v.ReadInConfig())
+
+	diffs := make(chan []KeyChange, 10)
+	v.OnConfigChangeDiff(func(changes []KeyChange) {
+		diffs <- changes
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("db:\n  host: db.internal\n  port: 5432\n  user: root\n"), 0o640))
+
+	select {
+	case changes := <-diffs:
+		assert.Equal(t, []KeyChange{
+			{Key: "db.host", Old: "localhost", New: "db.internal"},
+			{Key: "db.user", New: "root"},
+			{Key: "foo", Old: "bar"},
+		}, changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+}
+
+func TestWatchConfig_SkipUnchangedReloads(t *testing.T) {
+	configFile := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: bar\n"), 0o640))
+
+	// coalesce the events of each write so that files are never read while truncated
+	v := NewWithOptions(WithSkipUnchangedReloads(), WithWatchDebounce(100*time.Millisecond))
+	v.SetConfigFile(configFile)
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	// rewriting identical content is not a change
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: bar\n"), 0o640))
+
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected config change: %q", got)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: baz\n"), 0o640))
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "baz", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+}
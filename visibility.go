@@ -0,0 +1,154 @@
+package viper
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+
+	"github.com/spf13/viper/internal/maputil"
+)
+
+// Visibility describes who may see the value of a key.
+type Visibility int
+
+const (
+	// VisibilityPublic keys can be shown to anyone, including end users. This is the default.
+	VisibilityPublic Visibility = iota
+
+	// VisibilityInternal keys are meant for operators and support, but not for end users.
+	VisibilityInternal
+
+	// VisibilitySecret keys hold credentials or other sensitive values that are never shown.
+	VisibilitySecret
+)
+
+// String returns the name of the visibility level.
+func (vis Visibility) String() string {
+	switch vis {
+	case VisibilityPublic:
+		return "public"
+	case VisibilityInternal:
+		return "internal"
+	case VisibilitySecret:
+		return "secret"
+	default:
+		return "unknown"
+	}
+}
+
+// Audience selects which keys Export returns, based on their visibility.
+type Audience int
+
+const (
+	// ForEndUser only exports public keys.
+	ForEndUser Audience = iota
+
+	// ForSupportBundle exports public and internal keys.
+	// Secret keys are exported with their value replaced by RedactedValue,
+	// so that it is still visible whether they are set.
+	ForSupportBundle
+)
+
+// RedactedValue replaces the value of secret keys in exported settings.
+const RedactedValue = "[REDACTED]"
+
+// SetKeyVisibility sets the visibility of a key.
+// SetKeyVisibility is case-insensitive for a key.
+//
+// The visibility applies to nested keys as well, unless they have their own visibility:
+// marking "db" as internal and "db.password" as secret hides the whole "db" section from end users
+// and redacts the password in support bundles.
+func SetKeyVisibility(key string, vis Visibility) { v.SetKeyVisibility(key, vis) }
+
+func (v *Viper) SetKeyVisibility(key string, vis Visibility) {
+	if v.visibility == nil {
+		v.visibility = make(map[string]Visibility)
+	}
+
+	v.visibility[v.realKey(strings.ToLower(key))] = vis
+}
+
+// KeyVisibility returns the visibility of a key,
+// inherited from the closest parent key when it has none of its own.
+// KeyVisibility is case-insensitive for a key.
+func KeyVisibility(key string) Visibility { return v.KeyVisibility(key) }
+
+func (v *Viper) KeyVisibility(key string) Visibility {
+	path := strings.Split(v.realKey(strings.ToLower(key)), v.keyDelim)
+
+	for i := len(path); i > 0; i-- {
+		if vis, ok := v.visibility[strings.Join(path[:i], v.keyDelim)]; ok {
+			return vis
+		}
+	}
+
+	return VisibilityPublic
+}
+
+// Export returns the settings visible to an audience, as a nested map (see AllSettings).
+//
+// Use it instead of AllSettings when showing the configuration to someone else
+// (eg. in customer-facing pages or in diagnostic bundles sent to support).
+func Export(audience Audience) map[string]any { return v.Export(audience) }
+
+func (v *Viper) Export(audience Audience) map[string]any {
+	m := map[string]any{}
+
+	for _, k := range v.AllKeys() {
+		vis := v.KeyVisibility(k)
+
+		var value any
+
+		switch {
+		case vis == VisibilityPublic:
+			value = v.Get(k)
+		case audience == ForSupportBundle && vis == VisibilityInternal:
+			value = v.Get(k)
+		case audience == ForSupportBundle && vis == VisibilitySecret:
+			value = RedactedValue
+		}
+
+		if value == nil {
+			continue
+		}
+
+		path := strings.Split(k, v.keyDelim)
+		lastKey := strings.ToLower(path[len(path)-1])
+		deepestMap := maputil.DeepSearch(m, path[0:len(path)-1])
+		// set innermost value
+		deepestMap[lastKey] = value
+	}
+
+	return m
+}
+
+// redactSecretKeys returns a copy of the nested map of settings at prefix
+// with RedactedValue in place of the values of secret keys.
+func (v *Viper) redactSecretKeys(m map[string]any, prefix string) map[string]any {
+	if m == nil {
+		return nil
+	}
+
+	redacted := make(map[string]any, len(m))
+
+	for k, val := range m {
+		key := prefix + k
+
+		if v.KeyVisibility(key) == VisibilitySecret {
+			redacted[k] = RedactedValue
+
+			continue
+		}
+
+		switch val := val.(type) {
+		case map[string]any:
+			redacted[k] = v.redactSecretKeys(val, key+v.keyDelim)
+		case map[any]any:
+			redacted[k] = v.redactSecretKeys(cast.ToStringMap(val), key+v.keyDelim)
+		default:
+			redacted[k] = val
+		}
+	}
+
+	return redacted
+}
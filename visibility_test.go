@@ -0,0 +1,43 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	v := New()
+	v.Set("name", "app")
+	v.Set("db.host", "db.internal")
+	v.Set("db.password", "hunter2")
+	v.Set("api.token", "s3cr3t")
+	v.Set("api.url", "https://api.example.com")
+
+	v.SetKeyVisibility("DB", VisibilityInternal)
+	v.SetKeyVisibility("db.password", VisibilitySecret)
+	v.SetKeyVisibility("api.token", VisibilitySecret)
+
+	assert.Equal(t, VisibilityInternal, v.KeyVisibility("db.host"))
+	assert.Equal(t, VisibilitySecret, v.KeyVisibility("db.password"))
+	assert.Equal(t, VisibilityPublic, v.KeyVisibility("name"))
+
+	assert.Equal(t, map[string]any{
+		"name": "app",
+		"api": map[string]any{
+			"url": "https://api.example.com",
+		},
+	}, v.Export(ForEndUser))
+
+	assert.Equal(t, map[string]any{
+		"name": "app",
+		"db": map[string]any{
+			"host":     "db.internal",
+			"password": RedactedValue,
+		},
+		"api": map[string]any{
+			"token": RedactedValue,
+			"url":   "https://api.example.com",
+		},
+	}, v.Export(ForSupportBundle))
+}
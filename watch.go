@@ -0,0 +1,280 @@
+package viper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// WithWatchDebounce coalesces bursts of file system events received while watching
+// config files (eg. editors and Kubernetes ConfigMap updates writing files in several steps):
+// config files are only read again, and OnConfigChange only called, once no other change
+// was detected for the given duration. The handler receives the last event of the burst.
+func WithWatchDebounce(d time.Duration) Option {
+	return optionFunc(func(v *Viper) {
+		v.watchDebounce = d
+	})
+}
+
+// WithSkipUnchangedReloads makes WatchConfig compare a checksum of the content of config files
+// whenever a change is detected, and skip reading them again (and calling OnConfigChange)
+// when their content did not change (eg. when a configuration management tool rewrites identical files).
+func WithSkipUnchangedReloads() Option {
+	return optionFunc(func(v *Viper) {
+		v.skipUnchangedReloads = true
+	})
+}
+
+// WithReloadRateLimit limits how often WatchConfig reads config files again,
+// so that a writer touching them in a tight loop does not keep the process busy parsing them.
+//
+// Reloads are limited by a token bucket: up to burst reloads happen right away,
+// then at most one per interval. Changes detected in the meantime are coalesced
+// into a single reload (with the last event) once the interval elapsed.
+func WithReloadRateLimit(interval time.Duration, burst int) Option {
+	return optionFunc(func(v *Viper) {
+		v.reloadInterval = interval
+		v.reloadBurst = max(burst, 1)
+	})
+}
+
+// reloadLimiter is a token bucket limiting the frequency of reloads.
+// A nil limiter does not limit anything.
+type reloadLimiter struct {
+	interval time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+func newReloadLimiter(interval time.Duration, burst int) *reloadLimiter {
+	if interval <= 0 {
+		return nil
+	}
+
+	return &reloadLimiter{
+		interval: interval,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+	}
+}
+
+// reserve takes a token if one is available and returns zero.
+// Otherwise it returns how long to wait for the next token.
+func (l *reloadLimiter) reserve(now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) * float64(l.interval))
+}
+
+// configWatches tracks the config watchers started by WatchConfig.
+type configWatches struct {
+	mu      sync.Mutex
+	watches []configWatch
+	paused  bool
+}
+
+// configWatch controls the goroutine of a single config watcher.
+type configWatch struct {
+	stop   chan struct{} // closed to ask the event loop to exit
+	done   chan struct{} // closed once the watcher is released
+	resume chan bool     // receives whether to reload changes held while paused
+}
+
+func (w *configWatches) add() configWatch {
+	watch := configWatch{
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		resume: make(chan bool, 1),
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// forget about watchers that already exited on their own (eg. after a watcher error)
+	w.watches = slices.DeleteFunc(w.watches, func(watch configWatch) bool {
+		select {
+		case <-watch.done:
+			return true
+		default:
+			return false
+		}
+	})
+	w.watches = append(w.watches, watch)
+
+	return watch
+}
+
+// StopWatch stops watching config files for changes.
+//
+// It stops every watcher started by WatchConfig, releases the underlying
+// file system watchers and waits for their event loops to exit.
+// Calling StopWatch when no watcher is running is a no-op.
+//
+// StopWatch must not be called from the OnConfigChange handler,
+// as it waits for the event loop running the handler to exit.
+func StopWatch() { v.StopWatch() }
+
+func (v *Viper) StopWatch() {
+	v.watches.mu.Lock()
+	watches := v.watches.watches
+	v.watches.watches = nil
+	v.watches.mu.Unlock()
+
+	for _, watch := range watches {
+		close(watch.stop)
+	}
+
+	for _, watch := range watches {
+		<-watch.done
+	}
+}
+
+// PauseWatch pauses the handling of config file changes by WatchConfig
+// (eg. while the application writes its own config files with WriteConfig),
+// until ResumeWatch is called.
+//
+// Changes detected while paused are not read, and OnConfigChange is not called for them.
+func PauseWatch() { v.PauseWatch() }
+
+func (v *Viper) PauseWatch() {
+	v.watches.mu.Lock()
+	defer v.watches.mu.Unlock()
+
+	v.watches.paused = true
+}
+
+// ResumeWatch resumes the handling of config file changes paused by PauseWatch.
+//
+// When reload is true and config files changed while paused, they are read again once
+// (calling OnConfigChange with the last change detected). Otherwise those changes are dropped.
+func ResumeWatch(reload bool) { v.ResumeWatch(reload) }
+
+func (v *Viper) ResumeWatch(reload bool) {
+	v.watches.mu.Lock()
+	defer v.watches.mu.Unlock()
+
+	v.watches.paused = false
+
+	for _, watch := range v.watches.watches {
+		watch.resumeWith(reload)
+	}
+}
+
+// resumeWith notifies the event loop that watching resumed,
+// merging with a notification it did not receive yet.
+func (w configWatch) resumeWith(reload bool) {
+	for {
+		select {
+		case w.resume <- reload:
+			return
+		case queued := <-w.resume:
+			reload = reload || queued
+		}
+	}
+}
+
+func (w *configWatches) isPaused() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.paused
+}
+
+// KeyChange describes a setting that changed when config files were read again.
+type KeyChange struct {
+	// Key is the full key of the setting (eg. "db.host").
+	Key string
+
+	// Old is the value before the change, nil if the key was added.
+	Old any
+
+	// New is the value after the change, nil if the key was removed.
+	New any
+}
+
+// flatSettings returns the value of every key.
+func (v *Viper) flatSettings() map[string]any {
+	settings := make(map[string]any)
+
+	for _, key := range v.AllKeys() {
+		settings[key] = v.Get(key)
+	}
+
+	return settings
+}
+
+// diffSettings compares two sets of flat settings, returning the changes sorted by key.
+func diffSettings(before, after map[string]any) []KeyChange {
+	var changes []KeyChange
+
+	for key, old := range before {
+		if val, ok := after[key]; !ok || !reflect.DeepEqual(old, val) {
+			changes = append(changes, KeyChange{Key: key, Old: old, New: val})
+		}
+	}
+
+	for key, val := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, KeyChange{Key: key, New: val})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b KeyChange) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return changes
+}
+
+// hashConfigSources returns a checksum of the content of every config file of sources,
+// including the fragments of fragment directories.
+func (v *Viper) hashConfigSources(sources []configSource) (string, error) {
+	h := sha256.New()
+
+	for _, source := range sources {
+		files := []configSource{source}
+
+		if source.dir {
+			var err error
+
+			files, err = v.readConfigFragments(source.file)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		for _, file := range files {
+			b, err := afero.ReadFile(v.fs, file.file)
+			if err != nil {
+				return "", err
+			}
+
+			// the name is part of the checksum so that renaming fragments is a change
+			fmt.Fprintf(h, "%s\x00%d\x00", file.file, len(b))
+			h.Write(b)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,337 @@
+package viper
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+)
+
+// WithPollingWatcher makes WatchConfig detect changes by polling config files
+// at the given interval instead of relying on file system notifications,
+// which are not available on some file systems (eg. NFS mounts, some FUSE file systems)
+// or in restricted containers.
+//
+// Files are considered changed when their modification time or size changes
+// and their content hash differs. Changes go through the same pipeline as notifications
+// (including WithWatchDebounce and OnConfigChange).
+func WithPollingWatcher(interval time.Duration) Option {
+	return optionFunc(func(v *Viper) {
+		v.watchPollInterval = interval
+	})
+}
+
+// Watcher reports changes of the files of watched directories to WatchConfig.
+//
+// WatchConfig adds the directories of the config files with Add,
+// handles events for the config files until it is stopped, and then closes the watcher.
+type Watcher interface {
+	// Add starts watching a directory.
+	Add(name string) error
+
+	// Close stops watching and closes the Events and Errors channels.
+	Close() error
+
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+}
+
+// WithFileWatcher makes WatchConfig use the given watcher instead of file system notifications
+// (or polling, see WithPollingWatcher).
+// This lets tests deliver synthetic events, and platforms without fsnotify support plug in their own backend.
+//
+// The watcher is owned by the Viper instance and can only be used by a single call to WatchConfig.
+func WithFileWatcher(w Watcher) Option {
+	return optionFunc(func(v *Viper) {
+		v.fileWatcher = w
+	})
+}
+
+func (v *Viper) newFileWatcher() (Watcher, error) {
+	if v.fileWatcher != nil {
+		return v.fileWatcher, nil
+	}
+
+	if v.watchPollInterval > 0 {
+		return newPollingWatcher(v.fs, v.watchPollInterval), nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	return fsnotifyWatcher{watcher}, nil
+}
+
+// symlinkTargetDir returns the directory of the real file of a config file
+// when it is not the directory of the config file itself (eg. when the config file is a symlink),
+// or an empty string.
+func symlinkTargetDir(configFile, realConfigFile string) string {
+	if realConfigFile == "" {
+		return ""
+	}
+
+	realDir := filepath.Dir(realConfigFile)
+
+	// the directory of the config file may be a symlink itself (eg. /var on macOS)
+	if configDir, err := filepath.EvalSymlinks(filepath.Dir(configFile)); err == nil && configDir == realDir {
+		return ""
+	}
+
+	return realDir
+}
+
+// kubernetesDataLink is the symlink kubelet swaps to update the files
+// of ConfigMap and Secret volumes atomically.
+const kubernetesDataLink = "..data"
+
+// kubernetesDataLinkOf returns the "..data" symlink of the Kubernetes projected volume
+// containing a config file, or an empty string if the config file is not part of one.
+//
+// Projected volumes expose files as symlinks to "..data/<file>", where "..data" is a symlink
+// to a timestamped directory. Kubelet writes updated files to a new timestamped directory,
+// and then atomically renames a new symlink to "..data".
+func kubernetesDataLinkOf(configFile string) string {
+	dir := filepath.Dir(configFile)
+
+	// the config file is referenced through the "..data" symlink itself
+	if filepath.Base(dir) == kubernetesDataLink {
+		return dir
+	}
+
+	link := filepath.Join(dir, kubernetesDataLink)
+	if info, err := os.Lstat(link); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return ""
+	}
+
+	return link
+}
+
+// fsnotifyWatcher adapts fsnotify.Watcher to Watcher.
+type fsnotifyWatcher struct {
+	*fsnotify.Watcher
+}
+
+func (w fsnotifyWatcher) Events() <-chan fsnotify.Event { return w.Watcher.Events }
+func (w fsnotifyWatcher) Errors() <-chan error          { return w.Watcher.Errors }
+
+// pollingWatcher detects changes by periodically listing watched directories.
+type pollingWatcher struct {
+	fs       afero.Fs
+	interval time.Duration
+
+	events chan fsnotify.Event
+	errors chan error
+	done   chan struct{}
+	exited chan struct{}
+
+	closeOnce sync.Once
+
+	mu   sync.Mutex
+	dirs map[string]map[string]polledFile
+}
+
+// polledFile is the state of a file as of the last poll.
+type polledFile struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+func newPollingWatcher(fs afero.Fs, interval time.Duration) *pollingWatcher {
+	w := &pollingWatcher{
+		fs:       fs,
+		interval: interval,
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+		exited:   make(chan struct{}),
+		dirs:     make(map[string]map[string]polledFile),
+	}
+
+	go w.run()
+
+	return w
+}
+
+func (w *pollingWatcher) Events() <-chan fsnotify.Event { return w.events }
+func (w *pollingWatcher) Errors() <-chan error          { return w.errors }
+
+// Add starts watching the files of a directory.
+func (w *pollingWatcher) Add(name string) error {
+	files, err := w.list(name, nil)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.dirs[name] = files
+
+	return nil
+}
+
+// Close stops polling and closes the event channels.
+func (w *pollingWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		<-w.exited
+
+		close(w.events)
+		close(w.errors)
+	})
+
+	return nil
+}
+
+func (w *pollingWatcher) run() {
+	defer close(w.exited)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case <-ticker.C:
+			if !w.poll() {
+				return
+			}
+		}
+	}
+}
+
+// poll compares every watched directory with its previous state and sends the resulting events.
+// It reports whether the watcher is still open.
+func (w *pollingWatcher) poll() bool {
+	w.mu.Lock()
+	dirs := make([]string, 0, len(w.dirs))
+	for dir := range w.dirs {
+		dirs = append(dirs, dir)
+	}
+	w.mu.Unlock()
+
+	slices.Sort(dirs)
+
+	for _, dir := range dirs {
+		w.mu.Lock()
+		previous := w.dirs[dir]
+		w.mu.Unlock()
+
+		files, err := w.list(dir, previous)
+		if err != nil {
+			if !w.send(nil, err) {
+				return false
+			}
+
+			continue
+		}
+
+		w.mu.Lock()
+		w.dirs[dir] = files
+		w.mu.Unlock()
+
+		for _, event := range diffPolledFiles(dir, previous, files) {
+			if !w.send(&event, nil) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (w *pollingWatcher) send(event *fsnotify.Event, err error) bool {
+	if event != nil {
+		select {
+		case w.events <- *event:
+			return true
+		case <-w.done:
+			return false
+		}
+	}
+
+	select {
+	case w.errors <- err:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// list returns the state of the files of a directory.
+// The content of files is only hashed again when their modification time or size changed.
+func (w *pollingWatcher) list(dir string, previous map[string]polledFile) (map[string]polledFile, error) {
+	entries, err := afero.ReadDir(w.fs, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]polledFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]polledFile, len(entries))
+
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+
+		// follow symbolic links (eg. Kubernetes ConfigMap mounts)
+		info, err := w.fs.Stat(name)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		file := polledFile{modTime: info.ModTime(), size: info.Size()}
+
+		if prev, ok := previous[entry.Name()]; ok && prev.modTime.Equal(file.modTime) && prev.size == file.size {
+			file.hash = prev.hash
+		} else if b, err := afero.ReadFile(w.fs, name); err == nil {
+			file.hash = sha256.Sum256(b)
+		}
+
+		files[entry.Name()] = file
+	}
+
+	return files, nil
+}
+
+// diffPolledFiles returns the events turning the previous state of a directory into the current one,
+// sorted by file name.
+func diffPolledFiles(dir string, previous, current map[string]polledFile) []fsnotify.Event {
+	var events []fsnotify.Event
+
+	for name, file := range current {
+		prev, ok := previous[name]
+
+		switch {
+		case !ok:
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Create})
+		case prev.hash != file.hash:
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Write})
+		}
+	}
+
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Remove})
+		}
+	}
+
+	slices.SortFunc(events, func(a, b fsnotify.Event) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return events
+}
@@ -0,0 +1,506 @@
+package viper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPollingWatcher(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: bar\n"), 0o644))
+
+	v := NewWithOptions(WithPollingWatcher(10 * time.Millisecond))
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	// settings are read from the watcher goroutine to avoid racing with reloads
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	// touching the file without changing its content is not a change
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, fs.Chtimes("/etc/app/config.yaml", later, later))
+
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected config change: %q", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: baz\n"), 0o644))
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "baz", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+}
+
+func TestDiffPolledFiles(t *testing.T) {
+	previous := map[string]polledFile{
+		"changed.yaml":   {size: 1, hash: [32]byte{1}},
+		"touched.yaml":   {size: 1, hash: [32]byte{2}},
+		"removed.yaml":   {size: 1, hash: [32]byte{3}},
+		"unchanged.yaml": {size: 1, hash: [32]byte{4}},
+	}
+	current := map[string]polledFile{
+		"changed.yaml":   {size: 1, hash: [32]byte{5}},
+		"touched.yaml":   {size: 1, modTime: time.Now(), hash: [32]byte{2}},
+		"created.yaml":   {size: 1, hash: [32]byte{6}},
+		"unchanged.yaml": {size: 1, hash: [32]byte{4}},
+	}
+
+	assert.Equal(t, []fsnotify.Event{
+		{Name: "/etc/app/changed.yaml", Op: fsnotify.Write},
+		{Name: "/etc/app/created.yaml", Op: fsnotify.Create},
+		{Name: "/etc/app/removed.yaml", Op: fsnotify.Remove},
+	}, diffPolledFiles("/etc/app", previous, current))
+}
+
+// fakeWatcher delivers the events sent by tests to WatchConfig.
+type fakeWatcher struct {
+	events chan fsnotify.Event
+	errors chan error
+
+	mu   sync.Mutex
+	dirs []string
+
+	closeOnce sync.Once
+}
+
+func newFakeWatcher() *fakeWatcher {
+	return &fakeWatcher{
+		events: make(chan fsnotify.Event),
+		errors: make(chan error),
+	}
+}
+
+func (w *fakeWatcher) Add(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.dirs = append(w.dirs, name)
+
+	return nil
+}
+
+func (w *fakeWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.events)
+		close(w.errors)
+	})
+
+	return nil
+}
+
+func (w *fakeWatcher) Events() <-chan fsnotify.Event { return w.events }
+func (w *fakeWatcher) Errors() <-chan error          { return w.errors }
+
+func TestWithFileWatcher(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: bar\n"), 0o644))
+
+	watcher := newFakeWatcher()
+	v := NewWithOptions(WithFileWatcher(watcher))
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+
+	assert.Equal(t, []string{"/etc/app/"}, watcher.dirs)
+
+	// events are delivered synchronously, so events for other files are handled before the next send returns
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: baz\n"), 0o644))
+	watcher.events <- fsnotify.Event{Name: "/etc/app/other.yaml", Op: fsnotify.Write}
+	watcher.events <- fsnotify.Event{Name: "/etc/app/config.yaml", Op: fsnotify.Write}
+
+	assert.Equal(t, "baz", <-changes)
+
+	// the watcher is closed once watching stops
+	v.StopWatch()
+
+	_, ok := <-watcher.Events()
+	assert.False(t, ok)
+	assert.Empty(t, changes)
+}
+
+func TestPauseWatch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: bar\n"), 0o644))
+
+	watcher := newFakeWatcher()
+	v := NewWithOptions(WithFileWatcher(watcher))
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	write := func(content string) {
+		t.Helper()
+
+		require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(content), 0o644))
+		watcher.events <- fsnotify.Event{Name: "/etc/app/config.yaml", Op: fsnotify.Write}
+	}
+
+	// events are received one at a time, so once an unrelated event is received the previous one was handled
+	flush := func() {
+		watcher.events <- fsnotify.Event{Name: "/etc/app/other.yaml", Op: fsnotify.Write}
+	}
+
+	// changes are held while paused, and read once on resume
+	v.PauseWatch()
+	write("foo: baz\n")
+	write("foo: qux\n")
+	flush()
+	assert.Empty(t, changes)
+
+	v.ResumeWatch(true)
+	assert.Equal(t, "qux", <-changes)
+
+	// changes are dropped when resuming without reloading
+	v.PauseWatch()
+	write("foo: dropped\n")
+	flush()
+	v.ResumeWatch(false)
+
+	write("foo: resumed\n")
+	assert.Equal(t, "resumed", <-changes)
+	assert.Empty(t, changes)
+}
+
+func TestWatchConfig_AtomicSave(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: bar\n"), 0o644))
+
+	watcher := newFakeWatcher()
+	v := NewWithOptions(WithFileWatcher(watcher))
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	send := func(name string, op fsnotify.Op) {
+		watcher.events <- fsnotify.Event{Name: name, Op: op}
+	}
+
+	// Vim: the config file is renamed to a backup, and the new version is written in its place
+	require.NoError(t, fs.Rename("/etc/app/config.yaml", "/etc/app/config.yaml~"))
+	send("/etc/app/config.yaml", fsnotify.Rename)
+	send("/etc/app/config.yaml~", fsnotify.Create)
+	assert.Empty(t, changes)
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: baz\n"), 0o644))
+	send("/etc/app/config.yaml", fsnotify.Create)
+	assert.Equal(t, "baz", <-changes)
+
+	require.NoError(t, fs.Remove("/etc/app/config.yaml~"))
+	send("/etc/app/config.yaml~", fsnotify.Remove)
+
+	// VS Code and others: the new version is written to a temporary file, renamed over the config file
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml.tmp", []byte("foo: qux\n"), 0o644))
+	send("/etc/app/config.yaml.tmp", fsnotify.Create)
+	require.NoError(t, fs.Rename("/etc/app/config.yaml.tmp", "/etc/app/config.yaml"))
+	send("/etc/app/config.yaml.tmp", fsnotify.Rename)
+	send("/etc/app/config.yaml", fsnotify.Create)
+	assert.Equal(t, "qux", <-changes)
+
+	// removing the config file does not stop watching
+	require.NoError(t, fs.Remove("/etc/app/config.yaml"))
+	send("/etc/app/config.yaml", fsnotify.Remove)
+	send("/etc/app/other.yaml", fsnotify.Write) // the previous event was handled once this one is received
+	assert.Empty(t, changes)
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: quux\n"), 0o644))
+	send("/etc/app/config.yaml", fsnotify.Create)
+	assert.Equal(t, "quux", <-changes)
+
+	// watched directories are watched again when replaced
+	send("/etc/app", fsnotify.Remove)
+	send("/etc/app/other.yaml", fsnotify.Write)
+	assert.Equal(t, []string{"/etc/app/", "/etc/app"}, watcher.dirs)
+	assert.Empty(t, changes)
+}
+
+func TestWatchConfig_RenameSave(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: bar\n"), 0o640))
+
+	v := New()
+	v.SetConfigFile(configFile)
+	require.NoError(t, v.ReadInConfig())
+
+	// settings are read from the watcher goroutine to avoid racing with reloads
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	waitFor := func(want string) {
+		t.Helper()
+
+		timeout := time.After(5 * time.Second)
+
+		for {
+			select {
+			case got := <-changes:
+				if got == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", want)
+			}
+		}
+	}
+
+	save := func(content string) {
+		t.Helper()
+
+		tmp := filepath.Join(dir, ".config.yaml.tmp")
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o640))
+		require.NoError(t, os.Rename(tmp, configFile))
+	}
+
+	// every save is picked up, not only the first one
+	save("foo: baz\n")
+	waitFor("baz")
+
+	save("foo: qux\n")
+	waitFor("qux")
+
+	// the config file is moved away before the new version is written
+	require.NoError(t, os.Rename(configFile, configFile+"~"))
+	require.NoError(t, os.WriteFile(configFile, []byte("foo: quux\n"), 0o640))
+	waitFor("quux")
+}
+
+func TestWatchConfig_SymlinkTarget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test as creating symlinks requires privileges on Windows")
+	}
+
+	configDir := t.TempDir()
+	realDir := t.TempDir()
+	realFile := filepath.Join(realDir, "real.yaml")
+	configFile := filepath.Join(configDir, "config.yaml")
+
+	require.NoError(t, os.WriteFile(realFile, []byte("foo: bar\n"), 0o640))
+	require.NoError(t, os.Symlink(realFile, configFile))
+
+	v := NewWithOptions(WithWatchDebounce(100 * time.Millisecond))
+	v.SetConfigFile(configFile)
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	// the real file is edited in place, outside of the directory of the config file
+	require.NoError(t, os.WriteFile(realFile, []byte("foo: baz\n"), 0o640))
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "baz", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+}
+
+func TestWithReloadRateLimit(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("foo: bar\n"), 0o644))
+
+	watcher := newFakeWatcher()
+	v := NewWithOptions(WithFileWatcher(watcher), WithReloadRateLimit(200*time.Millisecond, 1))
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	changes := make(chan string, 10)
+	v.OnConfigChange(func(_ fsnotify.Event) {
+		changes <- v.GetString("foo")
+	})
+	require.NoError(t, v.WatchConfigE())
+	t.Cleanup(v.StopWatch)
+
+	write := func(content string) {
+		t.Helper()
+
+		require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(content), 0o644))
+		watcher.events <- fsnotify.Event{Name: "/etc/app/config.yaml", Op: fsnotify.Write}
+	}
+
+	write("foo: one\n")
+	assert.Equal(t, "one", <-changes)
+
+	// changes within the interval are coalesced into a single reload
+	write("foo: two\n")
+	write("foo: three\n")
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, "three", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change")
+	}
+
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected config change: %q", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestReloadLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newReloadLimiter(time.Second, 2)
+
+	assert.Zero(t, limiter.reserve(now))
+	assert.Zero(t, limiter.reserve(now))
+	assert.Equal(t, time.Second, limiter.reserve(now))
+
+	// tokens are refilled over time
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve(now.Add(500*time.Millisecond)))
+	assert.Zero(t, limiter.reserve(now.Add(time.Second)))
+	assert.Equal(t, time.Second, limiter.reserve(now.Add(time.Second)))
+
+	// up to burst tokens are accumulated
+	later := now.Add(time.Hour)
+	assert.Zero(t, limiter.reserve(later))
+	assert.Zero(t, limiter.reserve(later))
+	assert.Equal(t, time.Second, limiter.reserve(later))
+
+	// no limit without an interval
+	assert.Nil(t, newReloadLimiter(0, 1))
+	assert.Zero(t, newReloadLimiter(0, 1).reserve(now))
+}
+
+// configMapVolume reproduces the layout of Kubernetes ConfigMap volumes:
+// config.yaml -> ..data/config.yaml, ..data -> ..<timestamp>.
+type configMapVolume struct {
+	t       *testing.T
+	dir     string
+	version int
+}
+
+func newConfigMapVolume(t *testing.T, content string) *configMapVolume {
+	t.Helper()
+
+	volume := &configMapVolume{t: t, dir: t.TempDir()}
+	volume.update(content)
+
+	require.NoError(t, os.Symlink(filepath.Join("..data", "config.yaml"), filepath.Join(volume.dir, "config.yaml")))
+
+	return volume
+}
+
+// update writes the config file the same way kubelet does.
+func (v *configMapVolume) update(content string) {
+	v.t.Helper()
+
+	previous := fmt.Sprintf("..%d", v.version)
+	v.version++
+	current := fmt.Sprintf("..%d", v.version)
+
+	require.NoError(v.t, os.Mkdir(filepath.Join(v.dir, current), 0o755))
+	require.NoError(v.t, os.WriteFile(filepath.Join(v.dir, current, "config.yaml"), []byte(content), 0o644))
+	require.NoError(v.t, os.Symlink(current, filepath.Join(v.dir, "..data_tmp")))
+	require.NoError(v.t, os.Rename(filepath.Join(v.dir, "..data_tmp"), filepath.Join(v.dir, "..data")))
+
+	if v.version > 1 {
+		require.NoError(v.t, os.RemoveAll(filepath.Join(v.dir, previous)))
+	}
+}
+
+func TestWatchConfig_KubernetesConfigMap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test as creating symlinks requires privileges on Windows")
+	}
+
+	for name, configFile := range map[string]string{
+		"ConfigFile": "config.yaml",
+		"DataLink":   filepath.Join("..data", "config.yaml"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			volume := newConfigMapVolume(t, "foo: bar\n")
+
+			v := New()
+			v.SetConfigFile(filepath.Join(volume.dir, configFile))
+			require.NoError(t, v.ReadInConfig())
+			require.Equal(t, "bar", v.GetString("foo"))
+
+			// settings are read from the watcher goroutine to avoid racing with reloads
+			changes := make(chan string, 10)
+			v.OnConfigChange(func(_ fsnotify.Event) {
+				changes <- v.GetString("foo")
+			})
+			require.NoError(t, v.WatchConfigE())
+			t.Cleanup(v.StopWatch)
+
+			waitFor := func(want string) {
+				t.Helper()
+
+				timeout := time.After(5 * time.Second)
+
+				for {
+					select {
+					case got := <-changes:
+						if got == want {
+							return
+						}
+					case <-timeout:
+						t.Fatalf("timed out waiting for %q", want)
+					}
+				}
+			}
+
+			// every update is picked up, not only the first one
+			volume.update("foo: baz\n")
+			waitFor("baz")
+
+			volume.update("foo: qux\n")
+			waitFor("qux")
+
+			volume.update("foo: quux\n")
+			waitFor("quux")
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package viper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// WriteEnv writes the current settings as environment variable assignments, one per line,
+// eg. to pass the configuration on to a child process:
+//
+//	PREFIX_SERVER_PORT=8080
+//	PREFIX_SERVER_NAME='my server'
+//
+// Names are made of the prefix and the key, upper cased, with characters other than letters and digits
+// replaced by underscores. Values are quoted for POSIX shells when needed.
+// Slices and maps are written as JSON (see WithJSONEnv), and keys without a value are omitted.
+func WriteEnv(w io.Writer, prefix string) error { return v.WriteEnv(w, prefix) }
+
+func (v *Viper) WriteEnv(w io.Writer, prefix string) error {
+	keys := v.AllKeys()
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		val := v.Get(key)
+		if val == nil {
+			continue
+		}
+
+		s, err := envString(val)
+		if err != nil {
+			return fmt.Errorf("write env %q: %w", key, err)
+		}
+
+		name := key
+		if prefix != "" {
+			name = prefix + "_" + key
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", envName(name), shellQuote(s)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envString formats a value as the value of an environment variable.
+func envString(val any) (string, error) {
+	switch val.(type) {
+	case []any, []string, []int, map[string]any, map[string]string:
+		b, err := json.Marshal(val)
+
+		return string(b), err
+	}
+
+	return cast.ToStringE(val)
+}
+
+// envName upper cases name and replaces characters other than letters and digits with underscores.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+
+		return '_'
+	}, name)
+}
+
+// shellQuote quotes s for POSIX shells, unless it only contains characters that need no quoting.
+func shellQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_@%+=:,./-", r))
+	}) < 0
+	if safe {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,37 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteEnv(t *testing.T) {
+	v := New()
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.name", "it's my server")
+	v.SetDefault("timeout", 5*time.Second)
+	v.SetDefault("hosts", []string{"a", "b"})
+	v.SetDefault("labels", map[string]any{"Team": "core"})
+	v.SetDefault("empty", "")
+	v.SetDefault("log-level", "debug")
+
+	var buf bytes.Buffer
+	require.NoError(t, v.WriteEnv(&buf, "app"))
+
+	assert.Equal(t, `APP_EMPTY=''
+APP_HOSTS='["a","b"]'
+APP_LABELS_TEAM=core
+APP_LOG_LEVEL=debug
+APP_SERVER_NAME='it'\''s my server'
+APP_SERVER_PORT=8080
+APP_TIMEOUT=5s
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, v.WriteEnv(&buf, ""))
+	assert.Contains(t, buf.String(), "SERVER_PORT=8080\n")
+}